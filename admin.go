@@ -0,0 +1,133 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// forceTransitionAttempts 為人工操作遇到 generation 衝突時的重試次數；人工指定的目標不依賴讀到的狀態，
+// 以最新的 generation 重試即可。
+const forceTransitionAttempts = 3
+
+func ParseConnectorType(s string) (ExchangeConnectorType, error) {
+	switch ExchangeConnectorType(s) {
+	case ExchangeConnectorTypeBinance, ExchangeConnectorTypeOKX:
+		return ExchangeConnectorType(s), nil
+	}
+	return "", fmt.Errorf("unknown connector %q", s)
+}
+
+func (proxy ExchangeApiProxyImpl) Status(ctx context.Context) (FailoverStatus, error) {
+	return proxy.state().Status(ctx)
+}
+
+// ForceSwitch 手動切換交易所。切到備援時會設定 LockTime，避免馬上被自動切回；
+// 切回主交易所時清除 LockTime 與主交易所的錯誤計數。
+func (proxy ExchangeApiProxyImpl) ForceSwitch(ctx context.Context, ct ExchangeConnectorType, operator, reason string) error {
+	if _, err := ParseConnectorType(ct.String()); err != nil {
+		return err
+	}
+	state := proxy.state()
+
+	lockTTL := proxy.config().LockTimeTTL
+	if ct == ExchangeConnectorTypeBinance {
+		lockTTL = -1
+	}
+	from, gen, err := proxy.forceTransition(ctx, state, ct, lockTTL)
+	if err != nil {
+		return err
+	}
+	if ct == ExchangeConnectorTypeBinance {
+		if _, err := state.ResetFailures(ctx, ExchangeConnectorTypeBinance); err != nil {
+			return err
+		}
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:       FailoverEventManualSwitch,
+		From:       from,
+		To:         ct,
+		Generation: gen,
+		Operator:   operator,
+		Reason:     reason,
+	})
+	return nil
+}
+
+// forceTransition 以 Transition 寫入人工指定的狀態，generation 衝突時重新讀取後重試。
+func (proxy ExchangeApiProxyImpl) forceTransition(ctx context.Context, state *StateStore, ct ExchangeConnectorType, lockTTL time.Duration) (from ExchangeConnectorType, gen int64, err error) {
+	return proxy.forceWrite(ctx, state, func(expected int64) (int64, error) {
+		return state.Transition(ctx, expected, ct, lockTTL)
+	})
+}
+
+// forceWrite 以讀到的 generation 執行人工操作的寫入，generation 衝突時重新讀取後重試，
+// 回傳寫入前的交易所與新的 generation。
+func (proxy ExchangeApiProxyImpl) forceWrite(ctx context.Context, state *StateStore, write func(expected int64) (int64, error)) (from ExchangeConnectorType, gen int64, err error) {
+	for i := 0; i < forceTransitionAttempts; i++ {
+		from, gen, err = state.ConnectorState(ctx)
+		if err != nil {
+			return "", 0, err
+		}
+		gen, err = write(gen)
+		if !errors.Is(err, ErrStaleGeneration) {
+			return from, gen, err
+		}
+	}
+	return "", 0, err
+}
+
+func (proxy ExchangeApiProxyImpl) ClearLock(ctx context.Context, operator, reason string) error {
+	state := proxy.state()
+	nowConnector, gen, err := state.ConnectorState(ctx)
+	if err != nil {
+		return err
+	}
+	// 清除 LockTime 會改變自動切回的判斷，同樣經過 Transition 遞增 generation
+	if gen, err = state.Transition(ctx, gen, connectorOrPrimary(nowConnector), -1); err != nil {
+		return err
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:       FailoverEventLockCleared,
+		From:       nowConnector,
+		To:         nowConnector,
+		Generation: gen,
+		Operator:   operator,
+		Reason:     reason,
+	})
+	return nil
+}
+
+// ResetCounters 清空指定交易所的錯誤計數，cts 為空時清空全部。
+func (proxy ExchangeApiProxyImpl) ResetCounters(ctx context.Context, operator, reason string, cts ...ExchangeConnectorType) error {
+	if len(cts) == 0 {
+		cts = []ExchangeConnectorType{ExchangeConnectorTypeBinance, ExchangeConnectorTypeOKX}
+	}
+	state := proxy.state()
+	nowConnector, err := state.Connector(ctx)
+	if err != nil {
+		return err
+	}
+
+	cleared := 0
+	for _, ct := range cts {
+		n, err := state.ResetFailures(ctx, ct)
+		if err != nil {
+			return err
+		}
+		cleared += n
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:        FailoverEventCountersReset,
+		From:        nowConnector,
+		To:          nowConnector,
+		WindowCount: cleared,
+		Operator:    operator,
+		Reason:      reason,
+	})
+	return nil
+}
@@ -0,0 +1,223 @@
+package failover
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	kgrpc "github.com/go-kratos/kratos/v2/transport/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "github.com/yourorg/exchange-failover/api/admin/v1"
+)
+
+const defaultWatchInterval = time.Second
+
+// AdminGRPCService 以 ExchangeApiProxyImpl 的狀態實作 FailoverAdmin gRPC service。
+type AdminGRPCService struct {
+	v1.UnimplementedFailoverAdminServer
+
+	proxy   ExchangeApiProxyImpl
+	options adminGRPCOptions
+}
+
+// NewAdminGRPCService 建立 FailoverAdmin service。修改狀態的 RPC（ForceSwitch、Pin、Unpin、ClearLock）
+// 需以 WithAdminGRPCAuthorizer 設定授權檢查，未設定時回傳 403；authorizer 依賴的身分由 gRPC server 的
+// 驗證 middleware 負責寫入 context。
+func NewAdminGRPCService(proxy ExchangeApiProxyImpl, opts ...AdminGRPCOption) *AdminGRPCService {
+	s := &AdminGRPCService{proxy: proxy}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
+}
+
+func RegisterAdminGRPCServer(srv *kgrpc.Server, proxy ExchangeApiProxyImpl, opts ...AdminGRPCOption) {
+	v1.RegisterFailoverAdminServer(srv, NewAdminGRPCService(proxy, opts...))
+}
+
+// authorize 與 HTTP 管理 API 的 guard 相同：未設定 authorizer 時只允許唯讀請求。
+func (s *AdminGRPCService) authorize(ctx context.Context, mutating bool) error {
+	if s.options.authorizer == nil {
+		if mutating {
+			return errors.Forbidden("ADMIN_AUTH_REQUIRED", "admin authorizer not configured")
+		}
+		return nil
+	}
+	if err := s.options.authorizer(ctx, mutating); err != nil {
+		return adminAuthError(err)
+	}
+	return nil
+}
+
+func (s *AdminGRPCService) GetStatus(ctx context.Context, req *v1.GetStatusRequest) (*v1.FailoverStatus, error) {
+	if err := s.authorize(ctx, false); err != nil {
+		return nil, err
+	}
+	return s.status(ctx)
+}
+
+func (s *AdminGRPCService) ForceSwitch(ctx context.Context, req *v1.ForceSwitchRequest) (*v1.FailoverStatus, error) {
+	if err := s.authorize(ctx, true); err != nil {
+		return nil, err
+	}
+	ct, err := ParseConnectorType(req.GetConnector())
+	if err != nil {
+		return nil, errors.BadRequest("INVALID_CONNECTOR", err.Error())
+	}
+	if err := s.proxy.ForceSwitch(ctx, ct, req.GetOperator(), req.GetReason()); err != nil {
+		return nil, stateError(err)
+	}
+	return s.status(ctx)
+}
+
+func (s *AdminGRPCService) Pin(ctx context.Context, req *v1.PinRequest) (*v1.FailoverStatus, error) {
+	if err := s.authorize(ctx, true); err != nil {
+		return nil, err
+	}
+	ct, err := ParseConnectorType(req.GetConnector())
+	if err != nil {
+		return nil, errors.BadRequest("INVALID_CONNECTOR", err.Error())
+	}
+	if req.GetTtl().AsDuration() <= 0 {
+		return nil, errors.BadRequest("INVALID_TTL", "ttl must be positive")
+	}
+	if err := s.proxy.Pin(ctx, ct, req.GetTtl().AsDuration(), req.GetOperator(), req.GetReason()); err != nil {
+		return nil, stateError(err)
+	}
+	return s.status(ctx)
+}
+
+func (s *AdminGRPCService) Unpin(ctx context.Context, req *v1.UnpinRequest) (*v1.FailoverStatus, error) {
+	if err := s.authorize(ctx, true); err != nil {
+		return nil, err
+	}
+	if err := s.proxy.Unpin(ctx, req.GetOperator(), req.GetReason()); err != nil {
+		return nil, stateError(err)
+	}
+	return s.status(ctx)
+}
+
+func (s *AdminGRPCService) ClearLock(ctx context.Context, req *v1.ClearLockRequest) (*v1.FailoverStatus, error) {
+	if err := s.authorize(ctx, true); err != nil {
+		return nil, err
+	}
+	if err := s.proxy.ClearLock(ctx, req.GetOperator(), req.GetReason()); err != nil {
+		return nil, stateError(err)
+	}
+	return s.status(ctx)
+}
+
+func (s *AdminGRPCService) ListEvents(ctx context.Context, req *v1.ListEventsRequest) (*v1.ListEventsReply, error) {
+	if err := s.authorize(ctx, false); err != nil {
+		return nil, err
+	}
+	filter := EventFilter{Limit: req.GetLimit()}
+	if req.GetSince() != nil {
+		filter.Since = req.GetSince().AsTime()
+	}
+	if req.GetUntil() != nil {
+		filter.Until = req.GetUntil().AsTime()
+	}
+	for _, t := range req.GetTypes() {
+		filter.Types = append(filter.Types, FailoverEventType(t))
+	}
+
+	events, err := s.proxy.Events(ctx, filter)
+	if err != nil {
+		log.Errorf("admin list events error: %v", err)
+		return nil, errors.InternalServer("JOURNAL_UNAVAILABLE", "event journal unavailable")
+	}
+	reply := &v1.ListEventsReply{}
+	for _, event := range events {
+		reply.Events = append(reply.Events, &v1.FailoverEvent{
+			Id:          event.ID,
+			Type:        event.Type.String(),
+			From:        event.From.String(),
+			To:          event.To.String(),
+			Codes:       event.Codes,
+			Methods:     event.Methods,
+			InstanceId:  event.InstanceID,
+			WindowCount: int64(event.WindowCount),
+			Generation:  event.Generation,
+			Operator:    event.Operator,
+			Reason:      event.Reason,
+			Timestamp:   timestamppb.New(event.Timestamp),
+		})
+	}
+	return reply, nil
+}
+
+func (s *AdminGRPCService) WatchStatus(req *v1.WatchStatusRequest, stream v1.FailoverAdmin_WatchStatusServer) error {
+	interval := req.GetInterval().AsDuration()
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := stream.Context()
+	if err := s.authorize(ctx, false); err != nil {
+		return err
+	}
+	last := ""
+	for {
+		status, err := s.proxy.Status(ctx)
+		if err != nil {
+			return stateError(err)
+		}
+		if key := status.Fingerprint(); key != last {
+			if err := stream.Send(statusToProto(status)); err != nil {
+				return err
+			}
+			last = key
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AdminGRPCService) status(ctx context.Context) (*v1.FailoverStatus, error) {
+	status, err := s.proxy.Status(ctx)
+	if err != nil {
+		return nil, stateError(err)
+	}
+	return statusToProto(status), nil
+}
+
+func statusToProto(status FailoverStatus) *v1.FailoverStatus {
+	out := &v1.FailoverStatus{
+		Connector:  status.Connector.String(),
+		Locked:     status.Locked,
+		Generation: status.Generation,
+	}
+	if status.LockExpiresAt != nil {
+		out.LockExpiresAt = timestamppb.New(*status.LockExpiresAt)
+	}
+	if status.Pin != nil {
+		out.Pin = &v1.ConnectorPin{
+			Connector: status.Pin.Connector.String(),
+			Operator:  status.Pin.Operator,
+			Reason:    status.Pin.Reason,
+			CreatedAt: timestamppb.New(status.Pin.CreatedAt),
+			ExpiresAt: timestamppb.New(status.Pin.ExpiresAt),
+		}
+	}
+	for _, ct := range []ExchangeConnectorType{ExchangeConnectorTypeBinance, ExchangeConnectorTypeOKX} {
+		window := &v1.FailureWindow{Connector: ct.String()}
+		for _, record := range status.FailureWindows[ct] {
+			window.Records = append(window.Records, &v1.FailureRecord{
+				Code:   record.Code,
+				Method: record.Method,
+				At:     timestamppb.New(record.At),
+			})
+		}
+		out.FailureWindows = append(out.FailureWindows, window)
+	}
+	return out
+}
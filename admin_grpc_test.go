@@ -0,0 +1,58 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+
+	v1 "github.com/yourorg/exchange-failover/api/admin/v1"
+)
+
+func TestAdminGRPCMutationsRequireAuthorizer(t *testing.T) {
+	ctx := context.Background()
+	ft := newFailoverTest(t)
+	req := &v1.ForceSwitchRequest{Connector: "OKX", Operator: "alice"}
+
+	s := NewAdminGRPCService(ft.proxy)
+	if _, err := s.GetStatus(ctx, &v1.GetStatusRequest{}); err != nil {
+		t.Fatalf("read without authorizer: %v", err)
+	}
+	_, err := s.ForceSwitch(ctx, req)
+	if se := errors.FromError(err); se.Code != 403 || se.Reason != "ADMIN_AUTH_REQUIRED" {
+		t.Fatalf("err = %v, want 403 ADMIN_AUTH_REQUIRED", err)
+	}
+
+	denied := NewAdminGRPCService(ft.proxy, WithAdminGRPCAuthorizer(func(ctx context.Context, mutating bool) error {
+		if mutating {
+			return fmt.Errorf("token expired")
+		}
+		return nil
+	}))
+	_, err = denied.ForceSwitch(ctx, req)
+	if se := errors.FromError(err); se.Code != 403 || se.Message == "token expired" {
+		t.Fatalf("err = %v, want 403 without authorizer detail", err)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("connector = %v after denied switch", ct)
+	}
+
+	allowed := NewAdminGRPCService(ft.proxy, WithAdminGRPCAuthorizer(func(context.Context, bool) error { return nil }))
+	status, err := allowed.ForceSwitch(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.GetConnector() != "OKX" {
+		t.Fatalf("connector = %v, want OKX", status.GetConnector())
+	}
+}
+
+func TestAdminGRPCListEventsHidesJournalError(t *testing.T) {
+	s := NewAdminGRPCService(ExchangeApiProxyImpl{})
+	_, err := s.ListEvents(context.Background(), &v1.ListEventsRequest{})
+	se := errors.FromError(err)
+	if se.Reason != "JOURNAL_UNAVAILABLE" || se.Message != "event journal unavailable" {
+		t.Fatalf("err = %v, want fixed JOURNAL_UNAVAILABLE message", err)
+	}
+}
@@ -0,0 +1,200 @@
+package failover
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+type adminRequest struct {
+	Connector string `json:"connector"`
+	TTL       string `json:"ttl"`
+	Operator  string `json:"operator"`
+	Reason    string `json:"reason"`
+}
+
+// RegisterAdminHTTPServer 在 Kratos HTTP server 的 prefix 下掛載管理 API：
+//
+//	GET    {prefix}/status    目前交易所、LockTime 到期時間與各交易所錯誤視窗
+//	POST   {prefix}/switch    強制切換，body: {"connector","operator","reason"}
+//	POST   {prefix}/pin       固定交易所，body: {"connector","ttl":"2h","operator","reason"}
+//	DELETE {prefix}/pin       解除固定
+//	DELETE {prefix}/lock      清除 LockTime
+//	DELETE {prefix}/failures  清空錯誤計數，?connector= 可指定交易所
+//	GET    {prefix}/events    事件紀錄，?since=&until=（RFC3339）&type=&limit=
+//
+// 修改狀態的 API 需以 WithAdminAuthorizer 設定授權檢查，未設定時回傳 403。
+func RegisterAdminHTTPServer(srv *khttp.Server, prefix string, proxy ExchangeApiProxyImpl, opts ...AdminHTTPOption) {
+	options := adminHTTPOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	read, write := options.guard(false), options.guard(true)
+
+	r := srv.Route(prefix)
+	r.GET("/status", read(func(ctx khttp.Context) error {
+		return adminStatusResult(ctx, proxy)
+	}))
+
+	r.POST("/switch", write(func(ctx khttp.Context) error {
+		req := adminRequest{}
+		if err := ctx.Bind(&req); err != nil {
+			return errors.BadRequest("INVALID_BODY", err.Error())
+		}
+		ct, err := ParseConnectorType(req.Connector)
+		if err != nil {
+			return errors.BadRequest("INVALID_CONNECTOR", err.Error())
+		}
+		if err := proxy.ForceSwitch(ctx, ct, req.Operator, req.Reason); err != nil {
+			return stateError(err)
+		}
+		return adminStatusResult(ctx, proxy)
+	}))
+
+	r.POST("/pin", write(func(ctx khttp.Context) error {
+		req := adminRequest{}
+		if err := ctx.Bind(&req); err != nil {
+			return errors.BadRequest("INVALID_BODY", err.Error())
+		}
+		ct, err := ParseConnectorType(req.Connector)
+		if err != nil {
+			return errors.BadRequest("INVALID_CONNECTOR", err.Error())
+		}
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			return errors.BadRequest("INVALID_TTL", fmt.Sprintf("invalid ttl %q", req.TTL))
+		}
+		if err := proxy.Pin(ctx, ct, ttl, req.Operator, req.Reason); err != nil {
+			return stateError(err)
+		}
+		return adminStatusResult(ctx, proxy)
+	}))
+
+	r.DELETE("/pin", write(func(ctx khttp.Context) error {
+		req := adminRequest{Operator: ctx.Query().Get("operator"), Reason: ctx.Query().Get("reason")}
+		if err := proxy.Unpin(ctx, req.Operator, req.Reason); err != nil {
+			return stateError(err)
+		}
+		return adminStatusResult(ctx, proxy)
+	}))
+
+	r.DELETE("/lock", write(func(ctx khttp.Context) error {
+		req := adminRequest{Operator: ctx.Query().Get("operator"), Reason: ctx.Query().Get("reason")}
+		if err := proxy.ClearLock(ctx, req.Operator, req.Reason); err != nil {
+			return stateError(err)
+		}
+		return adminStatusResult(ctx, proxy)
+	}))
+
+	r.DELETE("/failures", write(func(ctx khttp.Context) error {
+		req := adminRequest{
+			Connector: ctx.Query().Get("connector"),
+			Operator:  ctx.Query().Get("operator"),
+			Reason:    ctx.Query().Get("reason"),
+		}
+		cts := []ExchangeConnectorType{}
+		if req.Connector != "" {
+			ct, err := ParseConnectorType(req.Connector)
+			if err != nil {
+				return errors.BadRequest("INVALID_CONNECTOR", err.Error())
+			}
+			cts = append(cts, ct)
+		}
+		if err := proxy.ResetCounters(ctx, req.Operator, req.Reason, cts...); err != nil {
+			return stateError(err)
+		}
+		return adminStatusResult(ctx, proxy)
+	}))
+
+	r.GET("/events", read(func(ctx khttp.Context) error {
+		filter, err := parseEventFilter(ctx)
+		if err != nil {
+			return errors.BadRequest("INVALID_QUERY", err.Error())
+		}
+		events, err := proxy.Events(ctx, filter)
+		if err != nil {
+			log.Errorf("admin list events error: %v", err)
+			return errors.InternalServer("JOURNAL_UNAVAILABLE", "event journal unavailable")
+		}
+		return ctx.JSON(http.StatusOK, map[string]interface{}{"events": events})
+	}))
+}
+
+func adminStatusResult(ctx khttp.Context, proxy ExchangeApiProxyImpl) error {
+	status, err := proxy.Status(ctx)
+	if err != nil {
+		return stateError(err)
+	}
+	return ctx.JSON(http.StatusOK, status)
+}
+
+func parseEventFilter(ctx khttp.Context) (EventFilter, error) {
+	query := ctx.Query()
+	filter := EventFilter{Limit: 100}
+	if v := query.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return EventFilter{}, err
+		}
+		filter.Since = t
+	}
+	if v := query.Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return EventFilter{}, err
+		}
+		filter.Until = t
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return EventFilter{}, err
+		}
+		filter.Limit = limit
+	}
+	for _, t := range query["type"] {
+		filter.Types = append(filter.Types, FailoverEventType(t))
+	}
+	return filter, nil
+}
+
+// stateError 將狀態讀寫的錯誤轉成 Kratos error，generation 衝突時回傳 409。
+// Redis 等內部錯誤只寫 log，回應使用固定訊息。
+func stateError(err error) error {
+	if errors.Is(err, ErrStaleGeneration) {
+		return errors.Conflict("STATE_CONFLICT", "failover state changed concurrently, retry with the latest status")
+	}
+	log.Errorf("admin state error: %v", err)
+	return errors.InternalServer("STATE_UNAVAILABLE", "failover state unavailable")
+}
+
+// guard 回傳在 handler 前執行授權檢查的 wrapper；未設定 authorizer 時只允許唯讀請求。
+func (o adminHTTPOptions) guard(mutating bool) func(khttp.HandlerFunc) khttp.HandlerFunc {
+	return func(h khttp.HandlerFunc) khttp.HandlerFunc {
+		return func(ctx khttp.Context) error {
+			if o.authorizer == nil {
+				if mutating {
+					return errors.Forbidden("ADMIN_AUTH_REQUIRED", "admin authorizer not configured")
+				}
+				return h(ctx)
+			}
+			if err := o.authorizer(ctx, mutating); err != nil {
+				return adminAuthError(err)
+			}
+			return h(ctx)
+		}
+	}
+}
+
+// adminAuthError 原樣回傳 authorizer 的 Kratos error，其他錯誤不回傳內容，一律 403。
+func adminAuthError(err error) error {
+	if se := new(errors.Error); errors.As(err, &se) {
+		return se
+	}
+	return errors.Forbidden("FORBIDDEN", "forbidden")
+}
@@ -0,0 +1,114 @@
+package failover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+func adminHTTPRequest(t *testing.T, ft *failoverTest, method, path, body string, opts ...AdminHTTPOption) *httptest.ResponseRecorder {
+	t.Helper()
+	srv := khttp.NewServer()
+	RegisterAdminHTTPServer(srv, "/admin", ft.proxy, opts...)
+	req := httptest.NewRequest(method, "/admin"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminHTTPAuthorizerDenies(t *testing.T) {
+	switchBody := `{"connector":"OKX","operator":"alice","reason":"test"}`
+	cases := []struct {
+		name       string
+		opts       []AdminHTTPOption
+		method     string
+		path       string
+		wantCode   int
+		wantReason string
+	}{
+		{
+			name:       "no authorizer",
+			method:     http.MethodPost,
+			path:       "/switch",
+			wantCode:   http.StatusForbidden,
+			wantReason: "ADMIN_AUTH_REQUIRED",
+		},
+		{
+			name: "plain error",
+			opts: []AdminHTTPOption{WithAdminAuthorizer(func(khttp.Context, bool) error {
+				return fmt.Errorf("token expired")
+			})},
+			method:     http.MethodPost,
+			path:       "/switch",
+			wantCode:   http.StatusForbidden,
+			wantReason: "FORBIDDEN",
+		},
+		{
+			name: "kratos error",
+			opts: []AdminHTTPOption{WithAdminAuthorizer(func(khttp.Context, bool) error {
+				return errors.Unauthorized("TOKEN_MISSING", "missing token")
+			})},
+			method:     http.MethodDelete,
+			path:       "/lock",
+			wantCode:   http.StatusUnauthorized,
+			wantReason: "TOKEN_MISSING",
+		},
+		{
+			name: "read denied",
+			opts: []AdminHTTPOption{WithAdminAuthorizer(func(_ khttp.Context, mutating bool) error {
+				return fmt.Errorf("mutating=%v", mutating)
+			})},
+			method:     http.MethodGet,
+			path:       "/status",
+			wantCode:   http.StatusForbidden,
+			wantReason: "FORBIDDEN",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ft := newFailoverTest(t)
+			rec := adminHTTPRequest(t, ft, c.method, c.path, switchBody, c.opts...)
+			if rec.Code != c.wantCode {
+				t.Fatalf("status = %v, want %v: %s", rec.Code, c.wantCode, rec.Body)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, c.wantReason) || strings.Contains(body, "token expired") {
+				t.Fatalf("body = %s, want reason %v without authorizer detail", body, c.wantReason)
+			}
+			if ct, gen := ft.connector(t); ct != ExchangeConnectorTypeBinance || gen != 0 {
+				t.Fatalf("state changed after denied request: %v/%v", ct, gen)
+			}
+		})
+	}
+}
+
+func TestAdminHTTPReadWithoutAuthorizer(t *testing.T) {
+	ft := newFailoverTest(t)
+	if rec := adminHTTPRequest(t, ft, http.MethodGet, "/status", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status = %v, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAdminHTTPAuthorizerAllows(t *testing.T) {
+	ft := newFailoverTest(t)
+	var mutations []bool
+	rec := adminHTTPRequest(t, ft, http.MethodPost, "/switch", `{"connector":"OKX","operator":"alice"}`,
+		WithAdminAuthorizer(func(_ khttp.Context, mutating bool) error {
+			mutations = append(mutations, mutating)
+			return nil
+		}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %v, want 200: %s", rec.Code, rec.Body)
+	}
+	if len(mutations) != 1 || !mutations[0] {
+		t.Fatalf("authorizer calls = %v, want [true]", mutations)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeOKX)
+	}
+}
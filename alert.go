@@ -0,0 +1,204 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+type AlertSeverity string
+
+const (
+	AlertSeverityInfo     AlertSeverity = "info"
+	AlertSeverityWarning  AlertSeverity = "warning"
+	AlertSeverityCritical AlertSeverity = "critical"
+)
+
+func (s AlertSeverity) String() string {
+	return string(s)
+}
+
+// Alert 為結構化的告警內容，sink 可依 Severity、Group 分流或組出 dashboard 連結；
+// Message 為依 Config.AlertLocale 範本產生的文字。
+type Alert struct {
+	Kind     AlertKind         `json:"kind"`
+	Type     AlertTemplateName `json:"type"`
+	Severity AlertSeverity     `json:"severity"`
+	Source   string            `json:"source"`
+	Group    string            `json:"group,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+
+	From         ExchangeConnectorType `json:"from,omitempty"`
+	To           ExchangeConnectorType `json:"to,omitempty"`
+	FailureCodes []string              `json:"failureCodes,omitempty"`
+	Methods      []string              `json:"methods,omitempty"`
+	FailureCount int                   `json:"failureCount,omitempty"`
+	Generation   int64                 `json:"generation,omitempty"`
+	// OutageStart 為這次異常開始的時間（切換、降級與對應的切回告警），Duration 只在切回類告警有值
+	OutageStart *time.Time    `json:"outageStart,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+
+	Operator string    `json:"operator,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Message  string    `json:"message"`
+	At       time.Time `json:"at"`
+}
+
+// AlertSink 接收結構化告警。
+type AlertSink interface {
+	SendAlert(ctx context.Context, alert Alert) error
+}
+
+// IRecoveryMessageAlertService 為可附帶內容的切回告警；IAlertService 有實作時，切回告警會帶上範本產生的內容，
+// 否則維持呼叫 SendRecoveryAlert(source)。
+type IRecoveryMessageAlertService interface {
+	SendRecoveryMessage(source, msg string) error
+}
+
+// AlertServiceSink 把結構化告警轉給既有的 IAlertService，只保留 source 與 Message。
+type AlertServiceSink struct {
+	Service IAlertService
+}
+
+func NewAlertServiceSink(s IAlertService) AlertServiceSink {
+	return AlertServiceSink{Service: s}
+}
+
+func (s AlertServiceSink) SendAlert(_ context.Context, alert Alert) error {
+	if alert.Kind != AlertKindRecovery {
+		return s.Service.SendErrorAlert(alert.Source, alert.Message)
+	}
+	if r, ok := s.Service.(IRecoveryMessageAlertService); ok && alert.Message != "" {
+		return r.SendRecoveryMessage(alert.Source, alert.Message)
+	}
+	return s.Service.SendRecoveryAlert(alert.Source)
+}
+
+// legacyAlert 把 IAlertService 形式的呼叫轉成 Alert，讓 sink 同時實作兩種介面。
+func legacyAlert(kind AlertKind, source, msg string) Alert {
+	severity := AlertSeverityCritical
+	if kind == AlertKindRecovery {
+		severity = AlertSeverityInfo
+	}
+	return Alert{
+		Kind:     kind,
+		Severity: severity,
+		Source:   source,
+		Message:  msg,
+		At:       time.Now(),
+	}
+}
+
+// alertTitle 為告警的一行摘要，例如 "[critical] switch Binance"。
+func alertTitle(alert Alert) string {
+	kind := string(alert.Type)
+	if kind == "" {
+		kind = alert.Kind.String()
+	}
+	return fmt.Sprintf("[%v] %v %v", alert.Severity, kind, alert.Source)
+}
+
+// alertContext 為告警的來源資訊，例如 "instance i-1 · generation 3 · 2024-01-01T00:00:00Z"。
+func alertContext(alert Alert) string {
+	parts := []string{}
+	if alert.Instance != "" {
+		parts = append(parts, "instance "+alert.Instance)
+	}
+	if alert.Generation != 0 {
+		parts = append(parts, fmt.Sprintf("generation %d", alert.Generation))
+	}
+	if !alert.At.IsZero() {
+		parts = append(parts, alert.At.Format(time.RFC3339))
+	}
+	return strings.Join(parts, " · ")
+}
+
+var alertKinds = map[AlertTemplateName]AlertKind{
+	AlertTemplateRecovery:       AlertKindRecovery,
+	AlertTemplateMaintenanceEnd: AlertKindRecovery,
+	AlertTemplateDegradedExit:   AlertKindRecovery,
+}
+
+var alertSeverities = map[AlertTemplateName]AlertSeverity{
+	AlertTemplateSwitch:                 AlertSeverityCritical,
+	AlertTemplateDegradedEnter:          AlertSeverityCritical,
+	AlertTemplateDegradedSwitch:         AlertSeverityCritical,
+	AlertTemplateMaintenanceProbeFailed: AlertSeverityWarning,
+	AlertTemplateDigest:                 AlertSeverityWarning,
+}
+
+func alertKindOf(name AlertTemplateName) AlertKind {
+	if kind, ok := alertKinds[name]; ok {
+		return kind
+	}
+	return AlertKindError
+}
+
+func alertSeverityOf(name AlertTemplateName) AlertSeverity {
+	if severity, ok := alertSeverities[name]; ok {
+		return severity
+	}
+	return AlertSeverityInfo
+}
+
+// alertSink 回傳告警目標；直接建立 ExchangeApiProxyImpl 只設定 AlertService 時以 AlertServiceSink 包裝。
+func (proxy ExchangeApiProxyImpl) alertSink() AlertSink {
+	if proxy.AlertSink != nil {
+		return proxy.AlertSink
+	}
+	if proxy.AlertService != nil {
+		return NewAlertServiceSink(proxy.AlertService)
+	}
+	return nil
+}
+
+func (proxy ExchangeApiProxyImpl) sendAlert(ctx context.Context, name AlertTemplateName, source string, data AlertData) {
+	sink := proxy.alertSink()
+	if sink == nil {
+		return
+	}
+	cfg := proxy.config()
+	alert := Alert{
+		Kind:         alertKindOf(name),
+		Type:         name,
+		Severity:     alertSeverityOf(name),
+		Source:       source,
+		Group:        cfg.Group,
+		Instance:     cfg.InstanceID,
+		From:         data.From,
+		To:           data.To,
+		FailureCodes: data.Codes,
+		Methods:      data.Methods,
+		FailureCount: data.WindowCount,
+		Generation:   data.Generation,
+		Duration:     data.OutageDuration,
+		Operator:     data.Operator,
+		Reason:       data.Reason,
+		Message:      proxy.renderAlert(name, source, data),
+		At:           time.Now(),
+	}
+	if !data.OutageStart.IsZero() {
+		alert.OutageStart = &data.OutageStart
+	}
+	// 告警不隨觸發的請求取消，逾時由各 sink 自己的設定（HTTP、SMTP、AlertRouter）控制
+	if innerErr := sink.SendAlert(detachContext(ctx), alert); innerErr != nil {
+		log.Infof("HandleRequestAlert error: %v", innerErr)
+	}
+}
+
+// detachedContext 保留 parent 的值（trace span 等），但不繼承取消與 deadline。
+type detachedContext struct {
+	parent context.Context
+}
+
+func detachContext(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
@@ -0,0 +1,164 @@
+package failover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AlertHTTPConfig 為以 HTTP 送出告警的 sink 共用的設定。
+type AlertHTTPConfig struct {
+	// Timeout 為單次請求的逾時
+	Timeout time.Duration
+	// Retries 為失敗後的重試次數，只重試連線錯誤、429 與 5xx；設為負數時不重試
+	Retries int
+	// RetryBackoff 為第一次重試前的等待，之後每次加倍；429 帶有 Retry-After 時以其為準
+	RetryBackoff time.Duration
+	// MaxRetryAfter 為 Retry-After（或 Telegram retry_after）採用的上限
+	MaxRetryAfter time.Duration
+	// MaxElapsed 為含重試在內送出一則告警的總時間上限，剩餘時間不足以等待下一次重試時直接回傳錯誤；
+	// 告警在切換的呼叫路徑上同步送出，不希望阻塞時改用 AlertRouter 的佇列
+	MaxElapsed time.Duration
+	// Transport 可換成測試用的 RoundTripper，預設為 http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+var DefaultAlertHTTPConfig = AlertHTTPConfig{
+	Timeout:       5 * time.Second,
+	Retries:       2,
+	RetryBackoff:  500 * time.Millisecond,
+	MaxRetryAfter: 5 * time.Second,
+	MaxElapsed:    10 * time.Second,
+}
+
+func (c AlertHTTPConfig) withDefaults() AlertHTTPConfig {
+	if c.Timeout == 0 {
+		c.Timeout = DefaultAlertHTTPConfig.Timeout
+	}
+	if c.Retries == 0 {
+		c.Retries = DefaultAlertHTTPConfig.Retries
+	}
+	if c.RetryBackoff == 0 {
+		c.RetryBackoff = DefaultAlertHTTPConfig.RetryBackoff
+	}
+	if c.MaxRetryAfter == 0 {
+		c.MaxRetryAfter = DefaultAlertHTTPConfig.MaxRetryAfter
+	}
+	if c.MaxElapsed == 0 {
+		c.MaxElapsed = DefaultAlertHTTPConfig.MaxElapsed
+	}
+	if c.Transport == nil {
+		c.Transport = http.DefaultTransport
+	}
+	return c
+}
+
+// alertHTTPError 為告警服務回傳的非 2xx 回應。
+type alertHTTPError struct {
+	StatusCode int
+	Body       string
+	retryAfter time.Duration
+}
+
+func (e *alertHTTPError) Error() string {
+	return fmt.Sprintf("alert endpoint returned %d: %v", e.StatusCode, e.Body)
+}
+
+func (e *alertHTTPError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+type sinkRetriesKey struct{}
+
+// withoutSinkRetries 標記由外層（AlertRouter）負責重試，postAlert 只送一次。
+func withoutSinkRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sinkRetriesKey{}, false)
+}
+
+func sinkRetries(ctx context.Context, retries int) int {
+	if enabled, ok := ctx.Value(sinkRetriesKey{}).(bool); ok && !enabled {
+		return 0
+	}
+	if retries < 0 {
+		return 0
+	}
+	return retries
+}
+
+// postAlert 以 POST 送出 body，失敗時依 cfg 重試；header 在每次請求都會帶上。
+func postAlert(ctx context.Context, cfg AlertHTTPConfig, url string, body []byte, header http.Header) error {
+	return postAlertWith(ctx, cfg, url, body, header, nil)
+}
+
+// postAlertWith 同 postAlert，retryAfter 可從回應內容解析等待時間（例如 Telegram 的 parameters.retry_after）。
+func postAlertWith(ctx context.Context, cfg AlertHTTPConfig, url string, body []byte, header http.Header, retryAfter func(body string) time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.MaxElapsed)
+	defer cancel()
+	client := &http.Client{Timeout: cfg.Timeout, Transport: cfg.Transport}
+	backoff := cfg.RetryBackoff
+	retries := sinkRetries(ctx, cfg.Retries)
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			wait := backoff
+			if httpErr, ok := err.(*alertHTTPError); ok && httpErr.retryAfter > 0 {
+				wait = httpErr.retryAfter
+			}
+			if wait > cfg.MaxRetryAfter {
+				wait = cfg.MaxRetryAfter
+			}
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			backoff *= 2
+		}
+		err = postAlertOnce(ctx, client, url, body, header)
+		if err == nil {
+			return nil
+		}
+		if httpErr, ok := err.(*alertHTTPError); ok {
+			if !httpErr.retryable() {
+				return err
+			}
+			if retryAfter != nil && httpErr.retryAfter == 0 {
+				httpErr.retryAfter = retryAfter(httpErr.Body)
+			}
+		}
+	}
+	return err
+}
+
+func postAlertOnce(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, values := range header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	httpErr := &alertHTTPError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		httpErr.retryAfter = time.Duration(seconds) * time.Second
+	}
+	return httpErr
+}
@@ -0,0 +1,177 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testAlert() Alert {
+	return Alert{
+		Kind:         AlertKindError,
+		Type:         AlertTemplateSwitch,
+		Severity:     AlertSeverityCritical,
+		Source:       "Binance",
+		Group:        "default",
+		From:         ExchangeConnectorTypeBinance,
+		To:           ExchangeConnectorTypeOKX,
+		FailureCodes: []string{"503"},
+		Message:      "Binance <down> & switched",
+	}
+}
+
+func TestWebhookSinkSignsBody(t *testing.T) {
+	const secret = "s3cret"
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts := r.Header.Get(DefaultWebhookTimestampHeader)
+		want := "sha256=" + SignWebhook(secret, ts, body)
+		if ts == "" || r.Header.Get(DefaultWebhookSignatureHeader) != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Team") != "trading" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: srv.URL, Secret: secret, Headers: map[string]string{"X-Team": "trading"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.SendAlert(context.Background(), testAlert()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if got.Message != testAlert().Message || got.To != ExchangeConnectorTypeOKX {
+		t.Fatalf("received %+v", got)
+	}
+}
+
+func TestWebhookSinkPayloadTemplate(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: srv.URL, PayloadTemplate: `{"summary": {{json .Message}}, "severity": "{{.Severity}}"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.SendAlert(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	payload := map[string]string{}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		t.Fatalf("payload %q is not JSON: %v", body, err)
+	}
+	if payload["summary"] != testAlert().Message || payload["severity"] != "critical" {
+		t.Fatalf("payload = %v", payload)
+	}
+}
+
+func TestSlackSinkMessage(t *testing.T) {
+	var msg slackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+	}))
+	defer srv.Close()
+
+	sink, err := NewSlackSink(SlackConfig{WebhookURL: srv.URL, Channel: "#alerts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.SendAlert(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Channel != "#alerts" || msg.Text != "Binance &lt;down&gt; &amp; switched" {
+		t.Fatalf("message = %+v", msg)
+	}
+	if len(msg.Blocks) < 3 || !strings.HasPrefix(msg.Blocks[0].Text.Text, ":red_circle:") {
+		t.Fatalf("blocks = %+v", msg.Blocks)
+	}
+}
+
+func TestPostAlertRetriesServerErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := AlertHTTPConfig{RetryBackoff: time.Millisecond}.withDefaults()
+	if err := postAlert(context.Background(), cfg, srv.URL, []byte("{}"), nil); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestPostAlertDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	cfg := AlertHTTPConfig{RetryBackoff: time.Millisecond}.withDefaults()
+	if err := postAlert(context.Background(), cfg, srv.URL, []byte("{}"), nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestPostAlertClampsRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := AlertHTTPConfig{MaxRetryAfter: 20 * time.Millisecond}.withDefaults()
+	start := time.Now()
+	if err := postAlert(context.Background(), cfg, srv.URL, []byte("{}"), nil); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("waited %v for Retry-After", elapsed)
+	}
+}
+
+func TestPostAlertBoundedByMaxElapsed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	cfg := AlertHTTPConfig{MaxElapsed: 100 * time.Millisecond, Retries: 5}.withDefaults()
+	start := time.Now()
+	err := postAlert(context.Background(), cfg, srv.URL, []byte("{}"), nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("took %v, want within MaxElapsed", elapsed)
+	}
+}
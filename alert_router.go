@@ -0,0 +1,291 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var ErrAlertRouterClosed = errors.New("alert router closed")
+
+var alertSeverityRank = map[AlertSeverity]int{
+	AlertSeverityInfo:     1,
+	AlertSeverityWarning:  2,
+	AlertSeverityCritical: 3,
+}
+
+// AlertRoute 決定哪些告警送到 Sink；各條件皆為空時收到所有告警，多個條件需同時符合。
+type AlertRoute struct {
+	Name string
+	Sink AlertSink
+	// Types 為告警種類，例如 AlertTemplateSwitch、AlertTemplateRecovery
+	Types       []AlertTemplateName
+	Kinds       []AlertKind
+	MinSeverity AlertSeverity
+	Groups      []string
+	// Match 為額外的自訂條件
+	Match func(Alert) bool
+}
+
+func (r AlertRoute) matches(alert Alert) bool {
+	if len(r.Types) > 0 && !containsAlertValue(r.Types, alert.Type) {
+		return false
+	}
+	if len(r.Kinds) > 0 && !containsAlertValue(r.Kinds, alert.Kind) {
+		return false
+	}
+	if r.MinSeverity != "" && alertSeverityRank[alert.Severity] < alertSeverityRank[r.MinSeverity] {
+		return false
+	}
+	if len(r.Groups) > 0 && !containsAlertValue(r.Groups, alert.Group) {
+		return false
+	}
+	return r.Match == nil || r.Match(alert)
+}
+
+func containsAlertValue[T comparable](values []T, v T) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+type AlertRouterConfig struct {
+	// QueueSize 為每條 route 的佇列長度；critical 告警另有同樣長度的佇列並優先送出，
+	// 佇列滿時丟棄新的告警，以 error 等級記錄並計入 Dropped
+	QueueSize int
+	// Retries 為 sink 回傳錯誤後的重試次數；設為負數時不重試。
+	// 經由 router 送出時內建 HTTP sink 不再自行重試，避免重試次數相乘
+	Retries      int
+	RetryBackoff time.Duration
+	// SendTimeout 為單次呼叫 sink 的逾時
+	SendTimeout time.Duration
+}
+
+var DefaultAlertRouterConfig = AlertRouterConfig{
+	QueueSize:    1000,
+	Retries:      3,
+	RetryBackoff: time.Second,
+	SendTimeout:  30 * time.Second,
+}
+
+func (c AlertRouterConfig) withDefaults() AlertRouterConfig {
+	if c.QueueSize == 0 {
+		c.QueueSize = DefaultAlertRouterConfig.QueueSize
+	}
+	if c.Retries == 0 {
+		c.Retries = DefaultAlertRouterConfig.Retries
+	}
+	if c.Retries < 0 {
+		c.Retries = 0
+	}
+	if c.RetryBackoff == 0 {
+		c.RetryBackoff = DefaultAlertRouterConfig.RetryBackoff
+	}
+	if c.SendTimeout == 0 {
+		c.SendTimeout = DefaultAlertRouterConfig.SendTimeout
+	}
+	return c
+}
+
+type alertRouteWorker struct {
+	AlertRoute
+	critical chan Alert
+	queue    chan Alert
+	dropped  uint64
+}
+
+// AlertRouter 依 AlertRoute 把告警分送到多個 sink。每條 route 有自己的佇列與 goroutine，
+// SendAlert 只負責放進佇列，單一 sink 變慢或失敗不會影響其他 sink 與 Invoke。
+// 實作 Kratos transport.Server，Stop 時會在 ctx 期限內送完佇列中的告警。
+type AlertRouter struct {
+	config AlertRouterConfig
+	routes []*alertRouteWorker
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+	run    *lifecycle
+}
+
+func NewAlertRouter(cfg AlertRouterConfig, routes ...AlertRoute) *AlertRouter {
+	cfg = cfg.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &AlertRouter{
+		config: cfg,
+		ctx:    withoutSinkRetries(ctx),
+		cancel: cancel,
+		run:    newLifecycle(),
+	}
+	for _, route := range routes {
+		w := &alertRouteWorker{
+			AlertRoute: route,
+			critical:   make(chan Alert, cfg.QueueSize),
+			queue:      make(chan Alert, cfg.QueueSize),
+		}
+		r.routes = append(r.routes, w)
+		r.wg.Add(1)
+		go r.work(w)
+	}
+	return r
+}
+
+// SendAlert 把告警放進符合條件的 route 佇列後立即回傳。
+func (r *AlertRouter) SendAlert(_ context.Context, alert Alert) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return ErrAlertRouterClosed
+	}
+	for _, w := range r.routes {
+		if !w.matches(alert) {
+			continue
+		}
+		queue := w.queue
+		if alert.Severity == AlertSeverityCritical {
+			queue = w.critical
+		}
+		select {
+		case queue <- alert:
+		default:
+			n := atomic.AddUint64(&w.dropped, 1)
+			log.Errorf("alert route %v queue full, drop %v %v alert from %v (%d dropped): %v",
+				w.Name, alert.Severity, alert.Type, alert.Source, n, alert.Message)
+		}
+	}
+	return nil
+}
+
+// Dropped 回傳各 route 因佇列已滿或重試用盡而丟棄的告警數。
+func (r *AlertRouter) Dropped() map[string]uint64 {
+	dropped := make(map[string]uint64, len(r.routes))
+	for _, w := range r.routes {
+		dropped[w.Name] += atomic.LoadUint64(&w.dropped)
+	}
+	return dropped
+}
+
+// work 優先送出 critical 佇列的告警，兩個佇列都已關閉且清空時結束。
+func (r *AlertRouter) work(w *alertRouteWorker) {
+	defer r.wg.Done()
+	critical, queue := w.critical, w.queue
+	for critical != nil || queue != nil {
+		var (
+			alert Alert
+			ok    bool
+		)
+		select {
+		case alert, ok = <-critical:
+			if !ok {
+				critical = nil
+				continue
+			}
+		default:
+			select {
+			case alert, ok = <-critical:
+				if !ok {
+					critical = nil
+					continue
+				}
+			case alert, ok = <-queue:
+				if !ok {
+					queue = nil
+					continue
+				}
+			}
+		}
+		r.deliver(w, alert)
+	}
+}
+
+func (r *AlertRouter) deliver(w *alertRouteWorker, alert Alert) {
+	backoff := r.config.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= r.config.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-r.ctx.Done():
+				atomic.AddUint64(&w.dropped, 1)
+				log.Errorf("alert route %v stopped, drop %v alert from %v: %v", w.Name, alert.Type, alert.Source, err)
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		ctx, cancel := context.WithTimeout(r.ctx, r.config.SendTimeout)
+		err = w.Sink.SendAlert(ctx, alert)
+		cancel()
+		if err == nil {
+			return
+		}
+		log.Infof("alert route %v send error (attempt %d): %v", w.Name, attempt+1, err)
+	}
+	atomic.AddUint64(&w.dropped, 1)
+	log.Errorf("alert route %v drop %v alert from %v after %d attempts: %v", w.Name, alert.Type, alert.Source, r.config.Retries+1, err)
+}
+
+func (r *AlertRouter) Start(ctx context.Context) error {
+	if !r.run.begin() {
+		return nil
+	}
+	defer r.run.end()
+	select {
+	case <-ctx.Done():
+	case <-r.run.stop:
+	}
+	return nil
+}
+
+// Stop 停止接收告警並等待佇列送完；ctx 到期時放棄尚未送出的告警。
+func (r *AlertRouter) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	for _, w := range r.routes {
+		close(w.critical)
+		close(w.queue)
+	}
+	r.mu.Unlock()
+	if err := r.run.shutdown(ctx); err != nil {
+		r.cancel()
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		return ctx.Err()
+	}
+}
+
+func (r *AlertRouter) SendErrorAlert(source, msg string) error {
+	return r.SendAlert(context.Background(), legacyAlert(AlertKindError, source, msg))
+}
+
+func (r *AlertRouter) SendRecoveryAlert(source string) error {
+	return r.SendRecoveryMessage(source, "")
+}
+
+func (r *AlertRouter) SendRecoveryMessage(source, msg string) error {
+	return r.SendAlert(context.Background(), legacyAlert(AlertKindRecovery, source, msg))
+}
@@ -0,0 +1,128 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingSink 在 release 關閉前阻塞，之後依序記錄收到的告警。
+type blockingSink struct {
+	release chan struct{}
+
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (s *blockingSink) SendAlert(_ context.Context, alert Alert) error {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func TestAlertRouterCriticalNotDroppedByFullQueue(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	router := NewAlertRouter(AlertRouterConfig{QueueSize: 2}, AlertRoute{Name: "slack", Sink: sink})
+	info := Alert{Severity: AlertSeverityInfo, Type: AlertTemplatePinned}
+	critical := Alert{Severity: AlertSeverityCritical, Type: AlertTemplateSwitch}
+
+	// 第一則被 worker 取出後阻塞，之後把 info 佇列塞滿
+	_ = router.SendAlert(context.Background(), info)
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_ = router.SendAlert(context.Background(), info)
+	}
+	_ = router.SendAlert(context.Background(), critical)
+
+	if dropped := router.Dropped()["slack"]; dropped != 1 {
+		t.Fatalf("dropped = %d, want 1 info alert", dropped)
+	}
+	close(sink.release)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := router.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.alerts) != 4 {
+		t.Fatalf("delivered %d alerts, want 4", len(sink.alerts))
+	}
+	// critical 佇列優先於已排隊的 info
+	if sink.alerts[1].Severity != AlertSeverityCritical {
+		t.Fatalf("second delivered = %v, want critical first", sink.alerts[1].Severity)
+	}
+}
+
+type failingSink struct {
+	calls int32
+}
+
+func (s *failingSink) SendAlert(context.Context, Alert) error {
+	atomic.AddInt32(&s.calls, 1)
+	return errors.New("down")
+}
+
+func TestAlertRouterCountsExhaustedRetries(t *testing.T) {
+	sink := &failingSink{}
+	router := NewAlertRouter(AlertRouterConfig{Retries: 2, RetryBackoff: time.Millisecond}, AlertRoute{Name: "pager", Sink: sink})
+	_ = router.SendAlert(context.Background(), Alert{Severity: AlertSeverityCritical})
+	if err := router.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if sink.calls != 3 {
+		t.Fatalf("calls = %d, want 3", sink.calls)
+	}
+	if dropped := router.Dropped()["pager"]; dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", dropped)
+	}
+}
+
+func TestAlertRouterDisablesSinkRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: srv.URL, HTTP: AlertHTTPConfig{Retries: 5, RetryBackoff: time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewAlertRouter(AlertRouterConfig{Retries: 1, RetryBackoff: time.Millisecond}, AlertRoute{Name: "webhook", Sink: sink})
+	_ = router.SendAlert(context.Background(), testAlert())
+	if err := router.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("webhook calls = %d, want 2 (router retries only)", calls)
+	}
+}
+
+func TestAlertRouterStopTwice(t *testing.T) {
+	router := NewAlertRouter(AlertRouterConfig{}, AlertRoute{Name: "noop", Sink: &recordingSink{}})
+	done := make(chan error, 1)
+	go func() { done <- router.Start(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := router.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Stop(ctx); err != nil {
+		t.Fatalf("second stop: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := router.SendAlert(ctx, testAlert()); !errors.Is(err, ErrAlertRouterClosed) {
+		t.Fatalf("send after stop = %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type SlackConfig struct {
+	// WebhookURL 為 Slack incoming webhook 的 URL
+	WebhookURL string
+	Channel    string
+	Username   string
+	IconEmoji  string
+	HTTP       AlertHTTPConfig
+}
+
+// SlackSink 以 incoming webhook 送出 blocks 格式的告警，同時實作 AlertSink 與 IAlertService。
+type SlackSink struct {
+	config SlackConfig
+}
+
+func NewSlackSink(cfg SlackConfig) (*SlackSink, error) {
+	if cfg.WebhookURL == "" {
+		return nil, fmt.Errorf("slack webhook url is required")
+	}
+	cfg.HTTP = cfg.HTTP.withDefaults()
+	return &SlackSink{config: cfg}, nil
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackMessage struct {
+	Channel   string       `json:"channel,omitempty"`
+	Username  string       `json:"username,omitempty"`
+	IconEmoji string       `json:"icon_emoji,omitempty"`
+	Text      string       `json:"text"`
+	Blocks    []slackBlock `json:"blocks"`
+}
+
+var slackSeverityEmoji = map[AlertSeverity]string{
+	AlertSeverityCritical: ":red_circle:",
+	AlertSeverityWarning:  ":warning:",
+	AlertSeverityInfo:     ":information_source:",
+}
+
+func (s *SlackSink) SendAlert(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(s.message(alert))
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if err := postAlert(ctx, s.config.HTTP, s.config.WebhookURL, body, header); err != nil {
+		return fmt.Errorf("send slack alert: %w", err)
+	}
+	return nil
+}
+
+func (s *SlackSink) message(alert Alert) slackMessage {
+	title := alertTitle(alert)
+	if alert.Kind == AlertKindRecovery {
+		title = ":white_check_mark: " + title
+	} else if emoji, ok := slackSeverityEmoji[alert.Severity]; ok {
+		title = emoji + " " + title
+	}
+
+	text := alert.Message
+	if text == "" {
+		text = title
+	}
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: title}},
+		{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackEscape(text)}},
+	}
+	if fields := slackFields(alert); len(fields) > 0 {
+		blocks = append(blocks, slackBlock{Type: "section", Fields: fields})
+	}
+	if footer := alertContext(alert); footer != "" {
+		blocks = append(blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: slackEscape(footer)}}})
+	}
+	return slackMessage{
+		Channel:   s.config.Channel,
+		Username:  s.config.Username,
+		IconEmoji: s.config.IconEmoji,
+		Text:      slackEscape(text),
+		Blocks:    blocks,
+	}
+}
+
+func slackFields(alert Alert) []slackText {
+	fields := []slackText{}
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%v*\n%v", name, slackEscape(value))})
+		}
+	}
+	if alert.From != "" || alert.To != "" {
+		add("Connector", fmt.Sprintf("%v → %v", alert.From, alert.To))
+	}
+	add("Group", alert.Group)
+	add("Failure codes", strings.Join(alert.FailureCodes, ", "))
+	add("Methods", strings.Join(alert.Methods, ", "))
+	if alert.FailureCount > 0 {
+		add("Failures", fmt.Sprint(alert.FailureCount))
+	}
+	if alert.Duration > 0 {
+		add("Duration", alert.Duration.Round(time.Second).String())
+	}
+	return fields
+}
+
+// slackEscape 跳脫 Slack mrkdwn 的控制字元。
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func (s *SlackSink) SendErrorAlert(source, msg string) error {
+	return s.SendAlert(context.Background(), legacyAlert(AlertKindError, source, msg))
+}
+
+func (s *SlackSink) SendRecoveryAlert(source string) error {
+	return s.SendRecoveryMessage(source, "")
+}
+
+func (s *SlackSink) SendRecoveryMessage(source, msg string) error {
+	return s.SendAlert(context.Background(), legacyAlert(AlertKindRecovery, source, msg))
+}
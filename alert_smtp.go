@@ -0,0 +1,294 @@
+package failover
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+type SMTPSecurity string
+
+const (
+	// SMTPSecurityNone 為明文連線，只適合本機或內網的 relay
+	SMTPSecurityNone SMTPSecurity = "none"
+	// SMTPSecurityStartTLS 以明文連線後升級為 TLS（通常為 587 port）
+	SMTPSecurityStartTLS SMTPSecurity = "starttls"
+	// SMTPSecurityTLS 為一開始就使用 TLS 的連線（通常為 465 port）
+	SMTPSecurityTLS SMTPSecurity = "tls"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Security SMTPSecurity
+	// TLSConfig 為 nil 時以 Host 驗證憑證
+	TLSConfig *tls.Config
+	// Username 不為空時以 PLAIN 驗證
+	Username string
+	Password string
+	From     string
+	To       []string
+	// SubjectPrefix 預設為 "[failover]"
+	SubjectPrefix string
+	Timeout       time.Duration
+}
+
+// SMTPSink 以 email 送出告警，同時實作 AlertSink 與 IAlertService。
+// 帶有 OutageStart 的告警依 group、source 與 OutageStart 算出同一個 thread：開啟異常的 critical 告警
+// 以 thread 作為 Message-ID，同一次異常之後的告警與切回告警以 In-Reply-To/References 接在下面。
+// thread 與 Message-ID 都由告警內容決定，不同 instance 或重送同一則告警時不會各自開新的 thread。
+type SMTPSink struct {
+	config SMTPConfig
+	domain string
+}
+
+func NewSMTPSink(cfg SMTPConfig) (*SMTPSink, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("smtp host is required")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from address: %w", err)
+	}
+	if len(cfg.To) == 0 {
+		return nil, fmt.Errorf("smtp recipients are required")
+	}
+	for _, to := range cfg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid smtp recipient %q: %w", to, err)
+		}
+	}
+	if cfg.Security == "" {
+		cfg.Security = SMTPSecurityStartTLS
+	}
+	if cfg.Port == 0 {
+		switch cfg.Security {
+		case SMTPSecurityTLS:
+			cfg.Port = 465
+		case SMTPSecurityStartTLS:
+			cfg.Port = 587
+		default:
+			cfg.Port = 25
+		}
+	}
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = "[failover]"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	domain := "localhost"
+	if i := strings.LastIndex(from.Address, "@"); i >= 0 {
+		domain = from.Address[i+1:]
+	}
+	return &SMTPSink{config: cfg, domain: domain}, nil
+}
+
+func (s *SMTPSink) SendAlert(ctx context.Context, alert Alert) error {
+	messageID, parent := s.messageID(alert), ""
+	if alert.OutageStart != nil {
+		thread := s.threadID(alert)
+		if opensThread(alert) {
+			messageID = thread
+		} else {
+			parent = thread
+		}
+	}
+
+	msg, err := s.message(alert, messageID, parent)
+	if err != nil {
+		return err
+	}
+	if err := s.send(ctx, msg); err != nil {
+		return fmt.Errorf("send smtp alert: %w", err)
+	}
+	return nil
+}
+
+// opensThread 回傳告警是否為異常的第一則（切換、進入降級）。
+func opensThread(alert Alert) bool {
+	return alert.Kind == AlertKindError && alert.Severity == AlertSeverityCritical
+}
+
+// threadID 為同一次異常共用的 Message-ID。
+func (s *SMTPSink) threadID(alert Alert) string {
+	return s.hashID("thread", alert.Group, alert.Source, strconv.FormatInt(alert.OutageStart.UnixNano(), 10))
+}
+
+// messageID 由告警內容決定，重試或重送同一則告警時收件端可依 Message-ID 去重。
+func (s *SMTPSink) messageID(alert Alert) string {
+	return s.hashID("alert", alert.Group, alert.Source, alert.Instance, string(alert.Type), alert.Kind.String(),
+		strconv.FormatInt(alert.Generation, 10), strconv.FormatInt(alert.At.UnixNano(), 10), alert.Message)
+}
+
+func (s *SMTPSink) hashID(kind string, parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("<failover.%v.%v@%v>", kind, hex.EncodeToString(h.Sum(nil)[:12]), s.domain)
+}
+
+func (s *SMTPSink) message(alert Alert, messageID, parent string) ([]byte, error) {
+	subject := fmt.Sprintf("%v %v", s.config.SubjectPrefix, alertTitle(alert))
+	if parent != "" {
+		subject = "Re: " + subject
+	}
+
+	var buf bytes.Buffer
+	header := func(k, v string) {
+		fmt.Fprintf(&buf, "%v: %v\r\n", k, v)
+	}
+	header("From", s.config.From)
+	header("To", strings.Join(s.config.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	if parent != "" {
+		header("In-Reply-To", parent)
+		header("References", parent)
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(smtpText(alert), "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func smtpText(alert Alert) string {
+	lines := []string{}
+	if alert.Message != "" {
+		lines = append(lines, alert.Message, "")
+	}
+	field := func(name, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%v: %v", name, value))
+		}
+	}
+	if alert.From != "" || alert.To != "" {
+		field("Connector", fmt.Sprintf("%v -> %v", alert.From, alert.To))
+	}
+	field("Severity", alert.Severity.String())
+	field("Group", alert.Group)
+	field("Failure codes", strings.Join(alert.FailureCodes, ", "))
+	field("Methods", strings.Join(alert.Methods, ", "))
+	if alert.FailureCount > 0 {
+		field("Failures", fmt.Sprint(alert.FailureCount))
+	}
+	if alert.OutageStart != nil {
+		field("Outage start", alert.OutageStart.Format(time.RFC3339))
+	}
+	if alert.Duration > 0 {
+		field("Duration", alert.Duration.Round(time.Second).String())
+	}
+	field("Reported", alertContext(alert))
+	return strings.Join(lines, "\n")
+}
+
+func (s *SMTPSink) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	tlsConfig := s.config.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: s.config.Host}
+	}
+
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	var conn net.Conn
+	var err error
+	if s.config.Security == SMTPSecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(s.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if s.config.Security == SMTPSecurityStartTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+	from, err := mail.ParseAddress(s.config.From)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range s.config.To {
+		rcpt, err := mail.ParseAddress(to)
+		if err != nil {
+			return err
+		}
+		if err := c.Rcpt(rcpt.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	// 伺服器已接受信件，QUIT 失敗時不回傳錯誤，避免重試送出重複的信
+	if err := c.Quit(); err != nil {
+		log.Infof("smtp quit error after message accepted: %v", err)
+	}
+	return nil
+}
+
+func (s *SMTPSink) SendErrorAlert(source, msg string) error {
+	return s.SendAlert(context.Background(), legacyAlert(AlertKindError, source, msg))
+}
+
+func (s *SMTPSink) SendRecoveryAlert(source string) error {
+	return s.SendRecoveryMessage(source, "")
+}
+
+func (s *SMTPSink) SendRecoveryMessage(source, msg string) error {
+	return s.SendAlert(context.Background(), legacyAlert(AlertKindRecovery, source, msg))
+}
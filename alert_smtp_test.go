@@ -0,0 +1,233 @@
+package failover
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer 只實作送信需要的指令，收到的信依序記在 messages。
+type fakeSMTPServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	messages []*mail.Message
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &fakeSMTPServer{ln: ln}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return srv
+}
+
+func (srv *fakeSMTPServer) port() int {
+	return srv.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (srv *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg, err := mail.ReadMessage(strings.NewReader(data.String()))
+			if err == nil {
+				srv.mu.Lock()
+				srv.messages = append(srv.messages, msg)
+				srv.mu.Unlock()
+			}
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (srv *fakeSMTPServer) received() []*mail.Message {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]*mail.Message(nil), srv.messages...)
+}
+
+func newTestSMTPSink(t *testing.T, srv *fakeSMTPServer) *SMTPSink {
+	t.Helper()
+	sink, err := NewSMTPSink(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     srv.port(),
+		Security: SMTPSecurityNone,
+		From:     "failover@example.com",
+		To:       []string{"oncall@example.com"},
+		Timeout:  time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sink
+}
+
+func TestSMTPSinkThreadsOutage(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	switchAlert := Alert{
+		Kind: AlertKindError, Type: AlertTemplateSwitch, Severity: AlertSeverityCritical,
+		Source: "Binance", Group: "default", Instance: "a", Generation: 7,
+		OutageStart: &start, Message: "switched", At: start,
+	}
+	recovery := Alert{
+		Kind: AlertKindRecovery, Type: AlertTemplateRecovery, Severity: AlertSeverityInfo,
+		Source: "Binance", Group: "default", Instance: "b", Generation: 8,
+		OutageStart: &start, Duration: time.Hour, Message: "recovered", At: start.Add(time.Hour),
+	}
+	// 切換與切回由不同 instance 送出，各自建立 sink
+	if err := newTestSMTPSink(t, srv).SendAlert(ctx, switchAlert); err != nil {
+		t.Fatalf("send switch: %v", err)
+	}
+	if err := newTestSMTPSink(t, srv).SendAlert(ctx, recovery); err != nil {
+		t.Fatalf("send recovery: %v", err)
+	}
+
+	msgs := srv.received()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+	root := msgs[0].Header.Get("Message-ID")
+	if root == "" || msgs[0].Header.Get("In-Reply-To") != "" {
+		t.Fatalf("switch mail should open a thread, headers %v", msgs[0].Header)
+	}
+	if got := msgs[1].Header.Get("In-Reply-To"); got != root {
+		t.Fatalf("recovery In-Reply-To = %q, want %q", got, root)
+	}
+	if got := msgs[1].Header.Get("References"); got != root {
+		t.Fatalf("recovery References = %q, want %q", got, root)
+	}
+	if msgs[1].Header.Get("Message-ID") == root {
+		t.Fatal("recovery reused the thread Message-ID")
+	}
+}
+
+func TestSMTPSinkDegradedExitJoinsThread(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	sink := newTestSMTPSink(t, srv)
+	since := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	enter := Alert{Kind: alertKindOf(AlertTemplateDegradedEnter), Type: AlertTemplateDegradedEnter, Severity: alertSeverityOf(AlertTemplateDegradedEnter), Source: "Redis", OutageStart: &since, At: since}
+	exit := Alert{Kind: alertKindOf(AlertTemplateDegradedExit), Type: AlertTemplateDegradedExit, Severity: alertSeverityOf(AlertTemplateDegradedExit), Source: "Redis", OutageStart: &since, At: since.Add(time.Minute)}
+	if exit.Kind != AlertKindRecovery {
+		t.Fatalf("degraded exit kind = %v, want recovery", exit.Kind)
+	}
+	for _, a := range []Alert{enter, exit} {
+		if err := sink.SendAlert(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msgs := srv.received()
+	if len(msgs) != 2 || msgs[1].Header.Get("In-Reply-To") != msgs[0].Header.Get("Message-ID") {
+		t.Fatalf("degraded exit not threaded under enter")
+	}
+}
+
+func TestSMTPSinkMessageIDStable(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	sink := newTestSMTPSink(t, srv)
+	at := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	alert := Alert{Kind: AlertKindError, Type: AlertTemplateMaintenanceProbeFailed, Severity: AlertSeverityWarning, Source: "Binance", Message: "probe failed", At: at}
+
+	for i := 0; i < 2; i++ {
+		if err := sink.SendAlert(context.Background(), alert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alert.At = at.Add(time.Second)
+	if err := sink.SendAlert(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := srv.received()
+	if len(msgs) != 3 {
+		t.Fatalf("got %d messages", len(msgs))
+	}
+	ids := []string{}
+	for _, m := range msgs {
+		ids = append(ids, m.Header.Get("Message-ID"))
+	}
+	if ids[0] != ids[1] {
+		t.Fatalf("resent alert got new Message-ID: %v", ids)
+	}
+	if ids[0] == ids[2] {
+		t.Fatalf("different alerts share Message-ID: %v", ids)
+	}
+	if !strings.HasSuffix(ids[0], "@example.com>") {
+		t.Fatalf("Message-ID %v should use the sender domain", ids[0])
+	}
+}
+
+func TestSMTPSinkServerDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	sink, err := NewSMTPSink(SMTPConfig{
+		Host: "127.0.0.1", Port: port, Security: SMTPSecurityNone,
+		From: "failover@example.com", To: []string{"oncall@example.com"}, Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.SendAlert(context.Background(), testAlert()); err == nil || !strings.Contains(err.Error(), strconv.Itoa(port)) {
+		t.Fatalf("error = %v, want dial error", err)
+	}
+}
@@ -0,0 +1,192 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DefaultTelegramBaseURL = "https://api.telegram.org"
+	// telegramMessageLimit 為 sendMessage 單則訊息的長度上限
+	telegramMessageLimit = 4096
+)
+
+type TelegramConfig struct {
+	Token   string
+	ChatIDs []string
+	// BaseURL 預設為 https://api.telegram.org，測試時可指向本機的 stub server
+	BaseURL string
+	// SilentInfo 為 true 時 info 告警以靜音通知送出
+	SilentInfo bool
+	HTTP       AlertHTTPConfig
+}
+
+// TelegramSink 透過 Bot API 把告警送到多個 chat，同時實作 AlertSink 與 IAlertService。
+type TelegramSink struct {
+	config TelegramConfig
+}
+
+func NewTelegramSink(cfg TelegramConfig) (*TelegramSink, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("telegram bot token is required")
+	}
+	if len(cfg.ChatIDs) == 0 {
+		return nil, fmt.Errorf("telegram chat ids are required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultTelegramBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	cfg.HTTP = cfg.HTTP.withDefaults()
+	return &TelegramSink{config: cfg}, nil
+}
+
+type telegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+	DisableNotification   bool   `json:"disable_notification,omitempty"`
+}
+
+// telegramError 為 Bot API 的錯誤回應，429 時 parameters.retry_after 為需等待的秒數。
+type telegramError struct {
+	Parameters struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+func (s *TelegramSink) SendAlert(ctx context.Context, alert Alert) error {
+	silent := s.config.SilentInfo && alert.Severity == AlertSeverityInfo
+	url := fmt.Sprintf("%v/bot%v/sendMessage", s.config.BaseURL, s.config.Token)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	// 多個 chat 與分段共用 MaxElapsed，避免依 chat 數倍增等待時間
+	ctx, cancel := context.WithTimeout(ctx, s.config.HTTP.MaxElapsed)
+	defer cancel()
+
+	var errs []error
+	for _, chatID := range s.config.ChatIDs {
+		for _, text := range splitTelegramMessage(telegramText(alert), telegramMessageLimit) {
+			body, err := json.Marshal(telegramMessage{
+				ChatID:                chatID,
+				Text:                  text,
+				ParseMode:             "MarkdownV2",
+				DisableWebPagePreview: true,
+				DisableNotification:   silent,
+			})
+			if err != nil {
+				return err
+			}
+			if err := postAlertWith(ctx, s.config.HTTP, url, body, header, telegramRetryAfter); err != nil {
+				// token 會出現在 URL 中，錯誤只帶 chat id
+				errs = append(errs, fmt.Errorf("send telegram alert to %v: %w", chatID, redactToken(err, s.config.Token)))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// telegramRetryAfter 解析 429 回應的 retry_after，實際等待仍受 AlertHTTPConfig.MaxRetryAfter 限制。
+func telegramRetryAfter(body string) time.Duration {
+	e := telegramError{}
+	if err := json.Unmarshal([]byte(body), &e); err != nil || e.Parameters.RetryAfter <= 0 {
+		return 0
+	}
+	return time.Duration(e.Parameters.RetryAfter) * time.Second
+}
+
+func redactToken(err error, token string) error {
+	if !strings.Contains(err.Error(), token) {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), token, "<token>"))
+}
+
+// telegramText 以 MarkdownV2 組出告警內容，所有動態文字都經過跳脫。
+func telegramText(alert Alert) string {
+	lines := []string{"*" + telegramEscape(alertTitle(alert)) + "*"}
+	if alert.Message != "" {
+		lines = append(lines, telegramEscape(alert.Message))
+	}
+	field := func(name, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("*%v:* %v", telegramEscape(name), telegramEscape(value)))
+		}
+	}
+	if alert.From != "" || alert.To != "" {
+		field("Connector", fmt.Sprintf("%v → %v", alert.From, alert.To))
+	}
+	field("Group", alert.Group)
+	field("Failure codes", strings.Join(alert.FailureCodes, ", "))
+	if alert.FailureCount > 0 {
+		field("Failures", fmt.Sprint(alert.FailureCount))
+	}
+	if alert.Duration > 0 {
+		field("Duration", alert.Duration.Round(time.Second).String())
+	}
+	if footer := alertContext(alert); footer != "" {
+		lines = append(lines, "_"+telegramEscape(footer)+"_")
+	}
+	return strings.Join(lines, "\n")
+}
+
+var telegramEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// telegramEscape 跳脫 MarkdownV2 的保留字元。
+func telegramEscape(s string) string {
+	return telegramEscaper.Replace(s)
+}
+
+// splitTelegramMessage 把已跳脫的內容切成不超過 limit 個字元的訊息，優先在換行處切開，
+// 且不會把跳脫字元與被跳脫的字元切到不同訊息。
+func splitTelegramMessage(text string, limit int) []string {
+	var chunks []string
+	for utf8.RuneCountInString(text) > limit {
+		runes := []rune(text)
+		cut := limit
+		if i := strings.LastIndex(string(runes[:limit]), "\n"); i > 0 {
+			cut = utf8.RuneCountInString(string(runes[:limit])[:i])
+		}
+		// 結尾為奇數個反斜線時，最後一個反斜線屬於下一段的跳脫
+		backslashes := 0
+		for i := cut - 1; i >= 0 && runes[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			cut--
+		}
+		if cut <= 0 {
+			cut = limit
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		text = strings.TrimPrefix(string(runes[cut:]), "\n")
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+func (s *TelegramSink) SendErrorAlert(source, msg string) error {
+	return s.SendAlert(context.Background(), legacyAlert(AlertKindError, source, msg))
+}
+
+func (s *TelegramSink) SendRecoveryAlert(source string) error {
+	return s.SendRecoveryMessage(source, "")
+}
+
+func (s *TelegramSink) SendRecoveryMessage(source, msg string) error {
+	return s.SendAlert(context.Background(), legacyAlert(AlertKindRecovery, source, msg))
+}
@@ -0,0 +1,122 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestTelegramSinkRetryAfter(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls = map[string]int{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botTOKEN/sendMessage" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		msg := telegramMessage{}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		mu.Lock()
+		calls[msg.ChatID]++
+		n := calls[msg.ChatID]
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"ok":false,"error_code":429,"parameters":{"retry_after":3600}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	sink, err := NewTelegramSink(TelegramConfig{
+		Token:   "TOKEN",
+		ChatIDs: []string{"1", "2"},
+		BaseURL: srv.URL,
+		HTTP:    AlertHTTPConfig{MaxRetryAfter: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := sink.SendAlert(context.Background(), testAlert()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("waited %v, retry_after should be capped", elapsed)
+	}
+	if calls["1"] != 2 || calls["2"] != 2 {
+		t.Fatalf("calls = %v, want one retry per chat", calls)
+	}
+}
+
+func TestTelegramSinkRedactsToken(t *testing.T) {
+	sink, err := NewTelegramSink(TelegramConfig{
+		Token:   "SECRET",
+		ChatIDs: []string{"1"},
+		BaseURL: "http://127.0.0.1:0",
+		HTTP:    AlertHTTPConfig{Retries: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sink.SendAlert(context.Background(), testAlert())
+	if err == nil || strings.Contains(err.Error(), "SECRET") {
+		t.Fatalf("error = %v, want redacted token", err)
+	}
+}
+
+func TestSplitTelegramMessage(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{name: "within limit", text: "abcd", limit: 4, want: []string{"abcd"}},
+		{name: "exact split", text: "abcdefgh", limit: 4, want: []string{"abcd", "efgh"}},
+		{name: "one over", text: "abcde", limit: 4, want: []string{"abcd", "e"}},
+		{name: "prefer newline", text: "ab\ncdef", limit: 4, want: []string{"ab", "cdef"}},
+		{name: "keep escape together", text: `abc\.d`, limit: 4, want: []string{"abc", `\.d`}},
+		{name: "escaped backslash", text: `ab\\cd`, limit: 4, want: []string{`ab\\`, "cd"}},
+		{name: "multibyte", text: "切換到備援交易所", limit: 3, want: []string{"切換到", "備援交", "易所"}},
+		{name: "empty", text: "", limit: 4, want: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := splitTelegramMessage(c.text, c.limit)
+			if strings.Join(got, "|") != strings.Join(c.want, "|") || len(got) != len(c.want) {
+				t.Fatalf("split(%q, %d) = %q, want %q", c.text, c.limit, got, c.want)
+			}
+			for _, chunk := range got {
+				if utf8.RuneCountInString(chunk) > c.limit {
+					t.Fatalf("chunk %q exceeds limit %d", chunk, c.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitTelegramMessageLimit(t *testing.T) {
+	text := strings.Repeat(telegramEscape("a.b-c!")+"\n", 1000)
+	chunks := splitTelegramMessage(text, telegramMessageLimit)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want split", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if utf8.RuneCountInString(chunk) > telegramMessageLimit {
+			t.Fatalf("chunk has %d runes", utf8.RuneCountInString(chunk))
+		}
+		if strings.HasSuffix(chunk, `\`) && !strings.HasSuffix(chunk, `\\`) {
+			t.Fatalf("chunk ends inside an escape: %q", chunk[len(chunk)-8:])
+		}
+	}
+}
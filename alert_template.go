@@ -0,0 +1,213 @@
+package failover
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+type AlertTemplateName string
+
+const (
+	AlertTemplateSwitch                 AlertTemplateName = "switch"
+	AlertTemplateRecovery               AlertTemplateName = "recovery"
+	AlertTemplateDegradedEnter          AlertTemplateName = "degraded_enter"
+	AlertTemplateDegradedSwitch         AlertTemplateName = "degraded_switch"
+	AlertTemplateDegradedExit           AlertTemplateName = "degraded_exit"
+	AlertTemplateMaintenanceScheduled   AlertTemplateName = "maintenance_scheduled"
+	AlertTemplateMaintenanceStart       AlertTemplateName = "maintenance_start"
+	AlertTemplateMaintenanceProbeFailed AlertTemplateName = "maintenance_probe_failed"
+	AlertTemplateMaintenanceEnd         AlertTemplateName = "maintenance_end"
+	AlertTemplatePinned                 AlertTemplateName = "pinned"
+	AlertTemplateUnpinned               AlertTemplateName = "unpinned"
+	AlertTemplatePinExpired             AlertTemplateName = "pin_expired"
+	AlertTemplateDigest                 AlertTemplateName = "digest"
+)
+
+const (
+	AlertLocaleZhTW = "zh-TW"
+	AlertLocaleEn   = "en"
+)
+
+// AlertData 為告警範本可使用的變數，未使用的欄位為零值。
+type AlertData struct {
+	Source         string
+	From           ExchangeConnectorType
+	To             ExchangeConnectorType
+	WindowCount    int
+	Codes          []string
+	Methods        []string
+	LockTTL        time.Duration
+	Instance       string
+	Group          string
+	Generation     int64
+	OutageStart    time.Time
+	OutageDuration time.Duration
+	Operator       string
+	Reason         string
+	Err            string
+	FailureCode    string
+	Start          time.Time
+	End            time.Time
+	Capabilities   []string
+	ProbeFailures  int
+	Kind           AlertKind
+	Elapsed        time.Duration
+	Count          int
+	LastMessage    string
+}
+
+var alertTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Second).String()
+	},
+	"time": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+}
+
+var defaultAlertTemplates = map[string]map[AlertTemplateName]string{
+	AlertLocaleZhTW: {
+		AlertTemplateSwitch: `因 {{.From}} 發生異常無法使用，先採用 {{.To}} 進行避險、報價的執行。請通知第三方廠商做緊急處理。` +
+			`錯誤視窗內 {{.WindowCount}} 次系統異常{{if .Codes}}（{{join .Codes ", "}}）{{end}}，LockTime {{duration .LockTTL}}，instance {{.Instance}}，狀態版本 {{.Generation}}。`,
+		AlertTemplateRecovery: `{{.To}} 已恢復，由 {{.From}} 切回 {{.To}}{{if .OutageDuration}}，異常持續 {{duration .OutageDuration}}（自 {{time .OutageStart}}）{{end}}。` +
+			`instance {{.Instance}}，狀態版本 {{.Generation}}。`,
+		AlertTemplateDegradedEnter: `{{.Instance}} 無法連線 Redis（{{.Err}}），進入降級模式：沿用最後已知狀態（{{.To}}），錯誤改為本 instance 自行計數，Redis 恢復後自動同步。`,
+		AlertTemplateDegradedSwitch: `Redis 無法連線期間，{{.Instance}} 在錯誤視窗內累積 {{.WindowCount}} 次系統異常{{if .Codes}}（{{join .Codes ", "}}）{{end}}，` +
+			`本 instance 先改用 {{.To}}（LockTime {{duration .LockTTL}}），Redis 恢復後會同步此決定。`,
+		AlertTemplateDegradedExit: `{{.Instance}} 已重新連線 Redis，結束降級模式（持續 {{duration .OutageDuration}}）` +
+			`{{if .Generation}}，已將本地切換到 {{.To}} 的決定寫回 Redis（狀態版本 {{.Generation}}）{{end}}。`,
+		AlertTemplateMaintenanceScheduled: `已排定 {{.From}} 維護時段 {{time .Start}} ~ {{time .End}}{{if .Capabilities}}（範圍：{{join .Capabilities ", "}}）{{end}}，` +
+			`維護開始前將切換至 {{.To}}。`,
+		AlertTemplateMaintenanceStart: `{{.From}} 即將於 {{time .Start}} 進入維護{{if .Capabilities}}（範圍：{{join .Capabilities ", "}}）{{end}}，` +
+			`已預先切換至 {{.To}}，預計 {{time .End}} 結束。（狀態版本 {{.Generation}}）`,
+		AlertTemplateMaintenanceProbeFailed: `{{.From}} 維護時段已結束，但探測失敗（第 {{.ProbeFailures}} 次，FailureCode={{.FailureCode}}, err={{.Err}}），繼續使用 {{.To}}。`,
+		AlertTemplateMaintenanceEnd:         `{{.To}} 維護結束且探測成功{{if .Generation}}，已由 {{.From}} 切回 {{.To}}（狀態版本 {{.Generation}}）{{end}}。`,
+		AlertTemplatePinned:                 `{{.Operator}} 已將交易所固定為 {{.To}}，至 {{time .End}} 為止暫停自動切換。原因：{{.Reason}}`,
+		AlertTemplateUnpinned:               `{{.Operator}} 已解除 {{.From}} pin，恢復自動切換，目前使用 {{.To}}。原因：{{.Reason}}`,
+		AlertTemplatePinExpired:             `{{.Operator}} 設定的 {{.From}} pin 已於 {{time .End}} 到期，恢復自動切換，目前使用 {{.To}}。`,
+		AlertTemplateDigest:                 `過去 {{duration .Elapsed}} 內已略過 {{.Count}} 則 {{.Source}} {{.Kind}} 告警。{{if .LastMessage}}最後一則：{{.LastMessage}}{{end}}`,
+	},
+	AlertLocaleEn: {
+		AlertTemplateSwitch: `{{.From}} is failing; hedging and quoting have moved to {{.To}}. Please escalate to the vendor. ` +
+			`{{.WindowCount}} system errors in the window{{if .Codes}} ({{join .Codes ", "}}){{end}}, lock {{duration .LockTTL}}, instance {{.Instance}}, generation {{.Generation}}.`,
+		AlertTemplateRecovery: `{{.To}} has recovered; switched back from {{.From}} to {{.To}}{{if .OutageDuration}} after {{duration .OutageDuration}} (since {{time .OutageStart}}){{end}}. ` +
+			`Instance {{.Instance}}, generation {{.Generation}}.`,
+		AlertTemplateDegradedEnter: `{{.Instance}} cannot reach Redis ({{.Err}}) and is running in degraded mode: using last known state ({{.To}}) ` +
+			`and counting failures locally until Redis is back.`,
+		AlertTemplateDegradedSwitch: `While Redis is unreachable, {{.Instance}} saw {{.WindowCount}} system errors in the window{{if .Codes}} ({{join .Codes ", "}}){{end}}; ` +
+			`this instance switched to {{.To}} (lock {{duration .LockTTL}}) and will sync the decision once Redis is back.`,
+		AlertTemplateDegradedExit: `{{.Instance}} reconnected to Redis and left degraded mode after {{duration .OutageDuration}}` +
+			`{{if .Generation}}; the local switch to {{.To}} was written back to Redis (generation {{.Generation}}){{end}}.`,
+		AlertTemplateMaintenanceScheduled: `Maintenance for {{.From}} scheduled {{time .Start}} ~ {{time .End}}{{if .Capabilities}} (scope: {{join .Capabilities ", "}}){{end}}; ` +
+			`traffic will move to {{.To}} before it starts.`,
+		AlertTemplateMaintenanceStart: `{{.From}} enters maintenance at {{time .Start}}{{if .Capabilities}} (scope: {{join .Capabilities ", "}}){{end}}; ` +
+			`traffic moved to {{.To}} until {{time .End}}. (generation {{.Generation}})`,
+		AlertTemplateMaintenanceProbeFailed: `{{.From}} maintenance window has ended but the probe failed (attempt {{.ProbeFailures}}, FailureCode={{.FailureCode}}, err={{.Err}}); staying on {{.To}}.`,
+		AlertTemplateMaintenanceEnd:         `{{.To}} maintenance finished and the probe succeeded{{if .Generation}}; switched back from {{.From}} to {{.To}} (generation {{.Generation}}){{end}}.`,
+		AlertTemplatePinned:                 `{{.Operator}} pinned the exchange to {{.To}} until {{time .End}}; automatic switching is paused. Reason: {{.Reason}}`,
+		AlertTemplateUnpinned:               `{{.Operator}} removed the {{.From}} pin; automatic switching resumed, now using {{.To}}. Reason: {{.Reason}}`,
+		AlertTemplatePinExpired:             `The {{.From}} pin set by {{.Operator}} expired at {{time .End}}; automatic switching resumed, now using {{.To}}.`,
+		AlertTemplateDigest:                 `Suppressed {{.Count}} {{.Source}} {{.Kind}} alerts in the last {{duration .Elapsed}}.{{if .LastMessage}} Last one: {{.LastMessage}}{{end}}`,
+	},
+}
+
+// AlertRenderer 依語系以 text/template 產生告警內容。
+type AlertRenderer struct {
+	locale    string
+	templates map[AlertTemplateName]*template.Template
+}
+
+// NewAlertRenderer 建立指定語系的 AlertRenderer，overrides 可覆蓋個別範本。
+func NewAlertRenderer(locale string, overrides map[AlertTemplateName]string) (*AlertRenderer, error) {
+	sources, ok := defaultAlertTemplates[locale]
+	if !ok {
+		return nil, fmt.Errorf("unsupported alert locale %q", locale)
+	}
+	for name := range overrides {
+		if _, ok := sources[name]; !ok {
+			return nil, fmt.Errorf("unknown alert template %v", name)
+		}
+	}
+	r := &AlertRenderer{
+		locale:    locale,
+		templates: map[AlertTemplateName]*template.Template{},
+	}
+	for name, text := range sources {
+		if override, ok := overrides[name]; ok {
+			text = override
+		}
+		tmpl, err := template.New(string(name)).Funcs(alertTemplateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parse alert template %v: %w", name, err)
+		}
+		r.templates[name] = tmpl
+	}
+	return r, nil
+}
+
+var defaultAlertRenderer, _ = NewAlertRenderer(AlertLocaleZhTW, nil)
+
+func (r *AlertRenderer) Locale() string {
+	return r.locale
+}
+
+func (r *AlertRenderer) Render(name AlertTemplateName, data AlertData) (string, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown alert template %v", name)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("render alert template %v: %w", name, err)
+	}
+	return sb.String(), nil
+}
+
+// localeRenderers 快取各語系的預設 AlertRenderer，供未經 NewProxy 建立的 proxy 使用。
+var localeRenderers sync.Map
+
+func (proxy ExchangeApiProxyImpl) alertRenderer() *AlertRenderer {
+	if proxy.AlertRenderer != nil {
+		return proxy.AlertRenderer
+	}
+	locale := proxy.config().AlertLocale
+	if locale == defaultAlertRenderer.Locale() {
+		return defaultAlertRenderer
+	}
+	if r, ok := localeRenderers.Load(locale); ok {
+		return r.(*AlertRenderer)
+	}
+	r, err := NewAlertRenderer(locale, nil)
+	if err != nil {
+		log.Errorf("alert locale error, use default: %v", err)
+		r = defaultAlertRenderer
+	}
+	actual, _ := localeRenderers.LoadOrStore(locale, r)
+	return actual.(*AlertRenderer)
+}
+
+func (proxy ExchangeApiProxyImpl) renderAlert(name AlertTemplateName, source string, data AlertData) string {
+	data.Source = source
+	data.Instance = proxy.config().InstanceID
+	data.Group = proxy.config().Group
+	msg, err := proxy.alertRenderer().Render(name, data)
+	if err != nil {
+		log.Infof("render alert error: %v", err)
+		return fmt.Sprintf("%v %v -> %v (%v)", name, data.From, data.To, source)
+	}
+	return msg
+}
+
+func capabilityNames(capabilities []Capability) []string {
+	names := make([]string, 0, len(capabilities))
+	for _, c := range capabilities {
+		names = append(names, c.String())
+	}
+	return names
+}
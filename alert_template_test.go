@@ -0,0 +1,40 @@
+package failover
+
+import "testing"
+
+func TestValidateProxyOptionsRejectsInvalidAlertTemplates(t *testing.T) {
+	cfg := DefaultConfig
+	WithAlertLocale("fr")(&cfg)
+	if err := ValidateProxyOptions(WithConfig(cfg)); err == nil {
+		t.Fatal("unknown locale: expected error")
+	}
+	if err := ValidateProxyOptions(WithAlertTemplates(map[AlertTemplateName]string{AlertTemplateSwitch: "{{.From"})); err == nil {
+		t.Fatal("bad template: expected error")
+	}
+	if err := ValidateProxyOptions(WithAlertTemplates(map[AlertTemplateName]string{"swtich": "{{.From}}"})); err == nil {
+		t.Fatal("unknown template name: expected error")
+	}
+	if err := ValidateProxyOptions(WithAlertTemplates(map[AlertTemplateName]string{AlertTemplateSwitch: "{{.From}} down"})); err != nil {
+		t.Fatalf("valid override: %v", err)
+	}
+}
+
+func TestNewProxyFallsBackToDefaultTemplates(t *testing.T) {
+	proxy := NewProxy(WithAlertTemplates(map[AlertTemplateName]string{AlertTemplateSwitch: "{{.From"}))
+	if proxy.AlertRenderer != defaultAlertRenderer {
+		t.Fatal("invalid templates: expected default renderer")
+	}
+}
+
+func TestAlertRendererCachedPerLocale(t *testing.T) {
+	cfg := DefaultConfig
+	WithAlertLocale(AlertLocaleEn)(&cfg)
+	proxy := ExchangeApiProxyImpl{Config: cfg}
+	first := proxy.alertRenderer()
+	if first.Locale() != AlertLocaleEn {
+		t.Fatalf("locale = %v, want %v", first.Locale(), AlertLocaleEn)
+	}
+	if second := proxy.alertRenderer(); second != first {
+		t.Fatal("renderer rebuilt for the same locale")
+	}
+}
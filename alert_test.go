@@ -0,0 +1,27 @@
+package failover
+
+import (
+	"context"
+	"testing"
+)
+
+type ctxSink struct {
+	err error
+}
+
+func (s *ctxSink) SendAlert(ctx context.Context, _ Alert) error {
+	s.err = ctx.Err()
+	return nil
+}
+
+func TestSendAlertIgnoresRequestCancel(t *testing.T) {
+	sink := &ctxSink{}
+	proxy := ExchangeApiProxyImpl{AlertSink: sink}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	proxy.sendAlert(ctx, AlertTemplateSwitch, "Binance", AlertData{From: ExchangeConnectorTypeBinance, To: ExchangeConnectorTypeOKX})
+	if sink.err != nil {
+		t.Fatalf("sink saw canceled context: %v", sink.err)
+	}
+}
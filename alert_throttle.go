@@ -0,0 +1,327 @@
+package failover
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+type AlertKind string
+
+const (
+	AlertKindError    AlertKind = "error"
+	AlertKindRecovery AlertKind = "recovery"
+)
+
+func (k AlertKind) String() string {
+	return string(k)
+}
+
+type AlertThrottleConfig struct {
+	// Cooldown 為同一則告警再次送出前的最短間隔
+	Cooldown time.Duration
+	// Cooldowns 依 "kind:source"（例如 "error:Binance"）覆蓋 Cooldown
+	Cooldowns map[string]time.Duration
+	// DigestInterval 為 Start 檢查並送出摘要的間隔
+	DigestInterval time.Duration
+	RedisKeyPrefix string
+	// DedupKey 決定哪些告警視為同一則，預設為 kind、source、範本與切換方向；
+	// 沒有範本的告警（SendErrorAlert 等）以完整訊息區分
+	DedupKey func(alert Alert) string
+	// Renderer 產生摘要內容，NewProxy 會帶入與其他告警相同語系的範本
+	Renderer *AlertRenderer
+}
+
+var DefaultAlertThrottleConfig = AlertThrottleConfig{
+	Cooldown:       10 * time.Minute,
+	DigestInterval: time.Minute,
+	RedisKeyPrefix: "exchange:alert",
+}
+
+func (c AlertThrottleConfig) withDefaults() AlertThrottleConfig {
+	if c.Cooldown == 0 {
+		c.Cooldown = DefaultAlertThrottleConfig.Cooldown
+	}
+	if c.DigestInterval == 0 {
+		c.DigestInterval = DefaultAlertThrottleConfig.DigestInterval
+	}
+	if c.RedisKeyPrefix == "" {
+		c.RedisKeyPrefix = DefaultAlertThrottleConfig.RedisKeyPrefix
+	}
+	if c.DedupKey == nil {
+		c.DedupKey = defaultAlertDedupKey
+	}
+	if c.Renderer == nil {
+		c.Renderer = defaultAlertRenderer
+	}
+	return c
+}
+
+func (c AlertThrottleConfig) cooldown(kind AlertKind, source string) time.Duration {
+	if d, ok := c.Cooldowns[fmt.Sprintf("%v:%v", kind, source)]; ok {
+		return d
+	}
+	return c.Cooldown
+}
+
+// defaultAlertDedupKey 以範本與切換方向區分告警，訊息中的次數、時間、狀態版本不影響 key，
+// 不同種類（切換、pin、維護）仍各自計算 cooldown；沒有範本時只合併訊息完全相同的告警。
+func defaultAlertDedupKey(alert Alert) string {
+	if alert.Type != "" {
+		return fmt.Sprintf("%v:%v:%v:%v-%v", alert.Kind, alert.Source, alert.Type, alert.From, alert.To)
+	}
+	sum := sha1.Sum([]byte(alert.Message))
+	return fmt.Sprintf("%v:%v:%v", alert.Kind, alert.Source, hex.EncodeToString(sum[:6]))
+}
+
+// suppressedAlert 為 cooldown 期間被略過的告警統計，存在 Redis hash 中供所有 instance 共用。
+type suppressedAlert struct {
+	Kind        AlertKind `json:"kind"`
+	Source      string    `json:"source"`
+	Group       string    `json:"group,omitempty"`
+	Count       int       `json:"count"`
+	First       time.Time `json:"first"`
+	LastMessage string    `json:"lastMessage,omitempty"`
+}
+
+// alert 把摘要包成 digest 類型的告警，kind 沿用被略過的告警；切回告警的摘要為 info。
+func (s suppressedAlert) alert(r *AlertRenderer, now time.Time) Alert {
+	severity := alertSeverityOf(AlertTemplateDigest)
+	if s.Kind == AlertKindRecovery {
+		severity = AlertSeverityInfo
+	}
+	return Alert{
+		Kind:         s.Kind,
+		Type:         AlertTemplateDigest,
+		Severity:     severity,
+		Source:       s.Source,
+		Group:        s.Group,
+		FailureCount: s.Count,
+		Message:      s.digest(r, now),
+		At:           now,
+	}
+}
+
+func (s suppressedAlert) digest(r *AlertRenderer, now time.Time) string {
+	msg, err := r.Render(AlertTemplateDigest, AlertData{
+		Source:      s.Source,
+		Kind:        s.Kind,
+		Elapsed:     now.Sub(s.First),
+		Count:       s.Count,
+		LastMessage: s.LastMessage,
+	})
+	if err != nil {
+		log.Infof("render alert digest error: %v", err)
+		return fmt.Sprintf("suppressed %d %v %v alerts", s.Count, s.Source, s.Kind)
+	}
+	return msg
+}
+
+var suppressAlertScript = redis.NewScript(`
+local raw = redis.call("HGET", KEYS[1], ARGV[1])
+local s
+if raw then
+	s = cjson.decode(raw)
+else
+	s = {kind = ARGV[2], source = ARGV[3], count = 0, first = ARGV[4], group = ARGV[6]}
+end
+s.count = s.count + 1
+s.lastMessage = ARGV[5]
+redis.call("HSET", KEYS[1], ARGV[1], cjson.encode(s))
+return s.count
+`)
+
+// claimDigestScript 取出並刪除摘要，多個 instance 同時取出時只有一個拿到。
+var claimDigestScript = redis.NewScript(`
+local raw = redis.call("HGET", KEYS[1], ARGV[1])
+if raw then
+	redis.call("HDEL", KEYS[1], ARGV[1])
+end
+return raw
+`)
+
+// ThrottledAlertService 包裝 AlertSink，以 Redis 共用的 dedup key 讓同一則告警在 cooldown 內只送一次，
+// 被略過的告警在下一次送出或 cooldown 結束時彙整成摘要。Redis 無法使用時照常送出。
+// 同時實作 IAlertService 與 AlertSink，以及 Kratos transport.Server，啟動後會定期送出 cooldown 已結束的摘要。
+type ThrottledAlertService struct {
+	inner  AlertSink
+	cache  redis.UniversalClient
+	config AlertThrottleConfig
+
+	run *lifecycle
+}
+
+func NewThrottledAlertService(inner IAlertService, cache redis.UniversalClient, cfg AlertThrottleConfig) *ThrottledAlertService {
+	return NewThrottledAlertSink(NewAlertServiceSink(inner), cache, cfg)
+}
+
+func NewThrottledAlertSink(inner AlertSink, cache redis.UniversalClient, cfg AlertThrottleConfig) *ThrottledAlertService {
+	return &ThrottledAlertService{
+		inner:  inner,
+		cache:  cache,
+		config: cfg.withDefaults(),
+		run:    newLifecycle(),
+	}
+}
+
+func (t *ThrottledAlertService) SendErrorAlert(source, msg string) error {
+	return t.SendAlert(context.Background(), legacyAlert(AlertKindError, source, msg))
+}
+
+func (t *ThrottledAlertService) SendRecoveryAlert(source string) error {
+	return t.SendRecoveryMessage(source, "")
+}
+
+// SendRecoveryMessage 實作 IRecoveryMessageAlertService。
+func (t *ThrottledAlertService) SendRecoveryMessage(source, msg string) error {
+	return t.SendAlert(context.Background(), legacyAlert(AlertKindRecovery, source, msg))
+}
+
+// SendAlert 實作 AlertSink；摘要附在同一則告警的 Message 後送出，不另外發送。
+func (t *ThrottledAlertService) SendAlert(ctx context.Context, alert Alert) error {
+	return t.send(ctx, alert, func(digest *Alert) error {
+		if digest != nil {
+			alert.Message = fmt.Sprintf("%v\n%v", alert.Message, digest.Message)
+		}
+		return t.inner.SendAlert(ctx, alert)
+	})
+}
+
+// send 只處理這一則告警的 cooldown 與摘要；其他 key 在 cooldown 結束後的摘要由 Start 定期送出，
+// 不在送出告警的路徑上逐一檢查。
+func (t *ThrottledAlertService) send(ctx context.Context, alert Alert, deliver func(digest *Alert) error) error {
+	kind, source := alert.Kind, alert.Source
+	key := t.config.DedupKey(alert)
+	cooldownKey := t.cooldownKey(key)
+	now := time.Now()
+
+	acquired, err := t.cache.SetNX(ctx, cooldownKey, now.Format(time.RFC3339Nano), t.config.cooldown(kind, source)).Result()
+	if err != nil {
+		log.Infof("alert throttle unavailable, send anyway: %v", err)
+		return deliver(nil)
+	}
+	if !acquired {
+		if err := suppressAlertScript.Run(ctx, t.cache, []string{t.suppressedKey()}, key, kind.String(), source, now.Format(time.RFC3339Nano), alert.Message, alert.Group).Err(); err != nil {
+			log.Infof("record suppressed alert error: %v", err)
+		}
+		return nil
+	}
+
+	var digest *Alert
+	s, claimed := t.claim(ctx, key, false)
+	if claimed {
+		d := s.alert(t.config.Renderer, now)
+		digest = &d
+	}
+	if err := deliver(digest); err != nil {
+		// 送出失敗時釋放 cooldown 並放回摘要，下一次告警（任一 instance）會重新送出
+		if delErr := t.cache.Del(ctx, cooldownKey).Err(); delErr != nil {
+			log.Infof("release alert cooldown error: %v", delErr)
+		}
+		if claimed {
+			t.restore(ctx, key, s)
+		}
+		return err
+	}
+	return nil
+}
+
+// restore 把送出失敗的摘要放回 Redis；期間已有新的略過紀錄時保留新的紀錄。
+func (t *ThrottledAlertService) restore(ctx context.Context, key string, s suppressedAlert) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	if err := t.cache.HSetNX(ctx, t.suppressedKey(), key, data).Err(); err != nil {
+		log.Infof("restore alert digest error: %v", err)
+	}
+}
+
+// claim 取出 key 的摘要；waitCooldown 為 true 時只在 cooldown 結束後取出。
+func (t *ThrottledAlertService) claim(ctx context.Context, key string, waitCooldown bool) (suppressedAlert, bool) {
+	// cooldown key 與摘要 hash 不在同一個 cluster slot，先另外確認 cooldown 已結束
+	if waitCooldown {
+		n, err := t.cache.Exists(ctx, t.cooldownKey(key)).Result()
+		if err != nil {
+			log.Infof("check alert cooldown error: %v", err)
+			return suppressedAlert{}, false
+		}
+		if n > 0 {
+			return suppressedAlert{}, false
+		}
+	}
+	raw, err := claimDigestScript.Run(ctx, t.cache, []string{t.suppressedKey()}, key).Text()
+	if err != nil {
+		if err != redis.Nil {
+			log.Infof("claim alert digest error: %v", err)
+		}
+		return suppressedAlert{}, false
+	}
+	s := suppressedAlert{}
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		log.Infof("decode alert digest error: %v", err)
+		return suppressedAlert{}, false
+	}
+	return s, true
+}
+
+// flush 送出 cooldown 已結束、之後沒有新告警帶出的摘要。
+func (t *ThrottledAlertService) flush(ctx context.Context, now time.Time) {
+	keys, err := t.cache.HKeys(ctx, t.suppressedKey()).Result()
+	if err != nil {
+		log.Infof("list alert digests error: %v", err)
+		return
+	}
+	for _, key := range keys {
+		s, ok := t.claim(ctx, key, true)
+		if !ok {
+			continue
+		}
+		if err := t.inner.SendAlert(ctx, s.alert(t.config.Renderer, now)); err != nil {
+			log.Infof("send alert digest error: %v", err)
+			t.restore(ctx, key, s)
+		}
+	}
+}
+
+// FlushDigest 立即送出 cooldown 已結束的摘要。
+func (t *ThrottledAlertService) FlushDigest(ctx context.Context) {
+	t.flush(ctx, time.Now())
+}
+
+func (t *ThrottledAlertService) Start(ctx context.Context) error {
+	if !t.run.begin() {
+		return nil
+	}
+	defer t.run.end()
+	ticker := time.NewTicker(t.config.DigestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.run.stop:
+			return nil
+		case <-ticker.C:
+			t.FlushDigest(ctx)
+		}
+	}
+}
+
+func (t *ThrottledAlertService) Stop(ctx context.Context) error {
+	return t.run.shutdown(ctx)
+}
+
+func (t *ThrottledAlertService) cooldownKey(key string) string {
+	return fmt.Sprintf("%v:cooldown:%v", t.config.RedisKeyPrefix, key)
+}
+
+func (t *ThrottledAlertService) suppressedKey() string {
+	return fmt.Sprintf("%v:suppressed", t.config.RedisKeyPrefix)
+}
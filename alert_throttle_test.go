@@ -0,0 +1,100 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type recordingSink struct {
+	alerts []Alert
+	err    error
+}
+
+func (s *recordingSink) SendAlert(_ context.Context, alert Alert) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+func switchAlert(msg string) Alert {
+	return Alert{
+		Kind:    AlertKindError,
+		Type:    AlertTemplateSwitch,
+		Source:  "Binance",
+		From:    ExchangeConnectorTypeBinance,
+		To:      ExchangeConnectorTypeOKX,
+		Message: msg,
+	}
+}
+
+func TestThrottleReleasesCooldownOnDeliveryFailure(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	sink := &recordingSink{err: errors.New("slack down")}
+	throttle := NewThrottledAlertSink(sink, rdb, AlertThrottleConfig{})
+	ctx := context.Background()
+
+	if err := throttle.SendAlert(ctx, switchAlert("switch 1")); err == nil {
+		t.Fatal("expected delivery error")
+	}
+	sink.err = nil
+	if err := throttle.SendAlert(ctx, switchAlert("switch 2")); err != nil {
+		t.Fatalf("second send: %v", err)
+	}
+	if len(sink.alerts) != 1 {
+		t.Fatalf("got %d alerts after failed delivery, want 1", len(sink.alerts))
+	}
+}
+
+func TestThrottleDedupKey(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	sink := &recordingSink{}
+	throttle := NewThrottledAlertSink(sink, rdb, AlertThrottleConfig{})
+	ctx := context.Background()
+
+	// 同一範本與方向只送一次，訊息中的次數不影響
+	_ = throttle.SendAlert(ctx, switchAlert("failures: 3"))
+	_ = throttle.SendAlert(ctx, switchAlert("failures: 5"))
+	// 沒有範本的告警只合併完全相同的訊息
+	_ = throttle.SendAlert(ctx, legacyAlert(AlertKindError, "Binance", "http 503"))
+	_ = throttle.SendAlert(ctx, legacyAlert(AlertKindError, "Binance", "http 429"))
+	_ = throttle.SendAlert(ctx, legacyAlert(AlertKindError, "Binance", "http 429"))
+
+	if len(sink.alerts) != 3 {
+		t.Fatalf("got %d alerts, want 3", len(sink.alerts))
+	}
+}
+
+func TestThrottleRecoveryDigestKeepsKind(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	sink := &recordingSink{}
+	throttle := NewThrottledAlertSink(sink, rdb, AlertThrottleConfig{Cooldown: time.Minute})
+	ctx := context.Background()
+
+	_ = throttle.SendRecoveryMessage("Binance", "recovered")
+	_ = throttle.SendRecoveryMessage("Binance", "recovered")
+	mr.FastForward(2 * time.Minute)
+	throttle.FlushDigest(ctx)
+
+	if len(sink.alerts) != 2 {
+		t.Fatalf("got %d alerts, want alert and digest", len(sink.alerts))
+	}
+	digest := sink.alerts[1]
+	if digest.Type != AlertTemplateDigest || digest.Kind != AlertKindRecovery || digest.Severity != AlertSeverityInfo {
+		t.Fatalf("digest = %v/%v/%v, want digest/recovery/info", digest.Type, digest.Kind, digest.Severity)
+	}
+}
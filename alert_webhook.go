@@ -0,0 +1,124 @@
+package failover
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	DefaultWebhookSignatureHeader = "X-Failover-Signature"
+	DefaultWebhookTimestampHeader = "X-Failover-Timestamp"
+)
+
+type WebhookConfig struct {
+	URL string
+	// PayloadTemplate 以 text/template 對 Alert 產生請求內容，未設定時送出 Alert 的 JSON；
+	// 範本內可用 json 函式輸出跳脫後的 JSON 值，例如 {"text": {{json .Message}}}
+	PayloadTemplate string
+	ContentType     string
+	// Secret 不為空時以 HMAC-SHA256 對 "timestamp.body" 簽章，放在 SignatureHeader（"sha256=<hex>"）
+	Secret          string
+	SignatureHeader string
+	TimestampHeader string
+	Headers         map[string]string
+	HTTP            AlertHTTPConfig
+}
+
+// WebhookSink 把告警以 JSON POST 到指定的 URL，同時實作 AlertSink 與 IAlertService。
+type WebhookSink struct {
+	config  WebhookConfig
+	payload *template.Template
+}
+
+func NewWebhookSink(cfg WebhookConfig) (*WebhookSink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook url is required")
+	}
+	if cfg.ContentType == "" {
+		cfg.ContentType = "application/json"
+	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = DefaultWebhookSignatureHeader
+	}
+	if cfg.TimestampHeader == "" {
+		cfg.TimestampHeader = DefaultWebhookTimestampHeader
+	}
+	cfg.HTTP = cfg.HTTP.withDefaults()
+
+	s := &WebhookSink{config: cfg}
+	if cfg.PayloadTemplate != "" {
+		tmpl, err := template.New("webhook").Funcs(alertTemplateFuncs).Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Parse(cfg.PayloadTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("parse webhook payload template: %w", err)
+		}
+		s.payload = tmpl
+	}
+	return s, nil
+}
+
+func (s *WebhookSink) SendAlert(ctx context.Context, alert Alert) error {
+	body, err := s.body(alert)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", s.config.ContentType)
+	for k, v := range s.config.Headers {
+		header.Set(k, v)
+	}
+	if s.config.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		header.Set(s.config.TimestampHeader, ts)
+		header.Set(s.config.SignatureHeader, "sha256="+SignWebhook(s.config.Secret, ts, body))
+	}
+	if err := postAlert(ctx, s.config.HTTP, s.config.URL, body, header); err != nil {
+		return fmt.Errorf("send webhook alert: %w", err)
+	}
+	return nil
+}
+
+func (s *WebhookSink) body(alert Alert) ([]byte, error) {
+	if s.payload == nil {
+		return json.Marshal(alert)
+	}
+	var sb strings.Builder
+	if err := s.payload.Execute(&sb, alert); err != nil {
+		return nil, fmt.Errorf("render webhook payload: %w", err)
+	}
+	return []byte(sb.String()), nil
+}
+
+func (s *WebhookSink) SendErrorAlert(source, msg string) error {
+	return s.SendAlert(context.Background(), legacyAlert(AlertKindError, source, msg))
+}
+
+func (s *WebhookSink) SendRecoveryAlert(source string) error {
+	return s.SendRecoveryMessage(source, "")
+}
+
+func (s *WebhookSink) SendRecoveryMessage(source, msg string) error {
+	return s.SendAlert(context.Background(), legacyAlert(AlertKindRecovery, source, msg))
+}
+
+// SignWebhook 回傳 webhook 簽章（hex），接收端以相同的 secret、timestamp header 與原始 body 驗證。
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,351 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: failover_admin.proto
+
+package v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// FailoverAdminClient is the client API for FailoverAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FailoverAdminClient interface {
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*FailoverStatus, error)
+	ForceSwitch(ctx context.Context, in *ForceSwitchRequest, opts ...grpc.CallOption) (*FailoverStatus, error)
+	Pin(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*FailoverStatus, error)
+	Unpin(ctx context.Context, in *UnpinRequest, opts ...grpc.CallOption) (*FailoverStatus, error)
+	ClearLock(ctx context.Context, in *ClearLockRequest, opts ...grpc.CallOption) (*FailoverStatus, error)
+	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsReply, error)
+	// WatchStatus 先送出目前狀態，之後狀態有變化時再送出。
+	WatchStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (FailoverAdmin_WatchStatusClient, error)
+}
+
+type failoverAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFailoverAdminClient(cc grpc.ClientConnInterface) FailoverAdminClient {
+	return &failoverAdminClient{cc}
+}
+
+func (c *failoverAdminClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*FailoverStatus, error) {
+	out := new(FailoverStatus)
+	err := c.cc.Invoke(ctx, "/exchange.failover.admin.v1.FailoverAdmin/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *failoverAdminClient) ForceSwitch(ctx context.Context, in *ForceSwitchRequest, opts ...grpc.CallOption) (*FailoverStatus, error) {
+	out := new(FailoverStatus)
+	err := c.cc.Invoke(ctx, "/exchange.failover.admin.v1.FailoverAdmin/ForceSwitch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *failoverAdminClient) Pin(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*FailoverStatus, error) {
+	out := new(FailoverStatus)
+	err := c.cc.Invoke(ctx, "/exchange.failover.admin.v1.FailoverAdmin/Pin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *failoverAdminClient) Unpin(ctx context.Context, in *UnpinRequest, opts ...grpc.CallOption) (*FailoverStatus, error) {
+	out := new(FailoverStatus)
+	err := c.cc.Invoke(ctx, "/exchange.failover.admin.v1.FailoverAdmin/Unpin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *failoverAdminClient) ClearLock(ctx context.Context, in *ClearLockRequest, opts ...grpc.CallOption) (*FailoverStatus, error) {
+	out := new(FailoverStatus)
+	err := c.cc.Invoke(ctx, "/exchange.failover.admin.v1.FailoverAdmin/ClearLock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *failoverAdminClient) ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsReply, error) {
+	out := new(ListEventsReply)
+	err := c.cc.Invoke(ctx, "/exchange.failover.admin.v1.FailoverAdmin/ListEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *failoverAdminClient) WatchStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (FailoverAdmin_WatchStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &FailoverAdmin_ServiceDesc.Streams[0], "/exchange.failover.admin.v1.FailoverAdmin/WatchStatus", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &failoverAdminWatchStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type FailoverAdmin_WatchStatusClient interface {
+	Recv() (*FailoverStatus, error)
+	grpc.ClientStream
+}
+
+type failoverAdminWatchStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *failoverAdminWatchStatusClient) Recv() (*FailoverStatus, error) {
+	m := new(FailoverStatus)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FailoverAdminServer is the server API for FailoverAdmin service.
+// All implementations must embed UnimplementedFailoverAdminServer
+// for forward compatibility
+type FailoverAdminServer interface {
+	GetStatus(context.Context, *GetStatusRequest) (*FailoverStatus, error)
+	ForceSwitch(context.Context, *ForceSwitchRequest) (*FailoverStatus, error)
+	Pin(context.Context, *PinRequest) (*FailoverStatus, error)
+	Unpin(context.Context, *UnpinRequest) (*FailoverStatus, error)
+	ClearLock(context.Context, *ClearLockRequest) (*FailoverStatus, error)
+	ListEvents(context.Context, *ListEventsRequest) (*ListEventsReply, error)
+	// WatchStatus 先送出目前狀態，之後狀態有變化時再送出。
+	WatchStatus(*WatchStatusRequest, FailoverAdmin_WatchStatusServer) error
+	mustEmbedUnimplementedFailoverAdminServer()
+}
+
+// UnimplementedFailoverAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedFailoverAdminServer struct {
+}
+
+func (UnimplementedFailoverAdminServer) GetStatus(context.Context, *GetStatusRequest) (*FailoverStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedFailoverAdminServer) ForceSwitch(context.Context, *ForceSwitchRequest) (*FailoverStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceSwitch not implemented")
+}
+func (UnimplementedFailoverAdminServer) Pin(context.Context, *PinRequest) (*FailoverStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pin not implemented")
+}
+func (UnimplementedFailoverAdminServer) Unpin(context.Context, *UnpinRequest) (*FailoverStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unpin not implemented")
+}
+func (UnimplementedFailoverAdminServer) ClearLock(context.Context, *ClearLockRequest) (*FailoverStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearLock not implemented")
+}
+func (UnimplementedFailoverAdminServer) ListEvents(context.Context, *ListEventsRequest) (*ListEventsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
+}
+func (UnimplementedFailoverAdminServer) WatchStatus(*WatchStatusRequest, FailoverAdmin_WatchStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStatus not implemented")
+}
+func (UnimplementedFailoverAdminServer) mustEmbedUnimplementedFailoverAdminServer() {}
+
+// UnsafeFailoverAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FailoverAdminServer will
+// result in compilation errors.
+type UnsafeFailoverAdminServer interface {
+	mustEmbedUnimplementedFailoverAdminServer()
+}
+
+func RegisterFailoverAdminServer(s grpc.ServiceRegistrar, srv FailoverAdminServer) {
+	s.RegisterService(&FailoverAdmin_ServiceDesc, srv)
+}
+
+func _FailoverAdmin_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FailoverAdminServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/exchange.failover.admin.v1.FailoverAdmin/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FailoverAdminServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FailoverAdmin_ForceSwitch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForceSwitchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FailoverAdminServer).ForceSwitch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/exchange.failover.admin.v1.FailoverAdmin/ForceSwitch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FailoverAdminServer).ForceSwitch(ctx, req.(*ForceSwitchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FailoverAdmin_Pin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FailoverAdminServer).Pin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/exchange.failover.admin.v1.FailoverAdmin/Pin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FailoverAdminServer).Pin(ctx, req.(*PinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FailoverAdmin_Unpin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnpinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FailoverAdminServer).Unpin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/exchange.failover.admin.v1.FailoverAdmin/Unpin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FailoverAdminServer).Unpin(ctx, req.(*UnpinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FailoverAdmin_ClearLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearLockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FailoverAdminServer).ClearLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/exchange.failover.admin.v1.FailoverAdmin/ClearLock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FailoverAdminServer).ClearLock(ctx, req.(*ClearLockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FailoverAdmin_ListEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FailoverAdminServer).ListEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/exchange.failover.admin.v1.FailoverAdmin/ListEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FailoverAdminServer).ListEvents(ctx, req.(*ListEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FailoverAdmin_WatchStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FailoverAdminServer).WatchStatus(m, &failoverAdminWatchStatusServer{stream})
+}
+
+type FailoverAdmin_WatchStatusServer interface {
+	Send(*FailoverStatus) error
+	grpc.ServerStream
+}
+
+type failoverAdminWatchStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *failoverAdminWatchStatusServer) Send(m *FailoverStatus) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// FailoverAdmin_ServiceDesc is the grpc.ServiceDesc for FailoverAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FailoverAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "exchange.failover.admin.v1.FailoverAdmin",
+	HandlerType: (*FailoverAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _FailoverAdmin_GetStatus_Handler,
+		},
+		{
+			MethodName: "ForceSwitch",
+			Handler:    _FailoverAdmin_ForceSwitch_Handler,
+		},
+		{
+			MethodName: "Pin",
+			Handler:    _FailoverAdmin_Pin_Handler,
+		},
+		{
+			MethodName: "Unpin",
+			Handler:    _FailoverAdmin_Unpin_Handler,
+		},
+		{
+			MethodName: "ClearLock",
+			Handler:    _FailoverAdmin_ClearLock_Handler,
+		},
+		{
+			MethodName: "ListEvents",
+			Handler:    _FailoverAdmin_ListEvents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStatus",
+			Handler:       _FailoverAdmin_WatchStatus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "failover_admin.proto",
+}
@@ -0,0 +1,3 @@
+package v1
+
+//go:generate protoc --proto_path=. --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. failover_admin.proto
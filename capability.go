@@ -0,0 +1,55 @@
+package failover
+
+type Capability string
+
+const (
+	CapabilityMarketData     Capability = "market_data"
+	CapabilitySpotTrading    Capability = "spot_trading"
+	CapabilityFuturesTrading Capability = "futures_trading"
+	CapabilityWallet         Capability = "wallet"
+)
+
+func (c Capability) String() string {
+	return string(c)
+}
+
+var methodCapabilities = map[string]Capability{
+	"Klines":                            CapabilityMarketData,
+	"ClosingTimeRemaining":              CapabilityMarketData,
+	"GetPriceHistoryIntervalLimit":      CapabilityMarketData,
+	"NewestQuoteTicker":                 CapabilityMarketData,
+	"SymbolPriceTicker":                 CapabilityMarketData,
+	"GetSpotPrecision":                  CapabilityMarketData,
+	"GetUSDTMFuturesPrecision":          CapabilityMarketData,
+	"FuturesExchangeInfo":               CapabilityMarketData,
+	"SpotTrade":                         CapabilitySpotTrading,
+	"SpotAllOrders":                     CapabilitySpotTrading,
+	"SpotAccountTradeList":              CapabilitySpotTrading,
+	"GetCommission":                     CapabilitySpotTrading,
+	"SpotCancelOrder":                   CapabilitySpotTrading,
+	"SpotCancelAllOrders":               CapabilitySpotTrading,
+	"SpotQueryOrder":                    CapabilitySpotTrading,
+	"SpotOpenOrders":                    CapabilitySpotTrading,
+	"SpotAmendOrder":                    CapabilitySpotTrading,
+	"FutureTrade":                       CapabilityFuturesTrading,
+	"PerpAccountTradeList":              CapabilityFuturesTrading,
+	"GetFuturesBills":                   CapabilityFuturesTrading,
+	"FuturesAccount":                    CapabilityFuturesTrading,
+	"FuturesAccountPositionRisk":        CapabilityFuturesTrading,
+	"FuturesTransfer":                   CapabilityFuturesTrading,
+	"FuturesCancelOrder":                CapabilityFuturesTrading,
+	"FuturesCancelAllOrders":            CapabilityFuturesTrading,
+	"FuturesQueryOrder":                 CapabilityFuturesTrading,
+	"FuturesOpenOrders":                 CapabilityFuturesTrading,
+	"FuturesAmendOrder":                 CapabilityFuturesTrading,
+	"SpotAssets":                        CapabilityWallet,
+	"CapitalCoinGetAll":                 CapabilityWallet,
+	"SpotWithdraw":                      CapabilityWallet,
+	"SpotWithdrawRecord":                CapabilityWallet,
+	"SpotAccountInternalTransferRecord": CapabilityWallet,
+}
+
+// MethodCapability 回傳 ExchangeConnector 方法所屬的功能分類，未知的方法回傳空字串。
+func MethodCapability(method string) Capability {
+	return methodCapabilities[method]
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	failover "github.com/yourorg/exchange-failover"
+)
+
+// connectorFactories 建立 probe 使用的 connector。這個 module 只有 Binance 的 connector，
+// 內部版本可另外加一個檔案，在 init() 中登記自己的實作，例如：
+//
+//	func init() {
+//		connectorFactories[failover.ExchangeConnectorTypeOKX] = func() (failover.ExchangeConnector, error) {
+//			return okx.NewConnector(os.Getenv("EXFO_OKX_API_KEY"), ...), nil
+//		}
+//	}
+var connectorFactories = map[failover.ExchangeConnectorType]func() (failover.ExchangeConnector, error){
+	failover.ExchangeConnectorTypeBinance: func() (failover.ExchangeConnector, error) {
+		return failover.NewBinanceConnector(
+			os.Getenv("EXFO_BINANCE_API_KEY"),
+			os.Getenv("EXFO_BINANCE_SECRET_KEY"),
+			envOr("EXFO_BINANCE_BASE_URL", "https://api.binance.com"),
+		), nil
+	},
+}
+
+func newConnector(ct failover.ExchangeConnectorType) (failover.ExchangeConnector, error) {
+	factory, ok := connectorFactories[ct]
+	if !ok {
+		return nil, fmt.Errorf("no connector factory for %v in this build", ct)
+	}
+	return factory()
+}
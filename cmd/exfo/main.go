@@ -0,0 +1,394 @@
+// exfo 直接操作 proxy 使用的 Redis 狀態，供值班時取代 redis-cli 使用。
+//
+//	exfo [-redis addr] [-json] <command> [args]
+//
+//	status                                  目前交易所、LockTime、錯誤視窗
+//	switch <connector> -reason ...          強制切換
+//	pin <connector> -ttl 2h -reason ...     固定交易所
+//	unpin -reason ...                       解除固定
+//	history -since 24h -limit 50            事件紀錄
+//	watch -interval 1s                      持續輸出狀態與新事件
+//	probe <connector> [-symbol BTCUSDT]     以唯讀 API 探測 connector，不影響錯誤計數
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	failover "github.com/yourorg/exchange-failover"
+)
+
+type cli struct {
+	proxy    failover.ExchangeApiProxyImpl
+	opts     []failover.ProxyOption
+	asJSON   bool
+	operator string
+}
+
+func main() {
+	global := flag.NewFlagSet("exfo", flag.ExitOnError)
+	redisAddr := global.String("redis", envOr("EXFO_REDIS_ADDR", "localhost:6379"), "redis address")
+	redisPassword := global.String("redis-password", os.Getenv("EXFO_REDIS_PASSWORD"), "redis password")
+	redisDB := global.Int("redis-db", 0, "redis db")
+	keyConnector := global.String("key-connector", failover.DefaultConfig.RedisKeyConnector, "redis key of current connector")
+	keyLockTime := global.String("key-lock", failover.DefaultConfig.RedisKeyLockTime, "redis key of lock time")
+	keyErrTimeAt := global.String("key-errtime", failover.DefaultConfig.RedisKeyErrTimeAt, "redis key prefix of failure window")
+	keyEvents := global.String("key-events", failover.DefaultConfig.RedisKeyEvents, "redis key of event stream")
+	keyPin := global.String("key-pin", failover.DefaultConfig.RedisKeyPin, "redis key of pin")
+	hashTag := global.String("hash-tag", "", "redis cluster hash tag of state keys, overrides -key-connector, -key-lock and -key-pin")
+	asJSON := global.Bool("json", false, "print JSON")
+	operator := global.String("operator", envOr("USER", "exfo"), "operator recorded in events")
+	global.Usage = usage
+	_ = global.Parse(os.Args[1:])
+
+	args := global.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	rdb := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:    strings.Split(*redisAddr, ","),
+		Password: *redisPassword,
+		DB:       *redisDB,
+	})
+	defer rdb.Close()
+
+	cfg := failover.DefaultConfig
+	for _, opt := range []failover.Option{
+		failover.WithRedisKeys(*keyConnector, *keyLockTime, *keyErrTimeAt),
+		failover.WithRedisKeyEvents(*keyEvents),
+		failover.WithRedisKeyPin(*keyPin),
+		failover.WithInstanceID("exfo/" + *operator),
+	} {
+		opt(&cfg)
+	}
+	if *hashTag != "" {
+		failover.WithRedisHashTag(*hashTag)(&cfg)
+	}
+
+	proxyOpts := []failover.ProxyOption{
+		failover.WithCache(rdb),
+		failover.WithConfig(cfg),
+	}
+	if err := failover.ValidateProxyOptions(proxyOpts...); err != nil {
+		fmt.Fprintf(os.Stderr, "exfo: %v\n", err)
+		os.Exit(2)
+	}
+	c := cli{
+		proxy:    failover.NewProxy(proxyOpts...),
+		opts:     proxyOpts,
+		asJSON:   *asJSON,
+		operator: *operator,
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := c.run(ctx, args[0], args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "exfo %v: %v\n", args[0], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `usage: exfo [global flags] <command> [args]
+
+commands:
+  status                                current connector, lock TTL, failure windows
+  switch <connector> -reason ...        force switch to connector
+  pin <connector> -ttl 2h -reason ...   pin connector for ttl
+  unpin -reason ...                     remove pin
+  history -since 24h -limit 50          failover events
+  watch -interval 1s                    live tail of status and events
+  probe <connector> [-symbol BTCUSDT]   read-only call through the connector, default SymbolPriceTicker
+
+global flags:
+  -redis, -redis-password, -redis-db, -key-*, -json, -operator
+`)
+}
+
+func (c cli) run(ctx context.Context, cmd string, args []string) error {
+	switch cmd {
+	case "status":
+		return c.status(ctx)
+	case "switch":
+		return c.switchConnector(ctx, args)
+	case "pin":
+		return c.pin(ctx, args)
+	case "unpin":
+		return c.unpin(ctx, args)
+	case "history":
+		return c.history(ctx, args)
+	case "watch":
+		return c.watch(ctx, args)
+	case "probe":
+		return c.probe(args)
+	}
+	usage()
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+func (c cli) status(ctx context.Context) error {
+	status, err := c.proxy.Status(ctx)
+	if err != nil {
+		return err
+	}
+	c.printStatus(status)
+	return nil
+}
+
+func (c cli) switchConnector(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("switch", flag.ExitOnError)
+	reason := fs.String("reason", "", "reason recorded in events")
+	ct, err := connectorArg(fs, args)
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return fmt.Errorf("-reason is required")
+	}
+	if err := c.proxy.ForceSwitch(ctx, ct, c.operator, *reason); err != nil {
+		return err
+	}
+	return c.status(ctx)
+}
+
+func (c cli) pin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("pin", flag.ExitOnError)
+	ttl := fs.Duration("ttl", time.Hour, "pin duration")
+	reason := fs.String("reason", "", "reason recorded in events")
+	ct, err := connectorArg(fs, args)
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return fmt.Errorf("-reason is required")
+	}
+	if err := c.proxy.Pin(ctx, ct, *ttl, c.operator, *reason); err != nil {
+		return err
+	}
+	return c.status(ctx)
+}
+
+func (c cli) unpin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("unpin", flag.ExitOnError)
+	reason := fs.String("reason", "", "reason recorded in events")
+	_ = fs.Parse(args)
+	if err := c.proxy.Unpin(ctx, c.operator, *reason); err != nil {
+		return err
+	}
+	return c.status(ctx)
+}
+
+func (c cli) history(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	since := fs.Duration("since", 24*time.Hour, "look back duration")
+	limit := fs.Int64("limit", 50, "max events")
+	types := fs.String("type", "", "comma separated event types")
+	_ = fs.Parse(args)
+
+	filter := failover.EventFilter{Since: time.Now().Add(-*since), Limit: *limit}
+	if *types != "" {
+		for _, t := range strings.Split(*types, ",") {
+			filter.Types = append(filter.Types, failover.FailoverEventType(t))
+		}
+	}
+	events, err := c.proxy.Events(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		c.printEvent(event)
+	}
+	return nil
+}
+
+func (c cli) watch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("interval", time.Second, "poll interval")
+	_ = fs.Parse(args)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	last := ""
+	since := time.Now()
+	seen := map[string]bool{}
+	for {
+		status, err := c.proxy.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if key := status.Fingerprint(); key != last {
+			c.printStatus(status)
+			last = key
+		}
+
+		events, err := c.proxy.Events(ctx, failover.EventFilter{Since: since})
+		if err != nil {
+			return err
+		}
+		batch := map[string]bool{}
+		for _, event := range events {
+			batch[event.ID] = true
+			if seen[event.ID] {
+				continue
+			}
+			c.printEvent(event)
+			// stream ID 前半為毫秒時間，下一輪從同一毫秒開始查並略過已輸出的事件
+			var ms int64
+			if _, err := fmt.Sscanf(event.ID, "%d-", &ms); err == nil {
+				since = time.UnixMilli(ms)
+			}
+		}
+		if len(events) > 0 {
+			seen = batch
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c cli) probe(args []string) error {
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	symbol := fs.String("symbol", "", "probe with NewestQuoteTicker(symbol) instead of SymbolPriceTicker")
+	ct, err := connectorArg(fs, args)
+	if err != nil {
+		return err
+	}
+	connector, err := newConnector(ct)
+	if err != nil {
+		return err
+	}
+
+	opts := append([]failover.ProxyOption{}, c.opts...)
+	if ct == failover.ExchangeConnectorTypeOKX {
+		opts = append(opts, failover.WithStandbyConnector(connector))
+	} else {
+		opts = append(opts, failover.WithPrimaryConnector(connector))
+	}
+	if *symbol != "" {
+		opts = append(opts, failover.WithMaintenanceProbe(func(connector failover.ExchangeConnector) (failover.ExchangeApiResponse, error) {
+			return connector.NewestQuoteTicker(*symbol)
+		}))
+	}
+
+	// 與維護結束時的探測相同，直接呼叫 connector，不記錄錯誤也不觸發切換
+	start := time.Now()
+	res, err := failover.NewProxy(opts...).Probe(ct)
+	elapsed := time.Since(start)
+	if err != nil {
+		return fmt.Errorf("probe %v after %v: %w", ct, elapsed, err)
+	}
+	abnormal := !res.IsSuccess && connector.IsSystemAbnormal(res.FailureCode)
+	if c.asJSON {
+		body := json.RawMessage(res.Body)
+		if !json.Valid(body) {
+			body, _ = json.Marshal(string(res.Body))
+		}
+		if err := printJSON(map[string]interface{}{
+			"connector":      ct,
+			"success":        res.IsSuccess,
+			"failureCode":    res.FailureCode,
+			"systemAbnormal": abnormal,
+			"latencyMs":      elapsed.Milliseconds(),
+			"body":           body,
+		}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%v success=%v failureCode=%q latency=%v\n%s\n", ct, res.IsSuccess, res.FailureCode, elapsed.Round(time.Millisecond), res.Body)
+	}
+	if !res.IsSuccess {
+		return fmt.Errorf("probe failed, system abnormal=%v", abnormal)
+	}
+	return nil
+}
+
+func connectorArg(fs *flag.FlagSet, args []string) (failover.ExchangeConnectorType, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", fmt.Errorf("missing connector argument")
+	}
+	ct, err := failover.ParseConnectorType(args[0])
+	if err != nil {
+		return "", err
+	}
+	_ = fs.Parse(args[1:])
+	return ct, nil
+}
+
+func (c cli) printStatus(status failover.FailoverStatus) {
+	if c.asJSON {
+		_ = printJSON(status)
+		return
+	}
+	fmt.Printf("connector: %v (generation %d)\n", status.Connector, status.Generation)
+	if status.LockExpiresAt != nil {
+		fmt.Printf("lock:      %v left (until %v)\n", time.Until(*status.LockExpiresAt).Round(time.Second), status.LockExpiresAt.Format(time.RFC3339))
+	} else {
+		fmt.Printf("lock:      none\n")
+	}
+	if status.Pin != nil {
+		fmt.Printf("pin:       %v by %v until %v (%v)\n", status.Pin.Connector, status.Pin.Operator, status.Pin.ExpiresAt.Format(time.RFC3339), status.Pin.Reason)
+	}
+	for _, ct := range []failover.ExchangeConnectorType{failover.ExchangeConnectorTypeBinance, failover.ExchangeConnectorTypeOKX} {
+		records := status.FailureWindows[ct]
+		fmt.Printf("failures %v: %d\n", ct, len(records))
+		for _, record := range records {
+			fmt.Printf("  %v  %-8v %v\n", record.At.Format("15:04:05.000"), record.Code, record.Method)
+		}
+	}
+}
+
+func (c cli) printEvent(event failover.FailoverEvent) {
+	if c.asJSON {
+		_ = printJSON(event)
+		return
+	}
+	line := fmt.Sprintf("%v  %-14v %v -> %v  instance=%v", event.Timestamp.Format(time.RFC3339), event.Type, event.From, event.To, event.InstanceID)
+	if event.Generation > 0 {
+		line += fmt.Sprintf(" gen=%d", event.Generation)
+	}
+	if event.WindowCount > 0 {
+		line += fmt.Sprintf(" window=%d", event.WindowCount)
+	}
+	if len(event.Codes) > 0 {
+		line += fmt.Sprintf(" codes=%v", strings.Join(event.Codes, ","))
+	}
+	if len(event.Methods) > 0 {
+		line += fmt.Sprintf(" methods=%v", strings.Join(event.Methods, ","))
+	}
+	if event.Operator != "" {
+		line += fmt.Sprintf(" operator=%v", event.Operator)
+	}
+	if event.Reason != "" {
+		line += fmt.Sprintf(" reason=%q", event.Reason)
+	}
+	fmt.Println(line)
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
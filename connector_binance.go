@@ -19,8 +19,8 @@ func NewBinanceConnector(apiKey, secretKey, baseURL string) *BinanceConnector {
 func (b *BinanceConnector) IsSystemAbnormal(failureCode string) bool {
 	systemAbnormalCodes := []string{
 		"-1000", "-1001", "-1002", "-1003", "-1004", "-1005", "-1006", "-1007", "-1008",
-		"-1010", "-1011", "-1012", "-1013", "-1014", "-1015", "-1016", "-1020", "-1021", "-1022",
-		"-1102", "-1111", "-1121", "-1136",
+		"-1010", "-1011", "-1012", "-1014", "-1015", "-1016", "-1020", "-1021", "-1022",
+		"-1102", "-1121", "-1136",
 	}
 	for _, code := range systemAbnormalCodes {
 		if failureCode == code {
@@ -123,3 +123,43 @@ func (b *BinanceConnector) GetSpotPrecision(base string) (ExchangeApiResponse, e
 func (b *BinanceConnector) SymbolPriceTicker() (ExchangeApiResponse, error) {
 	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
 }
+
+func (b *BinanceConnector) SpotCancelOrder(symbol, orderID string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) SpotCancelAllOrders(symbol string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) SpotQueryOrder(symbol, orderID string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) SpotOpenOrders(symbol string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) SpotAmendOrder(symbol, orderID, quantity, price string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) FuturesCancelOrder(symbol, orderID string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) FuturesCancelAllOrders(symbol string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) FuturesQueryOrder(symbol, orderID string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) FuturesOpenOrders(symbol string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
+
+func (b *BinanceConnector) FuturesAmendOrder(symbol, orderID, quantity, price string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, fmt.Errorf("not implemented")
+}
@@ -0,0 +1,254 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+type coordinatorReportKind string
+
+const (
+	coordinatorReportFailure coordinatorReportKind = "failure"
+	coordinatorReportSuccess coordinatorReportKind = "success"
+)
+
+// coordinatorReport 為 follower 送給 leader 的回報；錯誤本身已寫入 Redis 的錯誤視窗，
+// 回報只用來讓 leader 立即重新判斷。
+type coordinatorReport struct {
+	Kind       coordinatorReportKind `json:"kind"`
+	Connector  ExchangeConnectorType `json:"connector"`
+	Code       string                `json:"code,omitempty"`
+	Method     string                `json:"method,omitempty"`
+	InstanceID string                `json:"instanceId"`
+}
+
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Coordinator 以 Redis lease 選出一個 leader，只有 leader 執行切換、切回並送出告警，
+// 其他 instance 只寫入錯誤並回報。實作 Kratos transport.Server；沒有任何 instance 持有 lease 時，
+// 各 instance 照原本方式自行判斷。
+type Coordinator struct {
+	proxy ExchangeApiProxyImpl
+	store *StateStore
+
+	mu          sync.RWMutex
+	leaderUntil time.Time
+
+	run *lifecycle
+}
+
+func newCoordinator(cache redis.UniversalClient, cfg Config) *Coordinator {
+	return &Coordinator{
+		store: NewStateStore(cache, cfg),
+		run:   newLifecycle(),
+	}
+}
+
+// IsLeader 回傳本 instance 是否持有尚未過期的 lease。
+func (c *Coordinator) IsLeader() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return time.Now().Before(c.leaderUntil)
+}
+
+// Leader 回傳目前持有 lease 的 instance ID，沒有 leader 時為空字串。
+func (c *Coordinator) Leader(ctx context.Context) (string, error) {
+	leader, err := c.store.cache.Get(ctx, c.store.config.RedisKeyLeader).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return leader, err
+}
+
+// following 回傳本 instance 是否應交由其他 instance 決定切換。
+func (c *Coordinator) following(ctx context.Context) bool {
+	if c == nil || c.IsLeader() {
+		return false
+	}
+	leader, err := c.Leader(ctx)
+	if err != nil {
+		log.Infof("read coordinator leader error: %v", err)
+		return false
+	}
+	return leader != "" && leader != c.store.config.InstanceID
+}
+
+func (c *Coordinator) report(ctx context.Context, r coordinatorReport) {
+	r.InstanceID = c.store.config.InstanceID
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	if err := c.store.cache.Publish(ctx, c.store.config.RedisChannelReports, data).Err(); err != nil {
+		log.Infof("publish coordinator report error: %v", err)
+	}
+}
+
+// campaign 取得或延長 lease。
+func (c *Coordinator) campaign(ctx context.Context) {
+	cfg := c.store.config
+	now := time.Now()
+	wasLeader := c.IsLeader()
+
+	var held bool
+	if wasLeader {
+		n, err := renewLeaseScript.Run(ctx, c.store.cache, []string{cfg.RedisKeyLeader}, cfg.InstanceID, cfg.LeaderLeaseTTL.Milliseconds()).Int()
+		if err != nil {
+			log.Infof("renew coordinator lease error: %v", err)
+		}
+		held = err == nil && n == 1
+	} else {
+		ok, err := c.store.tryAcquire(ctx, cfg.RedisKeyLeader, cfg.LeaderLeaseTTL)
+		if err != nil {
+			log.Infof("acquire coordinator lease error: %v", err)
+		}
+		held = err == nil && ok
+	}
+
+	c.mu.Lock()
+	if held {
+		c.leaderUntil = now.Add(cfg.LeaderLeaseTTL)
+	} else if !now.Before(c.leaderUntil) {
+		c.leaderUntil = time.Time{}
+	}
+	c.mu.Unlock()
+
+	if held && !wasLeader {
+		log.Infof("coordinator %v became leader", cfg.InstanceID)
+	}
+	if !held && wasLeader {
+		log.Infof("coordinator %v lost leadership", cfg.InstanceID)
+	}
+}
+
+func (c *Coordinator) handle(ctx context.Context, payload string) {
+	r := coordinatorReport{}
+	if err := json.Unmarshal([]byte(payload), &r); err != nil {
+		log.Infof("decode coordinator report error: %v", err)
+		return
+	}
+	switch r.Kind {
+	case coordinatorReportFailure:
+		if _, err := c.proxy.handleFailureReport(ctx, r.Connector); err != nil {
+			log.Infof("handle failure report from %v error: %v", r.InstanceID, err)
+		}
+	case coordinatorReportSuccess:
+		if _, err := c.proxy.resetFailureCount(ctx, r.Connector, r.Method); err != nil {
+			log.Infof("handle success report from %v error: %v", r.InstanceID, err)
+		}
+	}
+}
+
+func (c *Coordinator) Start(ctx context.Context) error {
+	if !c.run.begin() {
+		return nil
+	}
+	defer c.run.end()
+	cfg := c.store.config
+	pubsub := c.store.cache.Subscribe(ctx, cfg.RedisChannelReports)
+	defer pubsub.Close()
+	reports := pubsub.Channel()
+
+	lease := time.NewTicker(cfg.LeaderLeaseTTL / 3)
+	defer lease.Stop()
+	evaluate := time.NewTicker(cfg.CoordinatorInterval)
+	defer evaluate.Stop()
+
+	c.campaign(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.run.stop:
+			c.resign(context.Background())
+			return nil
+		case <-lease.C:
+			c.campaign(ctx)
+		case msg, ok := <-reports:
+			if !ok {
+				return nil
+			}
+			if c.IsLeader() {
+				c.handle(ctx, msg.Payload)
+			}
+		case <-evaluate.C:
+			// 回報經 pub/sub 傳送可能遺失，leader 定期重新檢查錯誤視窗
+			if c.IsLeader() {
+				if _, err := c.proxy.evaluateWindow(ctx); err != nil {
+					log.Infof("evaluate failure window error: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func (c *Coordinator) Stop(ctx context.Context) error {
+	return c.run.shutdown(ctx)
+}
+
+// resign 釋放 lease，讓其他 instance 不必等到 lease 過期。
+func (c *Coordinator) resign(ctx context.Context) {
+	if !c.IsLeader() {
+		return
+	}
+	cfg := c.store.config
+	if err := releaseLeaseScript.Run(ctx, c.store.cache, []string{cfg.RedisKeyLeader}, cfg.InstanceID).Err(); err != nil {
+		log.Infof("release coordinator lease error: %v", err)
+	}
+	c.mu.Lock()
+	c.leaderUntil = time.Time{}
+	c.mu.Unlock()
+}
+
+// handleFailureReport 由 leader 依 follower 的錯誤回報重新判斷 ct 的錯誤視窗。
+func (proxy ExchangeApiProxyImpl) handleFailureReport(ctx context.Context, ct ExchangeConnectorType) (bool, error) {
+	state := proxy.state()
+	nowConnector, gen, err := state.ConnectorState(ctx)
+	if err != nil {
+		return false, err
+	}
+	if nowConnector == ExchangeConnectorTypeOKX {
+		if gen, err = proxy.extendLock(ctx, state, gen); err != nil {
+			return false, err
+		}
+	}
+	keys, err := state.failureKeys(ctx, ct)
+	if err != nil {
+		return false, err
+	}
+	return proxy.evaluateFailures(ctx, state, ct, nowConnector, gen, keys)
+}
+
+// evaluateWindow 在仍使用主交易所時檢查其錯誤視窗，補上遺失的回報。
+func (proxy ExchangeApiProxyImpl) evaluateWindow(ctx context.Context) (bool, error) {
+	state := proxy.state()
+	nowConnector, gen, err := state.ConnectorState(ctx)
+	if err != nil || nowConnector == ExchangeConnectorTypeOKX {
+		return false, err
+	}
+	keys, err := state.failureKeys(ctx, ExchangeConnectorTypeBinance)
+	if err != nil {
+		return false, err
+	}
+	return proxy.evaluateFailures(ctx, state, ExchangeConnectorTypeBinance, nowConnector, gen, keys)
+}
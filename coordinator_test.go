@@ -0,0 +1,115 @@
+package failover
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newCoordinatorTest(t *testing.T) (leader, follower *failoverTest) {
+	t.Helper()
+	mr, client := newTestRedis(t)
+	instance := func(id string) *failoverTest {
+		cfg := DefaultConfig
+		cfg.ErrThreshold = 3
+		cfg.LeaderLeaseTTL = 50 * time.Millisecond
+		WithInstanceID(id)(&cfg)
+		return newFailoverTestOn(t, mr, client, WithConfig(cfg), WithCoordinator())
+	}
+	leader, follower = instance("a"), instance("b")
+
+	leader.proxy.Coordinator.campaign(context.Background())
+	follower.proxy.Coordinator.campaign(context.Background())
+	if !leader.proxy.Coordinator.IsLeader() || follower.proxy.Coordinator.IsLeader() {
+		t.Fatal("expected instance a to hold the lease")
+	}
+	return leader, follower
+}
+
+// expireLease 讓 lease 在本地與 Redis 上都過期，模擬 leader 停止續約。
+func expireLease(ft *failoverTest) {
+	ttl := ft.proxy.config().LeaderLeaseTTL
+	time.Sleep(ttl + 10*time.Millisecond)
+	ft.mr.FastForward(ttl + 10*time.Millisecond)
+}
+
+func TestCoordinatorFollowerDoesNotSwitch(t *testing.T) {
+	ctx := context.Background()
+	_, follower := newCoordinatorTest(t)
+	if !follower.proxy.Coordinator.following(ctx) {
+		t.Fatal("instance b should follow")
+	}
+
+	follower.binance.fail("-1001")
+	follower.failures(t, nil, 3)
+	if ct, _ := follower.connector(t); ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("follower switched: connector = %v", ct)
+	}
+	records, err := follower.proxy.state().FailureWindow(ctx, ExchangeConnectorTypeBinance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("failure window = %v records, want 3", len(records))
+	}
+}
+
+func TestCoordinatorLeaseNotTakenBeforeExpiry(t *testing.T) {
+	ctx := context.Background()
+	leader, follower := newCoordinatorTest(t)
+	follower.proxy.Coordinator.campaign(ctx)
+	if follower.proxy.Coordinator.IsLeader() {
+		t.Fatal("lease taken over while still held")
+	}
+	if id, _ := leader.proxy.Coordinator.Leader(ctx); id != "a" {
+		t.Fatalf("leader = %q, want a", id)
+	}
+}
+
+func TestCoordinatorLeaseTakeoverAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	leader, follower := newCoordinatorTest(t)
+
+	follower.binance.fail("-1001")
+	follower.failures(t, nil, 2)
+
+	// leader 停止續約，lease 過期後由 follower 接手
+	expireLease(leader)
+	follower.proxy.Coordinator.campaign(ctx)
+	if !follower.proxy.Coordinator.IsLeader() {
+		t.Fatal("instance b did not take over the expired lease")
+	}
+	leader.proxy.Coordinator.campaign(ctx)
+	if leader.proxy.Coordinator.IsLeader() {
+		t.Fatal("instance a still leader after losing the lease")
+	}
+	if !leader.proxy.Coordinator.following(ctx) {
+		t.Fatal("instance a should follow the new leader")
+	}
+
+	// 新 leader 沿用 Redis 上的錯誤視窗自行切換
+	follower.failures(t, nil, 1)
+	if ct, _ := follower.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeOKX)
+	}
+	events := follower.events(t, FailoverEventSwitch)
+	if len(events) != 1 || events[0].InstanceID != "b" {
+		t.Fatalf("switch events = %+v", events)
+	}
+	if got := leader.alerts(AlertTemplateSwitch); len(got) != 0 {
+		t.Fatalf("old leader sent switch alerts: %+v", got)
+	}
+}
+
+func TestCoordinatorResignReleasesLease(t *testing.T) {
+	ctx := context.Background()
+	leader, follower := newCoordinatorTest(t)
+	leader.proxy.Coordinator.resign(ctx)
+	if leader.proxy.Coordinator.IsLeader() {
+		t.Fatal("still leader after resign")
+	}
+	follower.proxy.Coordinator.campaign(ctx)
+	if !follower.proxy.Coordinator.IsLeader() {
+		t.Fatal("lease not released by resign")
+	}
+}
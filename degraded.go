@@ -0,0 +1,251 @@
+package failover
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// degradedState 為 Redis 無法連線期間，單一 instance 自行維護的狀態。
+type degradedState struct {
+	since     time.Time
+	nextProbe time.Time
+	failures  map[ExchangeConnectorType][]FailureRecord
+	// connector 為降級期間本地做出的切換決定，空字串表示沿用最後已知狀態
+	connector ExchangeConnectorType
+	lockUntil time.Time
+	switched  []FailureRecord
+}
+
+func (c *StateCache) remember(snap StateSnapshot) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = &snap
+}
+
+func (c *StateCache) isDegraded() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.degraded != nil
+}
+
+// enterDegraded 進入降級模式，回傳是否由本次呼叫進入。
+func (c *StateCache) enterDegraded(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.degraded != nil {
+		return false
+	}
+	c.degraded = &degradedState{
+		since:     now,
+		nextProbe: now.Add(c.probeInterval),
+		failures:  map[ExchangeConnectorType][]FailureRecord{},
+	}
+	return true
+}
+
+// probeDue 每 DegradedProbeInterval 只放行一次 Redis 讀取，其餘呼叫直接使用本地狀態，
+// 避免每次呼叫都等待連線逾時。
+func (c *StateCache) probeDue(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.degraded == nil || now.Before(c.degraded.nextProbe) {
+		return false
+	}
+	c.degraded.nextProbe = now.Add(c.probeInterval)
+	return true
+}
+
+func (c *StateCache) leaveDegraded() *degradedState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	local := c.degraded
+	c.degraded = nil
+	return local
+}
+
+// degradedSnapshot 以最後已知狀態疊上本地的切換決定；從未成功讀取 Redis 時視為使用主交易所。
+func (c *StateCache) degradedSnapshot(now time.Time) StateSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	snap := StateSnapshot{}
+	if c.last != nil {
+		snap = *c.last
+	}
+	if c.degraded != nil && c.degraded.connector != "" {
+		snap.Connector = c.degraded.connector
+		snap.LockExpiresAt = c.degraded.lockUntil
+	}
+	// 到期的 pin 無法從 Redis 清除，直接忽略
+	if snap.Pin != nil && snap.Pin.Expired(now) {
+		snap.Pin = nil
+	}
+	return snap
+}
+
+// addLocalFailure 以與 Redis 相同的規則在本地計數，達到閾值時回傳 true 與視窗內的紀錄。
+func (c *StateCache) addLocalFailure(cfg Config, ct ExchangeConnectorType, failureCode, method string, now time.Time) (bool, []FailureRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	local := c.degraded
+	if local == nil {
+		return false, nil
+	}
+
+	nowConnector := local.connector
+	if nowConnector == "" && c.last != nil {
+		nowConnector = c.last.Connector
+	}
+	if nowConnector == ExchangeConnectorTypeOKX {
+		local.connector = ExchangeConnectorTypeOKX
+		local.lockUntil = now.Add(cfg.LockTimeTTL)
+	}
+
+	records := []FailureRecord{}
+	for _, record := range local.failures[ct] {
+		if now.Sub(record.At) < cfg.ErrTTL {
+			records = append(records, record)
+		}
+	}
+	records = append(records, FailureRecord{Code: failureCode, Method: method, At: now})
+	local.failures[ct] = records
+
+	if len(records) < cfg.ErrThreshold || nowConnector == ExchangeConnectorTypeOKX {
+		return false, nil
+	}
+	local.connector = ExchangeConnectorTypeOKX
+	local.lockUntil = now.Add(cfg.LockTimeTTL)
+	local.switched = records
+	return true, records
+}
+
+// recoverLocally 在本地 LockTime 過期後切回主交易所，回傳本地切走的時間（降級前已切走時為零值）。
+func (c *StateCache) recoverLocally(now time.Time) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	local := c.degraded
+	if local == nil || local.connector != ExchangeConnectorTypeOKX || now.Before(local.lockUntil) {
+		return time.Time{}, false
+	}
+	since := time.Time{}
+	if n := len(local.switched); n > 0 {
+		since = local.switched[n-1].At
+	}
+	local.connector = ExchangeConnectorTypeBinance
+	local.lockUntil = time.Time{}
+	local.switched = nil
+	delete(local.failures, ExchangeConnectorTypeBinance)
+	return since, true
+}
+
+// degrade 在 Redis 讀寫失敗時進入降級模式，回傳 false 表示無法降級（未使用 NewProxy 或 ctx 已結束），
+// 應照原本方式回傳錯誤。
+func (proxy ExchangeApiProxyImpl) degrade(ctx context.Context, err error) bool {
+	if proxy.StateCache == nil || ctx.Err() != nil {
+		return false
+	}
+	now := time.Now()
+	if proxy.StateCache.enterDegraded(now) {
+		snap := proxy.StateCache.degradedSnapshot(now)
+		log.Infof("redis unavailable, enter degraded mode: %v", err)
+		proxy.sendAlert(ctx, AlertTemplateDegradedEnter, "Redis", AlertData{
+			From:        connectorOrPrimary(snap.Connector),
+			To:          connectorOrPrimary(snap.Connector),
+			Generation:  snap.Generation,
+			Err:         err.Error(),
+			OutageStart: now,
+		})
+	}
+	return true
+}
+
+func (proxy ExchangeApiProxyImpl) addLocalFailure(ctx context.Context, ct ExchangeConnectorType, failureCode, method string) bool {
+	switched, records := proxy.StateCache.addLocalFailure(proxy.config(), ct, failureCode, method, time.Now())
+	if switched {
+		log.Infof("degraded mode switch to %v after %v failures", ExchangeConnectorTypeOKX, len(records))
+		codes, methods := summarizeFailures(records)
+		proxy.sendAlert(ctx, AlertTemplateDegradedSwitch, ct.String(), AlertData{
+			From:        ct,
+			To:          ExchangeConnectorTypeOKX,
+			WindowCount: len(records),
+			Codes:       codes,
+			Methods:     methods,
+			LockTTL:     proxy.config().LockTimeTTL,
+			// 與 recoverLocally 回傳的切走時間相同，切回告警接在同一個 thread
+			OutageStart: records[len(records)-1].At,
+		})
+	}
+	return switched
+}
+
+func (proxy ExchangeApiProxyImpl) recoverLocally(ctx context.Context) bool {
+	now := time.Now()
+	since, ok := proxy.StateCache.recoverLocally(now)
+	if !ok {
+		return false
+	}
+	log.Infof("degraded mode recover to %v", ExchangeConnectorTypeBinance)
+	data := AlertData{
+		From:        ExchangeConnectorTypeOKX,
+		To:          ExchangeConnectorTypeBinance,
+		OutageStart: since,
+	}
+	if !since.IsZero() {
+		data.OutageDuration = now.Sub(since)
+	}
+	proxy.sendAlert(ctx, AlertTemplateRecovery, ExchangeConnectorTypeBinance.String(), data)
+	return true
+}
+
+// reconcileDegraded 在 Redis 恢復後結束降級模式；降級期間本地切到 OKX 且 LockTime 尚未過期時，
+// 把這個決定寫回 Redis，讓其他 instance 一起沿用。回傳同步後的狀態。
+func (proxy ExchangeApiProxyImpl) reconcileDegraded(ctx context.Context, state *StateStore, snap StateSnapshot) StateSnapshot {
+	if proxy.StateCache == nil {
+		return snap
+	}
+	local := proxy.StateCache.leaveDegraded()
+	if local == nil {
+		return snap
+	}
+	now := time.Now()
+	log.Infof("redis recovered, leave degraded mode after %v", now.Sub(local.since))
+
+	synced := int64(0)
+	if local.connector == ExchangeConnectorTypeOKX && now.Before(local.lockUntil) && snap.Connector != ExchangeConnectorTypeOKX {
+		gen, err := state.Transition(ctx, snap.Generation, ExchangeConnectorTypeOKX, local.lockUntil.Sub(now))
+		if err != nil {
+			// ErrStaleGeneration 表示其他 instance 已在 Redis 上做出新的決定，以 Redis 為準
+			log.Infof("sync degraded switch error: %v", err)
+		} else {
+			codes, methods := summarizeFailures(local.switched)
+			proxy.recordEvent(ctx, FailoverEvent{
+				Type:        FailoverEventSwitch,
+				From:        snap.Connector,
+				To:          ExchangeConnectorTypeOKX,
+				Codes:       codes,
+				Methods:     methods,
+				WindowCount: len(local.switched),
+				Generation:  gen,
+				Reason:      "switched locally while redis was unavailable",
+			})
+			synced = gen
+			snap.Connector = ExchangeConnectorTypeOKX
+			snap.Generation = gen
+			snap.LockExpiresAt = local.lockUntil
+		}
+	}
+	proxy.sendAlert(ctx, AlertTemplateDegradedExit, "Redis", AlertData{
+		To:             connectorOrPrimary(snap.Connector),
+		Generation:     synced,
+		OutageStart:    local.since,
+		OutageDuration: now.Sub(local.since),
+	})
+	return snap
+}
@@ -0,0 +1,91 @@
+package failover
+
+import (
+	"testing"
+	"time"
+)
+
+func newDegradedTest(t *testing.T, lockTTL time.Duration) *failoverTest {
+	t.Helper()
+	cfg := DefaultConfig
+	cfg.ErrThreshold = 3
+	cfg.LockTimeTTL = lockTTL
+	cfg.DegradedProbeInterval = 20 * time.Millisecond
+	return newFailoverTest(t, WithConfig(cfg))
+}
+
+func TestDegradedSwitchReconcilesToRedis(t *testing.T) {
+	ft := newDegradedTest(t, time.Hour)
+	ft.mr.Close()
+
+	ft.binance.fail("-1001")
+	ft.failures(t, nil, 3)
+	if got := ft.alerts(AlertTemplateDegradedEnter); len(got) != 1 {
+		t.Fatalf("degraded enter alerts = %+v", got)
+	}
+	if got := ft.alerts(AlertTemplateDegradedSwitch); len(got) != 1 || got[0].To != ExchangeConnectorTypeOKX {
+		t.Fatalf("degraded switch alerts = %+v", got)
+	}
+
+	// Redis 仍無法連線，使用本地的切換決定
+	if ct, err := ft.ticker(nil); err != nil || ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("ticker = %v, %v, want %v", ct, err, ExchangeConnectorTypeOKX)
+	}
+
+	if err := ft.mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if ct, err := ft.ticker(nil); err != nil || ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("ticker after recovery = %v, %v, want %v", ct, err, ExchangeConnectorTypeOKX)
+	}
+	if ft.proxy.StateCache.isDegraded() {
+		t.Fatal("still degraded after redis recovered")
+	}
+
+	// 本地決定寫回 Redis，LockTime 沿用本地剩餘時間
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("redis connector = %v, want %v", ct, ExchangeConnectorTypeOKX)
+	}
+	if ttl := ft.mr.TTL(ft.proxy.config().RedisKeyLockTime); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("lock ttl = %v", ttl)
+	}
+	events := ft.events(t, FailoverEventSwitch)
+	if len(events) != 1 || events[0].WindowCount != 3 || events[0].Reason == "" {
+		t.Fatalf("switch events = %+v", events)
+	}
+	if got := ft.alerts(AlertTemplateDegradedExit); len(got) != 1 || got[0].Generation != events[0].Generation {
+		t.Fatalf("degraded exit alerts = %+v", got)
+	}
+}
+
+func TestDegradedRecoverLocallyAfterLock(t *testing.T) {
+	ft := newDegradedTest(t, 30*time.Millisecond)
+	ft.mr.Close()
+
+	ft.binance.fail("-1001")
+	ft.failures(t, nil, 3)
+	if got := ft.alerts(AlertTemplateDegradedSwitch); len(got) != 1 {
+		t.Fatalf("degraded switch alerts = %+v", got)
+	}
+
+	// LockTime 未過期前成功的呼叫不切回
+	if ct, err := ft.ticker(nil); err != nil || ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("ticker = %v, %v, want %v", ct, err, ExchangeConnectorTypeOKX)
+	}
+	if got := ft.alerts(AlertTemplateRecovery); len(got) != 0 {
+		t.Fatalf("recovered before lock expired: %+v", got)
+	}
+
+	ft.binance.fail("")
+	time.Sleep(40 * time.Millisecond)
+	if _, err := ft.ticker(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := ft.alerts(AlertTemplateRecovery); len(got) != 1 || got[0].To != ExchangeConnectorTypeBinance {
+		t.Fatalf("recovery alerts = %+v", got)
+	}
+	if ct, err := ft.ticker(nil); err != nil || ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("ticker after local recovery = %v, %v, want %v", ct, err, ExchangeConnectorTypeBinance)
+	}
+}
@@ -0,0 +1,153 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type FailoverEventType string
+
+const (
+	FailoverEventSwitch        FailoverEventType = "switch"
+	FailoverEventRecovery      FailoverEventType = "recovery"
+	FailoverEventManualSwitch  FailoverEventType = "manual_switch"
+	FailoverEventLockCleared   FailoverEventType = "lock_cleared"
+	FailoverEventCountersReset FailoverEventType = "counters_reset"
+	FailoverEventPinned        FailoverEventType = "pinned"
+	FailoverEventUnpinned      FailoverEventType = "unpinned"
+	FailoverEventPinExpired    FailoverEventType = "pin_expired"
+
+	FailoverEventMaintenanceScheduled FailoverEventType = "maintenance_scheduled"
+	FailoverEventMaintenanceCancelled FailoverEventType = "maintenance_cancelled"
+	FailoverEventMaintenanceStart     FailoverEventType = "maintenance_start"
+	FailoverEventMaintenanceEnd       FailoverEventType = "maintenance_end"
+)
+
+func (t FailoverEventType) String() string {
+	return string(t)
+}
+
+type FailoverEvent struct {
+	ID          string                `json:"id,omitempty"`
+	Type        FailoverEventType     `json:"type"`
+	From        ExchangeConnectorType `json:"from"`
+	To          ExchangeConnectorType `json:"to"`
+	Codes       []string              `json:"codes,omitempty"`
+	Methods     []string              `json:"methods,omitempty"`
+	InstanceID  string                `json:"instanceId"`
+	WindowCount int                   `json:"windowCount"`
+	Generation  int64                 `json:"generation,omitempty"`
+	Operator    string                `json:"operator,omitempty"`
+	Reason      string                `json:"reason,omitempty"`
+	Timestamp   time.Time             `json:"timestamp"`
+}
+
+// EventFilter 篩選事件，Since/Until 為零值時不限制，Limit > 0 時只保留最新的 Limit 筆。
+type EventFilter struct {
+	Since time.Time
+	Until time.Time
+	Types []FailoverEventType
+	Limit int64
+}
+
+func (f EventFilter) match(event FailoverEvent) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if event.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// EventJournal 為只增不改的狀態切換紀錄，List 回傳依時間由舊到新排序。
+type EventJournal interface {
+	Append(ctx context.Context, event FailoverEvent) error
+	List(ctx context.Context, filter EventFilter) ([]FailoverEvent, error)
+}
+
+type RedisEventJournal struct {
+	cache  redis.UniversalClient
+	key    string
+	maxLen int64
+}
+
+func NewRedisEventJournal(cache redis.UniversalClient, key string, maxLen int64) *RedisEventJournal {
+	return &RedisEventJournal{
+		cache:  cache,
+		key:    key,
+		maxLen: maxLen,
+	}
+}
+
+func (j *RedisEventJournal) Append(ctx context.Context, event FailoverEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	event.ID = ""
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	args := &redis.XAddArgs{
+		Stream: j.key,
+		Values: map[string]interface{}{"event": string(data)},
+	}
+	if j.maxLen > 0 {
+		args.MaxLen = j.maxLen
+		args.Approx = true
+	}
+	return j.cache.XAdd(ctx, args).Err()
+}
+
+func (j *RedisEventJournal) List(ctx context.Context, filter EventFilter) ([]FailoverEvent, error) {
+	start, end := "-", "+"
+	if !filter.Since.IsZero() {
+		start = fmt.Sprintf("%d", filter.Since.UnixMilli())
+	}
+	if !filter.Until.IsZero() {
+		end = fmt.Sprintf("%d", filter.Until.UnixMilli())
+	}
+
+	var messages []redis.XMessage
+	var err error
+	if filter.Limit > 0 && len(filter.Types) == 0 {
+		messages, err = j.cache.XRevRangeN(ctx, j.key, end, start, filter.Limit).Result()
+		for i, k := 0, len(messages)-1; i < k; i, k = i+1, k-1 {
+			messages[i], messages[k] = messages[k], messages[i]
+		}
+	} else {
+		messages, err = j.cache.XRange(ctx, j.key, start, end).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events := []FailoverEvent{}
+	for _, msg := range messages {
+		raw, ok := msg.Values["event"].(string)
+		if !ok {
+			continue
+		}
+		event := FailoverEvent{}
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			return nil, fmt.Errorf("decode event %v: %w", msg.ID, err)
+		}
+		event.ID = msg.ID
+		if filter.match(event) {
+			events = append(events, event)
+		}
+	}
+
+	if filter.Limit > 0 && int64(len(events)) > filter.Limit {
+		events = events[int64(len(events))-filter.Limit:]
+	}
+	return events, nil
+}
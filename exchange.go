@@ -1,6 +1,7 @@
 package failover
 
 import (
+	"context"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -49,10 +50,22 @@ type ExchangeConnector interface {
 	NewestQuoteTicker(symbol string) (res ExchangeApiResponse, err error)
 	GetSpotPrecision(base string) (res ExchangeApiResponse, err error)
 	SymbolPriceTicker() (res ExchangeApiResponse, err error)
+	// 訂單管理：quantity、price 為空字串時不修改
+	SpotCancelOrder(symbol, orderID string) (res ExchangeApiResponse, err error)
+	SpotCancelAllOrders(symbol string) (res ExchangeApiResponse, err error)
+	SpotQueryOrder(symbol, orderID string) (res ExchangeApiResponse, err error)
+	SpotOpenOrders(symbol string) (res ExchangeApiResponse, err error)
+	SpotAmendOrder(symbol, orderID, quantity, price string) (res ExchangeApiResponse, err error)
+	FuturesCancelOrder(symbol, orderID string) (res ExchangeApiResponse, err error)
+	FuturesCancelAllOrders(symbol string) (res ExchangeApiResponse, err error)
+	FuturesQueryOrder(symbol, orderID string) (res ExchangeApiResponse, err error)
+	FuturesOpenOrders(symbol string) (res ExchangeApiResponse, err error)
+	FuturesAmendOrder(symbol, orderID, quantity, price string) (res ExchangeApiResponse, err error)
 }
 
 type ExchangeApiProxy interface {
 	Invoke(fn func(ct ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), con *ExchangeConnectorType, needStandbyConnector bool) (ExchangeApiResponse, error)
+	InvokeContext(ctx context.Context, fn func(ct ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), con *ExchangeConnectorType, needStandbyConnector bool) (ExchangeApiResponse, error)
 	NowConnect() string
 }
 
@@ -81,6 +94,67 @@ type ExchangeApi interface {
 	NewestQuoteTicker(symbol string) (price decimal.Decimal, err error)
 	GetSpotPrecision(base string) (pricePrecision int32, quantityPrecision int32, quoteQuantityPrecision int32, err error)
 	SymbolPriceTicker() (price []map[string]interface{}, err error)
+	// 訂單管理的 connector 為下單的交易所，空字串時使用 proxy 記錄的下單交易所；
+	// OpenOrders 與 CancelAllOrders 未指定 connector 時查詢所有交易所
+	SpotCancelOrder(symbol, orderID string, connector ExchangeConnectorType) (output map[string]interface{}, err error)
+	SpotCancelAllOrders(symbol string, connector ExchangeConnectorType) (output []map[string]interface{}, err error)
+	SpotQueryOrder(symbol, orderID string, connector ExchangeConnectorType) (output map[string]interface{}, err error)
+	SpotOpenOrders(symbol string, connector ExchangeConnectorType) (output []map[string]interface{}, err error)
+	SpotAmendOrder(symbol, orderID, quantity, price string, connector ExchangeConnectorType) (output map[string]interface{}, err error)
+	FuturesCancelOrder(symbol, orderID string, connector ExchangeConnectorType) (output map[string]interface{}, err error)
+	FuturesCancelAllOrders(symbol string, connector ExchangeConnectorType) (output []map[string]interface{}, err error)
+	FuturesQueryOrder(symbol, orderID string, connector ExchangeConnectorType) (output map[string]interface{}, err error)
+	FuturesOpenOrders(symbol string, connector ExchangeConnectorType) (output []map[string]interface{}, err error)
+	FuturesAmendOrder(symbol, orderID, quantity, price string, connector ExchangeConnectorType) (output map[string]interface{}, err error)
+}
+
+// ContextExchangeApi 為可綁定呼叫端 ctx 的 ExchangeApi。WithContext 不放進 ExchangeApi，
+// 既有的 ExchangeApi 實作不需要新增方法。
+type ContextExchangeApi interface {
+	ExchangeApi
+	WithContext(ctx context.Context) ExchangeApi
+}
+
+// ExchangeApiV2 與 ExchangeApi 對應，但回傳與交易所無關的型別，每個方法都會回傳錯誤。
+type ExchangeApiV2 interface {
+	WithContext(ctx context.Context) ExchangeApiV2
+	NowConnect() string
+	Klines(symbol string, interval string, limit uint64) (klines []Kline, err error)
+	ClosingTimeRemaining(interval string) (remaining time.Duration, err error)
+	GetPriceHistoryIntervalLimit(intervalLetter string) (interval string, limit uint64, err error)
+	// FutureTrade 與 SpotTrade 的 price 為零時以市價下單
+	FutureTrade(symbol, side string, quantity, price decimal.Decimal) (order OrderResult, err error)
+	GetUSDTMFuturesPrecision(base string) (pricePrecision, quantityPrecision int32, err error)
+	SpotTrade(symbol, side string, quantity, price decimal.Decimal) (order OrderResult, err error)
+	FuturesExchangeInfo(symbol string) (info SymbolInfo, err error)
+	GetFuturesBills(startTime int64) (bills []Bill, err error)
+	FuturesTransfer(symbol string, amount decimal.Decimal, transferType string, connector ExchangeConnectorType) (tranID string, err error)
+	FuturesAccount() (account FuturesAccount, err error)
+	FuturesAccountPositionRisk(symbol string) (positions []Position, err error)
+	SpotAllOrders(symbol string, limit int64) (orders []OrderResult, err error)
+	SpotAccountTradeList(symbol string, limit int64) (trades []Trade, err error)
+	PerpAccountTradeList(symbol string, limit int64) (trades []Trade, err error)
+	GetCommission(symbols string) (commissions []Commission, err error)
+	SpotAccountInternalTransferRecord(startTime, endTime int64) (transfers []Transfer, err error)
+	SpotWithdraw(symbol string, amount decimal.Decimal, to, network string) (id string, err error)
+	SpotWithdrawRecord(startTime, endTime int64) (withdrawals []Withdrawal, err error)
+	CapitalCoinGetAll() (coins []CoinConfig, err error)
+	SpotAssets(symbol string) (balances []Balance, err error)
+	NewestQuoteTicker(symbol string) (price decimal.Decimal, err error)
+	GetSpotPrecision(base string) (pricePrecision int32, quantityPrecision int32, quoteQuantityPrecision int32, err error)
+	SymbolPriceTicker() (tickers []Ticker, err error)
+	// 訂單管理的 connector 規則與 ExchangeApi 相同，通常傳入 OrderResult.ConnectorType；
+	// AmendOrder 的 quantity、price 為零時不修改
+	SpotCancelOrder(symbol, orderID string, connector ExchangeConnectorType) (order OrderResult, err error)
+	SpotCancelAllOrders(symbol string, connector ExchangeConnectorType) (orders []OrderResult, err error)
+	SpotQueryOrder(symbol, orderID string, connector ExchangeConnectorType) (order OrderResult, err error)
+	SpotOpenOrders(symbol string, connector ExchangeConnectorType) (orders []OrderResult, err error)
+	SpotAmendOrder(symbol, orderID string, quantity, price decimal.Decimal, connector ExchangeConnectorType) (order OrderResult, err error)
+	FuturesCancelOrder(symbol, orderID string, connector ExchangeConnectorType) (order OrderResult, err error)
+	FuturesCancelAllOrders(symbol string, connector ExchangeConnectorType) (orders []OrderResult, err error)
+	FuturesQueryOrder(symbol, orderID string, connector ExchangeConnectorType) (order OrderResult, err error)
+	FuturesOpenOrders(symbol string, connector ExchangeConnectorType) (orders []OrderResult, err error)
+	FuturesAmendOrder(symbol, orderID string, quantity, price decimal.Decimal, connector ExchangeConnectorType) (order OrderResult, err error)
 }
 
 type IAlertService interface {
@@ -1,7 +1,10 @@
 package failover
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -9,6 +12,45 @@ import (
 
 type ExchangeApiAdapter struct {
 	ApiProxy ExchangeApiProxy
+	// Instruments 把呼叫端傳入的交易對轉成處理請求的交易所的原生名稱，未設定時使用 DefaultInstrumentRegistry
+	Instruments *InstrumentRegistry
+	// Validator 在 SpotTrade、FutureTrade 與 AmendOrder 送出前依目標交易所的限制檢查訂單，nil 表示不檢查
+	Validator *OrderValidator
+	// InfoCache 保存精度、exchange info 與幣種設定，nil 表示每次都查詢交易所
+	InfoCache *ExchangeInfoCache
+	// KlineOffset 為 ClosingTimeRemaining 切分 K 線使用的時區與 UTC 的差，0 與 Klines 取得的 UTC K 線一致
+	KlineOffset time.Duration
+	ctx         context.Context
+}
+
+// WithContext 回傳綁定 ctx 的 adapter，讓交易所呼叫能掛在呼叫端的 trace 之下。
+func (e ExchangeApiAdapter) WithContext(ctx context.Context) ExchangeApi {
+	e.ctx = ctx
+	return e
+}
+
+func (e ExchangeApiAdapter) context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// methodContext 帶上方法名稱，proxy 以此命名 span 與記錄錯誤，不需由 closure 名稱推算。
+func (e ExchangeApiAdapter) methodContext(method string) context.Context {
+	return WithInvokeMethod(e.context(), method)
+}
+
+// symbol 把 Binance 格式或標準 ID 的交易對轉成 ct 的原生名稱，Binance 格式的市場由 market 決定。
+func (e ExchangeApiAdapter) symbol(ct ExchangeConnectorType, symbol string, market MarketType) string {
+	return e.instruments().Symbol(ct, symbol, market)
+}
+
+func (e ExchangeApiAdapter) instruments() *InstrumentRegistry {
+	if e.Instruments == nil {
+		return DefaultInstrumentRegistry
+	}
+	return e.Instruments
 }
 
 func (e ExchangeApiAdapter) NowConnect() string {
@@ -16,63 +58,53 @@ func (e ExchangeApiAdapter) NowConnect() string {
 }
 
 func (e ExchangeApiAdapter) Klines(symbol string, interval string, limit uint64) (klines []map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.Klines(symbol, interval, limit)
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("Klines"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.Klines(e.symbol(cType, symbol, MarketTypeSpot), nativeInterval(cType, interval), limit)
 	}, nil, false)
 	if err != nil {
 		return nil, err
 	}
 
-	klines = []map[string]interface{}{}
-	err = json.Unmarshal(apiResponse.Body, &klines)
+	// Binance 回傳陣列格式的 K 線，統一轉成物件
+	result, err := decodeList(apiResponse.Body, toKline)
 	if err != nil {
 		return nil, err
 	}
 
-	return klines, nil
+	return normalizedKlines(result), nil
 }
 
+// ClosingTimeRemaining 在本地以 KlineOffset 切分的 K 線計算距離收盤的時間，interval 無法解析時回傳 0；需要錯誤時請使用 ExchangeApiV2。
 func (e ExchangeApiAdapter) ClosingTimeRemaining(interval string) time.Duration {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.ClosingTimeRemaining(interval)
-	}, nil, false)
-
-	remainingTime := time.Duration(0)
-	err = json.Unmarshal(apiResponse.Body, &remainingTime)
+	i, err := ParseInterval(interval)
 	if err != nil {
-		return remainingTime
+		return 0
 	}
 
-	return remainingTime
+	return i.Remaining(time.Now(), e.KlineOffset)
 }
 
 func (e ExchangeApiAdapter) GetPriceHistoryIntervalLimit(intervalLetter string) (interval string, limit uint64) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.GetPriceHistoryIntervalLimit(intervalLetter)
-	}, nil, false)
-	if err != nil {
-		return "", 0
-	}
-
-	resp := struct {
-		Interval string `json:"interval"`
-		Limit    uint64 `json:"limit"`
-	}{}
-	err = json.Unmarshal(apiResponse.Body, &resp)
+	i, limit, err := PriceHistoryIntervalLimit(ExchangeConnectorType(e.ApiProxy.NowConnect()), intervalLetter)
 	if err != nil {
 		return "", 0
 	}
 
-	return resp.Interval, resp.Limit
+	return i.String(), limit
 }
 
 func (e ExchangeApiAdapter) FutureTrade(symbol, side, quantity, price string) (output map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.FutureTrade(symbol, side, quantity, price)
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("FutureTrade"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		quantity, price, err := e.Validator.validateOrderStrings(e.context(), e.instruments(), cType, connector, symbol, MarketTypeUSDTPerp, side, quantity, price)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		return connector.FutureTrade(e.symbol(cType, symbol, MarketTypeUSDTPerp), side, quantity, price)
 	}, nil, true)
 	if err != nil {
 		return nil, err
 	}
+	recordOrder(e.context(), e.ApiProxy, MarketTypeUSDTPerp, apiResponse)
 
 	result := map[string]interface{}{}
 	err = json.Unmarshal(apiResponse.Body, &result)
@@ -84,9 +116,9 @@ func (e ExchangeApiAdapter) FutureTrade(symbol, side, quantity, price string) (o
 }
 
 func (e ExchangeApiAdapter) GetUSDTMFuturesPrecision(base string) (pricePrecision, quantityPrecision int32, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.InfoCache.invoke(e.methodContext("GetUSDTMFuturesPrecision"), e.ApiProxy, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.GetUSDTMFuturesPrecision(base)
-	}, nil, false)
+	}, nil, base)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -104,12 +136,17 @@ func (e ExchangeApiAdapter) GetUSDTMFuturesPrecision(base string) (pricePrecisio
 }
 
 func (e ExchangeApiAdapter) SpotTrade(symbol, side, quantity, price string) (output map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.SpotTrade(symbol, side, quantity, price)
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("SpotTrade"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		quantity, price, err := e.Validator.validateOrderStrings(e.context(), e.instruments(), cType, connector, symbol, MarketTypeSpot, side, quantity, price)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		return connector.SpotTrade(e.symbol(cType, symbol, MarketTypeSpot), side, quantity, price)
 	}, nil, false)
 	if err != nil {
 		return nil, err
 	}
+	recordOrder(e.context(), e.ApiProxy, MarketTypeSpot, apiResponse)
 
 	result := map[string]interface{}{}
 	err = json.Unmarshal(apiResponse.Body, &result)
@@ -121,9 +158,9 @@ func (e ExchangeApiAdapter) SpotTrade(symbol, side, quantity, price string) (out
 }
 
 func (e ExchangeApiAdapter) FuturesExchangeInfo(symbol string) (resp map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.FuturesExchangeInfo(symbol)
-	}, nil, false)
+	apiResponse, err := e.InfoCache.invoke(e.methodContext("FuturesExchangeInfo"), e.ApiProxy, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesExchangeInfo(e.symbol(cType, symbol, MarketTypeUSDTPerp))
+	}, nil, symbol)
 	if err != nil {
 		return nil, err
 	}
@@ -138,7 +175,7 @@ func (e ExchangeApiAdapter) FuturesExchangeInfo(symbol string) (resp map[string]
 }
 
 func (e ExchangeApiAdapter) GetFuturesBills(startTime int64) (resp []map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("GetFuturesBills"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.GetFuturesBills(startTime)
 	}, nil, false)
 	if err != nil {
@@ -155,7 +192,7 @@ func (e ExchangeApiAdapter) GetFuturesBills(startTime int64) (resp []map[string]
 }
 
 func (e ExchangeApiAdapter) FuturesTransfer(symbol, amount, transferType string, connector ExchangeConnectorType) (err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("FuturesTransfer"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.FuturesTransfer(symbol, amount, transferType)
 	}, &connector, false)
 	if err != nil {
@@ -171,7 +208,7 @@ func (e ExchangeApiAdapter) FuturesTransfer(symbol, amount, transferType string,
 }
 
 func (e ExchangeApiAdapter) FuturesAccount() (account map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("FuturesAccount"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.FuturesAccount()
 	}, nil, false)
 	if err != nil {
@@ -188,8 +225,8 @@ func (e ExchangeApiAdapter) FuturesAccount() (account map[string]interface{}, er
 }
 
 func (e ExchangeApiAdapter) FuturesAccountPositionRisk(symbol string) (risk []map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.FuturesAccountPositionRisk(symbol)
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("FuturesAccountPositionRisk"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesAccountPositionRisk(e.symbol(cType, symbol, MarketTypeUSDTPerp))
 	}, nil, false)
 	if err != nil {
 		return []map[string]interface{}{}, err
@@ -207,8 +244,8 @@ func (e ExchangeApiAdapter) FuturesAccountPositionRisk(symbol string) (risk []ma
 func (e ExchangeApiAdapter) SpotAllOrders(symbol string, limit int64) (output []map[string]interface{}, err error) {
 	binanceCon := ExchangeConnectorTypeBinance
 
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.SpotAllOrders(symbol, limit)
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("SpotAllOrders"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotAllOrders(e.symbol(cType, symbol, MarketTypeSpot), limit)
 	}, &binanceCon, false)
 	if err != nil {
 		return []map[string]interface{}{}, err
@@ -226,8 +263,8 @@ func (e ExchangeApiAdapter) SpotAllOrders(symbol string, limit int64) (output []
 func (e ExchangeApiAdapter) SpotAccountTradeList(symbol string, limit int64) (output []map[string]interface{}, err error) {
 	binanceCon := ExchangeConnectorTypeBinance
 
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.SpotAccountTradeList(symbol, limit)
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("SpotAccountTradeList"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotAccountTradeList(e.symbol(cType, symbol, MarketTypeSpot), limit)
 	}, &binanceCon, false)
 	if err != nil {
 		return []map[string]interface{}{}, err
@@ -243,7 +280,7 @@ func (e ExchangeApiAdapter) SpotAccountTradeList(symbol string, limit int64) (ou
 }
 
 func (e ExchangeApiAdapter) GetCommission(symbols string) (output []map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("GetCommission"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.GetCommission(symbols)
 	}, nil, false)
 	if err != nil {
@@ -262,8 +299,8 @@ func (e ExchangeApiAdapter) GetCommission(symbols string) (output []map[string]i
 func (e ExchangeApiAdapter) PerpAccountTradeList(symbol string, limit int64) (output []map[string]interface{}, err error) {
 	binanceCon := ExchangeConnectorTypeBinance
 
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.PerpAccountTradeList(symbol, limit)
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("PerpAccountTradeList"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.PerpAccountTradeList(e.symbol(cType, symbol, MarketTypeUSDTPerp), limit)
 	}, &binanceCon, false)
 	if err != nil {
 		return []map[string]interface{}{}, err
@@ -281,7 +318,7 @@ func (e ExchangeApiAdapter) PerpAccountTradeList(symbol string, limit int64) (ou
 func (e ExchangeApiAdapter) SpotAccountInternalTransferRecord(startTime, endTime int64) (output []map[string]interface{}, err error) {
 	binanceCon := ExchangeConnectorTypeBinance
 
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("SpotAccountInternalTransferRecord"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.SpotAccountInternalTransferRecord(startTime, endTime)
 	}, &binanceCon, false)
 	if err != nil {
@@ -300,7 +337,7 @@ func (e ExchangeApiAdapter) SpotAccountInternalTransferRecord(startTime, endTime
 func (e ExchangeApiAdapter) SpotWithdraw(symbol, amount, to, network string) (id string, err error) {
 	binanceCon := ExchangeConnectorTypeBinance
 
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("SpotWithdraw"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.SpotWithdraw(symbol, amount, to, network)
 	}, &binanceCon, false)
 	if err != nil {
@@ -321,7 +358,7 @@ func (e ExchangeApiAdapter) SpotWithdraw(symbol, amount, to, network string) (id
 func (e ExchangeApiAdapter) SpotWithdrawRecord(startTime, endTime int64) (output []map[string]interface{}, err error) {
 	binanceCon := ExchangeConnectorTypeBinance
 
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("SpotWithdrawRecord"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.SpotWithdrawRecord(startTime, endTime)
 	}, &binanceCon, false)
 	if err != nil {
@@ -340,9 +377,9 @@ func (e ExchangeApiAdapter) SpotWithdrawRecord(startTime, endTime int64) (output
 func (e ExchangeApiAdapter) CapitalCoinGetAll() (coinConfigs []map[string]interface{}, err error) {
 	binanceCon := ExchangeConnectorTypeBinance
 
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.InfoCache.invoke(e.methodContext("CapitalCoinGetAll"), e.ApiProxy, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.CapitalCoinGetAll()
-	}, &binanceCon, false)
+	}, &binanceCon, "")
 	if err != nil {
 		return []map[string]interface{}{}, err
 	}
@@ -357,7 +394,7 @@ func (e ExchangeApiAdapter) CapitalCoinGetAll() (coinConfigs []map[string]interf
 }
 
 func (e ExchangeApiAdapter) SpotAssets(symbol string) (spotAssets []map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("SpotAssets"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.SpotAssets(symbol)
 	}, nil, false)
 	if err != nil {
@@ -374,8 +411,8 @@ func (e ExchangeApiAdapter) SpotAssets(symbol string) (spotAssets []map[string]i
 }
 
 func (e ExchangeApiAdapter) NewestQuoteTicker(symbol string) (price decimal.Decimal, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
-		return connector.NewestQuoteTicker(symbol)
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("NewestQuoteTicker"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.NewestQuoteTicker(e.symbol(cType, symbol, MarketTypeSpot))
 	}, nil, false)
 	if err != nil {
 		return decimal.Zero, err
@@ -398,9 +435,9 @@ func (e ExchangeApiAdapter) NewestQuoteTicker(symbol string) (price decimal.Deci
 }
 
 func (e ExchangeApiAdapter) GetSpotPrecision(base string) (pricePrecision int32, quantityPrecision int32, quoteQuantityPrecision int32, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.InfoCache.invoke(e.methodContext("GetSpotPrecision"), e.ApiProxy, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.GetSpotPrecision(base)
-	}, nil, false)
+	}, nil, base)
 	if err != nil {
 		return 0, 0, 0, err
 	}
@@ -419,7 +456,7 @@ func (e ExchangeApiAdapter) GetSpotPrecision(base string) (pricePrecision int32,
 }
 
 func (e ExchangeApiAdapter) SymbolPriceTicker() (price []map[string]interface{}, err error) {
-	apiResponse, err := e.ApiProxy.Invoke(func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext("SymbolPriceTicker"), func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
 		return connector.SymbolPriceTicker()
 	}, nil, false)
 	if err != nil {
@@ -434,3 +471,114 @@ func (e ExchangeApiAdapter) SymbolPriceTicker() (price []map[string]interface{},
 
 	return result, nil
 }
+
+func (e ExchangeApiAdapter) SpotCancelOrder(symbol, orderID string, connector ExchangeConnectorType) (output map[string]interface{}, err error) {
+	return e.invokeOrder("SpotCancelOrder", MarketTypeSpot, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotCancelOrder(e.symbol(cType, symbol, MarketTypeSpot), orderID)
+	})
+}
+
+func (e ExchangeApiAdapter) SpotCancelAllOrders(symbol string, connector ExchangeConnectorType) (output []map[string]interface{}, err error) {
+	return e.invokeOrders("SpotCancelAllOrders", connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotCancelAllOrders(e.symbol(cType, symbol, MarketTypeSpot))
+	})
+}
+
+func (e ExchangeApiAdapter) SpotQueryOrder(symbol, orderID string, connector ExchangeConnectorType) (output map[string]interface{}, err error) {
+	return e.invokeOrder("SpotQueryOrder", MarketTypeSpot, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotQueryOrder(e.symbol(cType, symbol, MarketTypeSpot), orderID)
+	})
+}
+
+func (e ExchangeApiAdapter) SpotOpenOrders(symbol string, connector ExchangeConnectorType) (output []map[string]interface{}, err error) {
+	return e.invokeOrders("SpotOpenOrders", connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotOpenOrders(e.symbol(cType, symbol, MarketTypeSpot))
+	})
+}
+
+func (e ExchangeApiAdapter) SpotAmendOrder(symbol, orderID, quantity, price string, connector ExchangeConnectorType) (output map[string]interface{}, err error) {
+	return e.invokeOrder("SpotAmendOrder", MarketTypeSpot, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		quantity, price, err := e.Validator.validateAmendStrings(e.context(), e.instruments(), cType, connector, symbol, MarketTypeSpot, quantity, price)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		return connector.SpotAmendOrder(e.symbol(cType, symbol, MarketTypeSpot), orderID, quantity, price)
+	})
+}
+
+func (e ExchangeApiAdapter) FuturesCancelOrder(symbol, orderID string, connector ExchangeConnectorType) (output map[string]interface{}, err error) {
+	return e.invokeOrder("FuturesCancelOrder", MarketTypeUSDTPerp, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesCancelOrder(e.symbol(cType, symbol, MarketTypeUSDTPerp), orderID)
+	})
+}
+
+func (e ExchangeApiAdapter) FuturesCancelAllOrders(symbol string, connector ExchangeConnectorType) (output []map[string]interface{}, err error) {
+	return e.invokeOrders("FuturesCancelAllOrders", connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesCancelAllOrders(e.symbol(cType, symbol, MarketTypeUSDTPerp))
+	})
+}
+
+func (e ExchangeApiAdapter) FuturesQueryOrder(symbol, orderID string, connector ExchangeConnectorType) (output map[string]interface{}, err error) {
+	return e.invokeOrder("FuturesQueryOrder", MarketTypeUSDTPerp, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesQueryOrder(e.symbol(cType, symbol, MarketTypeUSDTPerp), orderID)
+	})
+}
+
+func (e ExchangeApiAdapter) FuturesOpenOrders(symbol string, connector ExchangeConnectorType) (output []map[string]interface{}, err error) {
+	return e.invokeOrders("FuturesOpenOrders", connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesOpenOrders(e.symbol(cType, symbol, MarketTypeUSDTPerp))
+	})
+}
+
+func (e ExchangeApiAdapter) FuturesAmendOrder(symbol, orderID, quantity, price string, connector ExchangeConnectorType) (output map[string]interface{}, err error) {
+	return e.invokeOrder("FuturesAmendOrder", MarketTypeUSDTPerp, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		quantity, price, err := e.Validator.validateAmendStrings(e.context(), e.instruments(), cType, connector, symbol, MarketTypeUSDTPerp, quantity, price)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		return connector.FuturesAmendOrder(e.symbol(cType, symbol, MarketTypeUSDTPerp), orderID, quantity, price)
+	})
+}
+
+// invokeOrder 把 orderID 的請求送到下單的交易所，並解析回傳的單筆訂單。
+func (e ExchangeApiAdapter) invokeOrder(method string, market MarketType, orderID string, connector ExchangeConnectorType, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error)) (output map[string]interface{}, err error) {
+	con, err := orderConnector(e.context(), e.ApiProxy, market, orderID, connector)
+	if err != nil {
+		return nil, err
+	}
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext(method), fn, con, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	err = json.Unmarshal(apiResponse.Body, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// invokeOrders 對 orderConnectors 決定的交易所呼叫 fn，並合併回傳的訂單清單。
+func (e ExchangeApiAdapter) invokeOrders(method string, connector ExchangeConnectorType, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error)) (output []map[string]interface{}, err error) {
+	responses, err := invokeEach(e.methodContext(method), e.ApiProxy, fn, orderConnectors(e.ApiProxy, connector))
+
+	return decodeOrderLists(responses, err)
+}
+
+// decodeOrderLists 合併各交易所回傳的訂單清單；err 為 invokeEach 的錯誤，成功的交易所結果仍會回傳。
+func decodeOrderLists(responses []ExchangeApiResponse, err error) (output []map[string]interface{}, _ error) {
+	output = []map[string]interface{}{}
+	errs := []error{err}
+	for _, apiResponse := range responses {
+		result := []map[string]interface{}{}
+		if decodeErr := json.Unmarshal(apiResponse.Body, &result); decodeErr != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", apiResponse.ConnectorType, decodeErr))
+			continue
+		}
+		output = append(output, result...)
+	}
+
+	return output, errors.Join(errs...)
+}
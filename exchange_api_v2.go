@@ -0,0 +1,468 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeApiV2Adapter 以與 ExchangeApiAdapter 相同的路由呼叫 proxy，再把回應轉成 models.go 的型別。
+type ExchangeApiV2Adapter struct {
+	ApiProxy ExchangeApiProxy
+	// Instruments 把呼叫端傳入的交易對轉成處理請求的交易所的原生名稱，未設定時使用 DefaultInstrumentRegistry
+	Instruments *InstrumentRegistry
+	// Validator 在 SpotTrade、FutureTrade 與 AmendOrder 送出前依目標交易所的限制檢查訂單，nil 表示不檢查
+	Validator *OrderValidator
+	// InfoCache 保存精度、exchange info 與幣種設定，nil 表示每次都查詢交易所
+	InfoCache *ExchangeInfoCache
+	// KlineOffset 為 ClosingTimeRemaining 切分 K 線使用的時區與 UTC 的差，0 與 Klines 取得的 UTC K 線一致
+	KlineOffset time.Duration
+	ctx         context.Context
+}
+
+func (e ExchangeApiV2Adapter) WithContext(ctx context.Context) ExchangeApiV2 {
+	e.ctx = ctx
+	return e
+}
+
+func (e ExchangeApiV2Adapter) context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+func (e ExchangeApiV2Adapter) methodContext(method string) context.Context {
+	return WithInvokeMethod(e.context(), method)
+}
+
+// symbol 把 Binance 格式或標準 ID 的交易對轉成 ct 的原生名稱，Binance 格式的市場由 market 決定。
+func (e ExchangeApiV2Adapter) symbol(ct ExchangeConnectorType, symbol string, market MarketType) string {
+	return e.instruments().Symbol(ct, symbol, market)
+}
+
+func (e ExchangeApiV2Adapter) instruments() *InstrumentRegistry {
+	if e.Instruments == nil {
+		return DefaultInstrumentRegistry
+	}
+	return e.Instruments
+}
+
+func (e ExchangeApiV2Adapter) NowConnect() string {
+	return e.ApiProxy.NowConnect()
+}
+
+// invokeTyped 呼叫 proxy 後以 decode 解析回應；解析錯誤會帶上方法名稱與實際處理的交易所。
+// method 以 WithInvokeMethod 傳給 proxy，用於能力判斷與 tracing。
+func invokeTyped[T any](e ExchangeApiV2Adapter, method string, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), con *ExchangeConnectorType, needStandbyConnector bool, decode func(body []byte) (T, error)) (T, ExchangeConnectorType, error) {
+	apiResponse, err := e.ApiProxy.InvokeContext(e.methodContext(method), fn, con, needStandbyConnector)
+	return decodeTyped(method, apiResponse, err, decode)
+}
+
+// invokeCached 與 invokeTyped 相同，但回應經由 InfoCache 取得，arg 為 fn 的查詢參數。
+func invokeCached[T any](e ExchangeApiV2Adapter, method string, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), con *ExchangeConnectorType, arg string, decode func(body []byte) (T, error)) (T, ExchangeConnectorType, error) {
+	apiResponse, err := e.InfoCache.invoke(e.methodContext(method), e.ApiProxy, fn, con, arg)
+	return decodeTyped(method, apiResponse, err, decode)
+}
+
+func decodeTyped[T any](method string, apiResponse ExchangeApiResponse, err error, decode func(body []byte) (T, error)) (T, ExchangeConnectorType, error) {
+	var zero T
+	if err != nil {
+		return zero, apiResponse.ConnectorType, err
+	}
+	result, err := decode(apiResponse.Body)
+	if err != nil {
+		return zero, apiResponse.ConnectorType, fmt.Errorf("decode %v response from %v: %w", method, apiResponse.ConnectorType, err)
+	}
+	return result, apiResponse.ConnectorType, nil
+}
+
+// orderPrice 把零值轉成空字串：下單的價格為空時由 connector 以市價下單，改單的數量或價格為空時表示不修改。
+func orderPrice(price decimal.Decimal) string {
+	if price.IsZero() {
+		return ""
+	}
+	return price.String()
+}
+
+func (e ExchangeApiV2Adapter) Klines(symbol string, interval string, limit uint64) (klines []Kline, err error) {
+	klines, _, err = invokeTyped(e, "Klines", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.Klines(e.symbol(cType, symbol, MarketTypeSpot), nativeInterval(cType, interval), limit)
+	}, nil, false, func(body []byte) ([]Kline, error) {
+		return decodeList(body, toKline)
+	})
+	return klines, err
+}
+
+// ClosingTimeRemaining 在本地以 KlineOffset 切分的 K 線計算距離收盤的時間。
+func (e ExchangeApiV2Adapter) ClosingTimeRemaining(interval string) (remaining time.Duration, err error) {
+	i, err := ParseInterval(interval)
+	if err != nil {
+		return 0, err
+	}
+	return i.Remaining(time.Now(), e.KlineOffset), nil
+}
+
+func (e ExchangeApiV2Adapter) GetPriceHistoryIntervalLimit(intervalLetter string) (interval string, limit uint64, err error) {
+	i, limit, err := PriceHistoryIntervalLimit(ExchangeConnectorType(e.ApiProxy.NowConnect()), intervalLetter)
+	if err != nil {
+		return "", 0, err
+	}
+	return i.String(), limit, nil
+}
+
+func (e ExchangeApiV2Adapter) FutureTrade(symbol, side string, quantity, price decimal.Decimal) (order OrderResult, err error) {
+	order, connectorType, err := invokeTyped(e, "FutureTrade", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		quantity, price, err := e.Validator.Validate(e.context(), e.instruments(), cType, connector, symbol, MarketTypeUSDTPerp, side, quantity, price)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		return connector.FutureTrade(e.symbol(cType, symbol, MarketTypeUSDTPerp), side, quantity.String(), orderPrice(price))
+	}, nil, true, func(body []byte) (OrderResult, error) {
+		return decodeObject(body, toOrderResult)
+	})
+	if err != nil {
+		return OrderResult{}, err
+	}
+	order.ConnectorType = connectorType
+	recordOrderResult(e.context(), e.ApiProxy, MarketTypeUSDTPerp, order)
+	return order, nil
+}
+
+func (e ExchangeApiV2Adapter) GetUSDTMFuturesPrecision(base string) (pricePrecision, quantityPrecision int32, err error) {
+	type precision struct {
+		PricePrecision    int32 `json:"pricePrecision"`
+		QuantityPrecision int32 `json:"quantityPrecision"`
+	}
+	result, _, err := invokeCached(e, "GetUSDTMFuturesPrecision", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.GetUSDTMFuturesPrecision(base)
+	}, nil, base, func(body []byte) (precision, error) {
+		r := precision{}
+		err := json.Unmarshal(body, &r)
+		return r, err
+	})
+	return result.PricePrecision, result.QuantityPrecision, err
+}
+
+func (e ExchangeApiV2Adapter) SpotTrade(symbol, side string, quantity, price decimal.Decimal) (order OrderResult, err error) {
+	order, connectorType, err := invokeTyped(e, "SpotTrade", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		quantity, price, err := e.Validator.Validate(e.context(), e.instruments(), cType, connector, symbol, MarketTypeSpot, side, quantity, price)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		return connector.SpotTrade(e.symbol(cType, symbol, MarketTypeSpot), side, quantity.String(), orderPrice(price))
+	}, nil, false, func(body []byte) (OrderResult, error) {
+		return decodeObject(body, toOrderResult)
+	})
+	if err != nil {
+		return OrderResult{}, err
+	}
+	order.ConnectorType = connectorType
+	recordOrderResult(e.context(), e.ApiProxy, MarketTypeSpot, order)
+	return order, nil
+}
+
+// FuturesExchangeInfo 接受單一交易對的物件，或含 symbols 清單的完整 exchange info。
+func (e ExchangeApiV2Adapter) FuturesExchangeInfo(symbol string) (info SymbolInfo, err error) {
+	info, _, err = invokeCached(e, "FuturesExchangeInfo", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesExchangeInfo(e.symbol(cType, symbol, MarketTypeUSDTPerp))
+	}, nil, symbol, func(body []byte) (SymbolInfo, error) {
+		return findSymbolInfo(body, e.symbol(ExchangeConnectorTypeBinance, symbol, MarketTypeUSDTPerp))
+	})
+	return info, err
+}
+
+func (e ExchangeApiV2Adapter) GetFuturesBills(startTime int64) (bills []Bill, err error) {
+	bills, _, err = invokeTyped(e, "GetFuturesBills", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.GetFuturesBills(startTime)
+	}, nil, false, func(body []byte) ([]Bill, error) {
+		return decodeList(body, toBill)
+	})
+	return bills, err
+}
+
+func (e ExchangeApiV2Adapter) FuturesTransfer(symbol string, amount decimal.Decimal, transferType string, connector ExchangeConnectorType) (tranID string, err error) {
+	tranID, _, err = invokeTyped(e, "FuturesTransfer", func(cType ExchangeConnectorType, c ExchangeConnector) (ExchangeApiResponse, error) {
+		return c.FuturesTransfer(symbol, amount.String(), transferType)
+	}, &connector, false, func(body []byte) (string, error) {
+		return decodeObject(body, func(v interface{}) (string, error) {
+			r := newPayloadReader(v)
+			return r.str("tranId"), r.err
+		})
+	})
+	return tranID, err
+}
+
+func (e ExchangeApiV2Adapter) FuturesAccount() (account FuturesAccount, err error) {
+	account, connectorType, err := invokeTyped(e, "FuturesAccount", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesAccount()
+	}, nil, false, func(body []byte) (FuturesAccount, error) {
+		return decodeObject(body, toFuturesAccount)
+	})
+	if err != nil {
+		return FuturesAccount{}, err
+	}
+	account.ConnectorType = connectorType
+	return account, nil
+}
+
+func (e ExchangeApiV2Adapter) FuturesAccountPositionRisk(symbol string) (positions []Position, err error) {
+	positions, _, err = invokeTyped(e, "FuturesAccountPositionRisk", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesAccountPositionRisk(e.symbol(cType, symbol, MarketTypeUSDTPerp))
+	}, nil, false, func(body []byte) ([]Position, error) {
+		return decodeList(body, toPosition)
+	})
+	return positions, err
+}
+
+func (e ExchangeApiV2Adapter) SpotAllOrders(symbol string, limit int64) (orders []OrderResult, err error) {
+	binanceCon := ExchangeConnectorTypeBinance
+
+	orders, connectorType, err := invokeTyped(e, "SpotAllOrders", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotAllOrders(e.symbol(cType, symbol, MarketTypeSpot), limit)
+	}, &binanceCon, false, func(body []byte) ([]OrderResult, error) {
+		return decodeList(body, toOrderResult)
+	})
+	for i := range orders {
+		orders[i].ConnectorType = connectorType
+	}
+	return orders, err
+}
+
+func (e ExchangeApiV2Adapter) SpotAccountTradeList(symbol string, limit int64) (trades []Trade, err error) {
+	binanceCon := ExchangeConnectorTypeBinance
+
+	trades, _, err = invokeTyped(e, "SpotAccountTradeList", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotAccountTradeList(e.symbol(cType, symbol, MarketTypeSpot), limit)
+	}, &binanceCon, false, func(body []byte) ([]Trade, error) {
+		return decodeList(body, toTrade)
+	})
+	return trades, err
+}
+
+func (e ExchangeApiV2Adapter) PerpAccountTradeList(symbol string, limit int64) (trades []Trade, err error) {
+	binanceCon := ExchangeConnectorTypeBinance
+
+	trades, _, err = invokeTyped(e, "PerpAccountTradeList", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.PerpAccountTradeList(e.symbol(cType, symbol, MarketTypeUSDTPerp), limit)
+	}, &binanceCon, false, func(body []byte) ([]Trade, error) {
+		return decodeList(body, toTrade)
+	})
+	return trades, err
+}
+
+func (e ExchangeApiV2Adapter) GetCommission(symbols string) (commissions []Commission, err error) {
+	commissions, _, err = invokeTyped(e, "GetCommission", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.GetCommission(symbols)
+	}, nil, false, func(body []byte) ([]Commission, error) {
+		return decodeList(body, toCommission)
+	})
+	return commissions, err
+}
+
+func (e ExchangeApiV2Adapter) SpotAccountInternalTransferRecord(startTime, endTime int64) (transfers []Transfer, err error) {
+	binanceCon := ExchangeConnectorTypeBinance
+
+	transfers, _, err = invokeTyped(e, "SpotAccountInternalTransferRecord", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotAccountInternalTransferRecord(startTime, endTime)
+	}, &binanceCon, false, func(body []byte) ([]Transfer, error) {
+		return decodeList(body, toTransfer)
+	})
+	return transfers, err
+}
+
+func (e ExchangeApiV2Adapter) SpotWithdraw(symbol string, amount decimal.Decimal, to, network string) (id string, err error) {
+	binanceCon := ExchangeConnectorTypeBinance
+
+	id, _, err = invokeTyped(e, "SpotWithdraw", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotWithdraw(symbol, amount.String(), to, network)
+	}, &binanceCon, false, func(body []byte) (string, error) {
+		return decodeObject(body, func(v interface{}) (string, error) {
+			r := newPayloadReader(v)
+			return r.str("id"), r.err
+		})
+	})
+	return id, err
+}
+
+func (e ExchangeApiV2Adapter) SpotWithdrawRecord(startTime, endTime int64) (withdrawals []Withdrawal, err error) {
+	binanceCon := ExchangeConnectorTypeBinance
+
+	withdrawals, _, err = invokeTyped(e, "SpotWithdrawRecord", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotWithdrawRecord(startTime, endTime)
+	}, &binanceCon, false, func(body []byte) ([]Withdrawal, error) {
+		return decodeList(body, toWithdrawal)
+	})
+	return withdrawals, err
+}
+
+func (e ExchangeApiV2Adapter) CapitalCoinGetAll() (coins []CoinConfig, err error) {
+	binanceCon := ExchangeConnectorTypeBinance
+
+	coins, _, err = invokeCached(e, "CapitalCoinGetAll", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.CapitalCoinGetAll()
+	}, &binanceCon, "", func(body []byte) ([]CoinConfig, error) {
+		return decodeList(body, toCoinConfig)
+	})
+	return coins, err
+}
+
+func (e ExchangeApiV2Adapter) SpotAssets(symbol string) (balances []Balance, err error) {
+	balances, _, err = invokeTyped(e, "SpotAssets", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotAssets(symbol)
+	}, nil, false, func(body []byte) ([]Balance, error) {
+		return decodeList(body, toBalance)
+	})
+	return balances, err
+}
+
+func (e ExchangeApiV2Adapter) NewestQuoteTicker(symbol string) (price decimal.Decimal, err error) {
+	ticker, _, err := invokeTyped(e, "NewestQuoteTicker", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.NewestQuoteTicker(e.symbol(cType, symbol, MarketTypeSpot))
+	}, nil, false, func(body []byte) (Ticker, error) {
+		return decodeObject(body, toTicker)
+	})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return ticker.Price, nil
+}
+
+func (e ExchangeApiV2Adapter) GetSpotPrecision(base string) (pricePrecision int32, quantityPrecision int32, quoteQuantityPrecision int32, err error) {
+	type precision struct {
+		PricePrecision         int32 `json:"pricePrecision"`
+		QuantityPrecision      int32 `json:"quantityPrecision"`
+		QuoteQuantityPrecision int32 `json:"quoteQuantityPrecision"`
+	}
+	result, _, err := invokeCached(e, "GetSpotPrecision", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.GetSpotPrecision(base)
+	}, nil, base, func(body []byte) (precision, error) {
+		r := precision{}
+		err := json.Unmarshal(body, &r)
+		return r, err
+	})
+	return result.PricePrecision, result.QuantityPrecision, result.QuoteQuantityPrecision, err
+}
+
+func (e ExchangeApiV2Adapter) SymbolPriceTicker() (tickers []Ticker, err error) {
+	tickers, _, err = invokeTyped(e, "SymbolPriceTicker", func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SymbolPriceTicker()
+	}, nil, false, func(body []byte) ([]Ticker, error) {
+		return decodeList(body, toTicker)
+	})
+	return tickers, err
+}
+
+func (e ExchangeApiV2Adapter) SpotCancelOrder(symbol, orderID string, connector ExchangeConnectorType) (order OrderResult, err error) {
+	return invokeOrder(e, "SpotCancelOrder", MarketTypeSpot, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotCancelOrder(e.symbol(cType, symbol, MarketTypeSpot), orderID)
+	})
+}
+
+func (e ExchangeApiV2Adapter) SpotCancelAllOrders(symbol string, connector ExchangeConnectorType) (orders []OrderResult, err error) {
+	return invokeOrders(e, "SpotCancelAllOrders", connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotCancelAllOrders(e.symbol(cType, symbol, MarketTypeSpot))
+	})
+}
+
+func (e ExchangeApiV2Adapter) SpotQueryOrder(symbol, orderID string, connector ExchangeConnectorType) (order OrderResult, err error) {
+	return invokeOrder(e, "SpotQueryOrder", MarketTypeSpot, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotQueryOrder(e.symbol(cType, symbol, MarketTypeSpot), orderID)
+	})
+}
+
+func (e ExchangeApiV2Adapter) SpotOpenOrders(symbol string, connector ExchangeConnectorType) (orders []OrderResult, err error) {
+	return invokeOrders(e, "SpotOpenOrders", connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SpotOpenOrders(e.symbol(cType, symbol, MarketTypeSpot))
+	})
+}
+
+func (e ExchangeApiV2Adapter) SpotAmendOrder(symbol, orderID string, quantity, price decimal.Decimal, connector ExchangeConnectorType) (order OrderResult, err error) {
+	return invokeOrder(e, "SpotAmendOrder", MarketTypeSpot, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		quantity, price, err := e.Validator.ValidateAmend(e.context(), e.instruments(), cType, connector, symbol, MarketTypeSpot, quantity, price)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		return connector.SpotAmendOrder(e.symbol(cType, symbol, MarketTypeSpot), orderID, orderPrice(quantity), orderPrice(price))
+	})
+}
+
+func (e ExchangeApiV2Adapter) FuturesCancelOrder(symbol, orderID string, connector ExchangeConnectorType) (order OrderResult, err error) {
+	return invokeOrder(e, "FuturesCancelOrder", MarketTypeUSDTPerp, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesCancelOrder(e.symbol(cType, symbol, MarketTypeUSDTPerp), orderID)
+	})
+}
+
+func (e ExchangeApiV2Adapter) FuturesCancelAllOrders(symbol string, connector ExchangeConnectorType) (orders []OrderResult, err error) {
+	return invokeOrders(e, "FuturesCancelAllOrders", connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesCancelAllOrders(e.symbol(cType, symbol, MarketTypeUSDTPerp))
+	})
+}
+
+func (e ExchangeApiV2Adapter) FuturesQueryOrder(symbol, orderID string, connector ExchangeConnectorType) (order OrderResult, err error) {
+	return invokeOrder(e, "FuturesQueryOrder", MarketTypeUSDTPerp, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesQueryOrder(e.symbol(cType, symbol, MarketTypeUSDTPerp), orderID)
+	})
+}
+
+func (e ExchangeApiV2Adapter) FuturesOpenOrders(symbol string, connector ExchangeConnectorType) (orders []OrderResult, err error) {
+	return invokeOrders(e, "FuturesOpenOrders", connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.FuturesOpenOrders(e.symbol(cType, symbol, MarketTypeUSDTPerp))
+	})
+}
+
+func (e ExchangeApiV2Adapter) FuturesAmendOrder(symbol, orderID string, quantity, price decimal.Decimal, connector ExchangeConnectorType) (order OrderResult, err error) {
+	return invokeOrder(e, "FuturesAmendOrder", MarketTypeUSDTPerp, orderID, connector, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		quantity, price, err := e.Validator.ValidateAmend(e.context(), e.instruments(), cType, connector, symbol, MarketTypeUSDTPerp, quantity, price)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		return connector.FuturesAmendOrder(e.symbol(cType, symbol, MarketTypeUSDTPerp), orderID, orderPrice(quantity), orderPrice(price))
+	})
+}
+
+// invokeOrder 把 orderID 的請求送到下單的交易所，並解析回傳的單筆訂單。
+func invokeOrder(e ExchangeApiV2Adapter, method string, market MarketType, orderID string, connector ExchangeConnectorType, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error)) (OrderResult, error) {
+	con, err := orderConnector(e.context(), e.ApiProxy, market, orderID, connector)
+	if err != nil {
+		return OrderResult{}, err
+	}
+	order, connectorType, err := invokeTyped(e, method, fn, con, false, func(body []byte) (OrderResult, error) {
+		return decodeObject(body, toOrderResult)
+	})
+	if err != nil {
+		return OrderResult{}, err
+	}
+	order.ConnectorType = connectorType
+	return order, nil
+}
+
+// invokeOrders 對 orderConnectors 決定的交易所呼叫 fn，並合併回傳的訂單。
+func invokeOrders(e ExchangeApiV2Adapter, method string, connector ExchangeConnectorType, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error)) ([]OrderResult, error) {
+	responses, err := invokeEach(e.methodContext(method), e.ApiProxy, fn, orderConnectors(e.ApiProxy, connector))
+	return decodeOrderResults(method, responses, err)
+}
+
+// decodeOrderResults 合併各交易所回傳的訂單並標上交易所；err 為 invokeEach 的錯誤，成功的交易所結果仍會回傳。
+func decodeOrderResults(method string, responses []ExchangeApiResponse, err error) ([]OrderResult, error) {
+	orders := []OrderResult{}
+	errs := []error{err}
+	for _, apiResponse := range responses {
+		result, connectorType, err := decodeTyped(method, apiResponse, nil, func(body []byte) ([]OrderResult, error) {
+			return decodeList(body, toOrderResult)
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for i := range result {
+			result[i].ConnectorType = connectorType
+		}
+		orders = append(orders, result...)
+	}
+	return orders, errors.Join(errs...)
+}
@@ -0,0 +1,385 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+type ExchangeInfoEventType string
+
+const (
+	// ExchangeInfoListed 為新上架的交易對或幣種
+	ExchangeInfoListed   ExchangeInfoEventType = "listed"
+	ExchangeInfoDelisted ExchangeInfoEventType = "delisted"
+	// ExchangeInfoChanged 為精度、filters、狀態或網路設定等內容變動
+	ExchangeInfoChanged ExchangeInfoEventType = "changed"
+)
+
+func (t ExchangeInfoEventType) String() string {
+	return string(t)
+}
+
+// ExchangeInfoEvent 為兩次載入之間的差異。Key 為交易對或幣種，單一物件的回應（例如精度）為查詢參數；
+// Before、After 為標準格式的 JSON，上架時 Before 為空、下架時 After 為空。
+type ExchangeInfoEvent struct {
+	Type      ExchangeInfoEventType `json:"type"`
+	Connector ExchangeConnectorType `json:"connector"`
+	Method    string                `json:"method"`
+	Key       string                `json:"key"`
+	Before    json.RawMessage       `json:"before,omitempty"`
+	After     json.RawMessage       `json:"after,omitempty"`
+	Timestamp time.Time             `json:"timestamp"`
+}
+
+type ExchangeInfoCacheConfig struct {
+	// TTL 為項目的有效時間，過期後下一次查詢會重新向交易所載入
+	TTL time.Duration
+	// RefreshInterval 為 Start 之後背景重新載入所有項目的間隔，應小於 TTL，讓查詢不必等待交易所
+	RefreshInterval time.Duration
+	// MaxStale 為項目過期後重新載入失敗時仍可使用舊資料的時間，超過後回傳載入錯誤；預設與 TTL 相同，負數表示不使用舊資料
+	MaxStale time.Duration
+	// OnChange 在重新載入發現差異時呼叫，於載入的 goroutine 中同步執行
+	OnChange func(ExchangeInfoEvent)
+}
+
+var DefaultExchangeInfoCacheConfig = ExchangeInfoCacheConfig{
+	TTL:             24 * time.Hour,
+	RefreshInterval: time.Hour,
+}
+
+func (c ExchangeInfoCacheConfig) withDefaults() ExchangeInfoCacheConfig {
+	if c.TTL == 0 {
+		c.TTL = DefaultExchangeInfoCacheConfig.TTL
+	}
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = DefaultExchangeInfoCacheConfig.RefreshInterval
+	}
+	if c.MaxStale == 0 {
+		c.MaxStale = c.TTL
+	}
+	return c
+}
+
+type exchangeInfoKey struct {
+	Connector ExchangeConnectorType
+	Method    string
+	Arg       string
+}
+
+// exchangeInfoLoader 載入一個項目；con 不為 nil 時需由該交易所處理。
+type exchangeInfoLoader func(ctx context.Context, con *ExchangeConnectorType) (ExchangeApiResponse, error)
+
+type exchangeInfoEntry struct {
+	res ExchangeApiResponse
+	// refresh 為背景重新載入使用的 loader，nil 表示只在過期後的查詢重新載入
+	refresh  exchangeInfoLoader
+	loadedAt time.Time
+}
+
+type exchangeInfoCall struct {
+	done chan struct{}
+	res  ExchangeApiResponse
+	err  error
+}
+
+// ExchangeInfoCache 在記憶體保存精度、exchange info 與幣種設定等很少變動的回應，依交易所分開保存。
+// 同一項目同時未命中時只向交易所查詢一次。實作 Kratos transport.Server，啟動後每 RefreshInterval
+// 重新載入所有項目，並以 OnChange 回報差異；未啟動時項目於 TTL 過期後在下一次查詢重新載入。
+type ExchangeInfoCache struct {
+	config ExchangeInfoCacheConfig
+
+	mu      sync.RWMutex
+	entries map[exchangeInfoKey]*exchangeInfoEntry
+	calls   map[exchangeInfoKey]*exchangeInfoCall
+
+	run *lifecycle
+}
+
+func NewExchangeInfoCache(cfg ExchangeInfoCacheConfig) *ExchangeInfoCache {
+	return &ExchangeInfoCache{
+		config:  cfg.withDefaults(),
+		entries: map[exchangeInfoKey]*exchangeInfoEntry{},
+		calls:   map[exchangeInfoKey]*exchangeInfoCall{},
+		run:     newLifecycle(),
+	}
+}
+
+// directInvoker 讓快取在背景重新載入時直接呼叫指定的交易所，不經過 proxy 的失敗計數與切換。
+type directInvoker interface {
+	invokeDirect(ctx context.Context, ct ExchangeConnectorType, method string, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error)) (ExchangeApiResponse, error)
+}
+
+func (proxy ExchangeApiProxyImpl) invokeDirect(_ context.Context, ct ExchangeConnectorType, method string, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error)) (ExchangeApiResponse, error) {
+	connector := proxy.connectorOf(ct)
+	if connector == nil {
+		return ExchangeApiResponse{}, fmt.Errorf("connector %v not configured", ct)
+	}
+	res, err := fn(ct, connector)
+	if err != nil {
+		return ExchangeApiResponse{}, err
+	}
+	if !res.IsSuccess {
+		return ExchangeApiResponse{}, fmt.Errorf("%v %v failed: %v", ct, method, res.FailureCode)
+	}
+	if res.ConnectorType == "" {
+		res.ConnectorType = ct
+	}
+	return proxy.normalize(method, res)
+}
+
+// invoke 以 proxy 呼叫 fn 並快取回應，arg 為 fn 的查詢參數。未指定 con 時以 proxy 目前的交易所查詢快取，
+// 回應則存在實際處理請求的交易所之下。c 為 nil 時直接呼叫 proxy。背景重新載入直接呼叫原本的交易所，
+// proxy 不支援時項目只在過期後的查詢重新載入。
+func (c *ExchangeInfoCache) invoke(ctx context.Context, proxy ExchangeApiProxy, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), con *ExchangeConnectorType, arg string) (ExchangeApiResponse, error) {
+	if c == nil {
+		return proxy.InvokeContext(ctx, fn, con, false)
+	}
+	ct := ExchangeConnectorType(proxy.NowConnect())
+	if con != nil {
+		ct = *con
+	}
+	method := invokeMethod(ctx, fn)
+	var refresh exchangeInfoLoader
+	if d, ok := proxy.(directInvoker); ok {
+		refresh = func(ctx context.Context, con *ExchangeConnectorType) (ExchangeApiResponse, error) {
+			return d.invokeDirect(ctx, *con, method, fn)
+		}
+	}
+	return c.get(ctx, exchangeInfoKey{Connector: ct, Method: method, Arg: arg}, con, func(ctx context.Context, con *ExchangeConnectorType) (ExchangeApiResponse, error) {
+		return proxy.InvokeContext(ctx, fn, con, false)
+	}, refresh)
+}
+
+func (c *ExchangeInfoCache) lookup(key exchangeInfoKey) (*exchangeInfoEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return entry, time.Since(entry.loadedAt) < c.config.TTL
+}
+
+func (c *ExchangeInfoCache) get(ctx context.Context, key exchangeInfoKey, con *ExchangeConnectorType, load, refresh exchangeInfoLoader) (ExchangeApiResponse, error) {
+	if entry, fresh := c.lookup(key); fresh {
+		return entry.res, nil
+	}
+
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.res, call.err
+		case <-ctx.Done():
+			return ExchangeApiResponse{}, ctx.Err()
+		}
+	}
+	call := &exchangeInfoCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.res, call.err = c.load(ctx, key, con, load, refresh)
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+	return call.res, call.err
+}
+
+// load 載入並保存項目；載入失敗但過期未超過 MaxStale 時回傳舊資料，交易所暫時異常時仍可使用。
+func (c *ExchangeInfoCache) load(ctx context.Context, key exchangeInfoKey, con *ExchangeConnectorType, load, refresh exchangeInfoLoader) (ExchangeApiResponse, error) {
+	res, err := load(ctx, con)
+	if err != nil {
+		if entry, _ := c.lookup(key); entry != nil {
+			if age := time.Since(entry.loadedAt); age < c.config.TTL+c.config.MaxStale {
+				log.Infof("load %v %v(%v) error, using cached response loaded %v ago: %v", key.Connector, key.Method, key.Arg, age.Truncate(time.Second), err)
+				return entry.res, nil
+			}
+		}
+		return ExchangeApiResponse{}, err
+	}
+	key.Connector = res.ConnectorType
+	c.store(key, res, refresh)
+	return res, nil
+}
+
+func (c *ExchangeInfoCache) store(key exchangeInfoKey, res ExchangeApiResponse, refresh exchangeInfoLoader) {
+	now := time.Now()
+	c.mu.Lock()
+	prev := c.entries[key]
+	c.entries[key] = &exchangeInfoEntry{res: res, refresh: refresh, loadedAt: now}
+	c.mu.Unlock()
+
+	if prev == nil || c.config.OnChange == nil {
+		return
+	}
+	for _, event := range diffExchangeInfo(key, prev.res.Body, res.Body) {
+		event.Timestamp = now
+		c.config.OnChange(event)
+	}
+}
+
+// Refresh 重新載入所有項目，每個項目直接由原本的交易所處理，失敗不計入 proxy 的失敗次數。
+func (c *ExchangeInfoCache) Refresh(ctx context.Context) {
+	c.mu.RLock()
+	entries := make(map[exchangeInfoKey]exchangeInfoLoader, len(c.entries))
+	for key, entry := range c.entries {
+		if entry.refresh != nil {
+			entries[key] = entry.refresh
+		}
+	}
+	c.mu.RUnlock()
+
+	for key, refresh := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		ct := key.Connector
+		res, err := refresh(ctx, &ct)
+		if err != nil {
+			log.Infof("refresh %v %v(%v) error: %v", key.Connector, key.Method, key.Arg, err)
+			continue
+		}
+		c.store(key, res, refresh)
+	}
+}
+
+// Invalidate 移除 ct 的所有項目，ct 為空字串時清空快取。
+func (c *ExchangeInfoCache) Invalidate(ct ExchangeConnectorType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if ct == "" || key.Connector == ct {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *ExchangeInfoCache) Start(ctx context.Context) error {
+	if !c.run.begin() {
+		return nil
+	}
+	defer c.run.end()
+	ticker := time.NewTicker(c.config.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.run.stop:
+			return nil
+		case <-ticker.C:
+			c.Refresh(ctx)
+		}
+	}
+}
+
+func (c *ExchangeInfoCache) Stop(ctx context.Context) error {
+	return c.run.shutdown(ctx)
+}
+
+// diffExchangeInfo 比較同一項目兩次載入的內容。含 symbols 的 exchange info 與清單依 symbol 或 coin 逐筆比較，
+// 單一物件以查詢參數為 key 整筆比較。
+func diffExchangeInfo(key exchangeInfoKey, before, after []byte) []ExchangeInfoEvent {
+	prev, err := exchangeInfoItems(key, before)
+	if err != nil {
+		return nil
+	}
+	next, err := exchangeInfoItems(key, after)
+	if err != nil {
+		log.Infof("diff %v %v response error: %v", key.Connector, key.Method, err)
+		return nil
+	}
+
+	var events []ExchangeInfoEvent
+	event := func(t ExchangeInfoEventType, item string, b, a interface{}) {
+		e := ExchangeInfoEvent{Type: t, Connector: key.Connector, Method: key.Method, Key: item}
+		if b != nil {
+			e.Before, _ = json.Marshal(b)
+		}
+		if a != nil {
+			e.After, _ = json.Marshal(a)
+		}
+		events = append(events, e)
+	}
+	for _, item := range sortedKeys(next) {
+		old, ok := prev[item]
+		switch {
+		case !ok:
+			event(ExchangeInfoListed, item, nil, next[item])
+		case !reflect.DeepEqual(old, next[item]):
+			event(ExchangeInfoChanged, item, old, next[item])
+		}
+	}
+	for _, item := range sortedKeys(prev) {
+		if _, ok := next[item]; !ok {
+			event(ExchangeInfoDelisted, item, prev[item], nil)
+		}
+	}
+	return events
+}
+
+func exchangeInfoItems(key exchangeInfoKey, body []byte) (map[string]interface{}, error) {
+	var v interface{}
+	if err := decodePayload(body, &v); err != nil {
+		return nil, err
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		if list = newPayloadReader(v).list("symbols"); list == nil {
+			return map[string]interface{}{key.Arg: v}, nil
+		}
+	}
+	items := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		r := newPayloadReader(item)
+		items[r.str("symbol", "coin")] = item
+	}
+	return items, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CachedSymbolInfoSource 以 Cache 保存 Source 的結果，讓下單前檢查不必每筆訂單都查詢交易所。
+type CachedSymbolInfoSource struct {
+	Cache  *ExchangeInfoCache
+	Source SymbolInfoSource
+}
+
+func (s CachedSymbolInfoSource) SymbolInfo(ctx context.Context, ct ExchangeConnectorType, connector ExchangeConnector, inst Instrument) (SymbolInfo, error) {
+	if s.Cache == nil {
+		return s.Source.SymbolInfo(ctx, ct, connector, inst)
+	}
+	key := exchangeInfoKey{Connector: ct, Method: "SymbolInfo", Arg: inst.ID()}
+	load := func(ctx context.Context, _ *ExchangeConnectorType) (ExchangeApiResponse, error) {
+		info, err := s.Source.SymbolInfo(ctx, ct, connector, inst)
+		if err != nil {
+			return ExchangeApiResponse{}, err
+		}
+		body, err := json.Marshal(info)
+		return ExchangeApiResponse{IsSuccess: true, Body: body, ConnectorType: ct}, err
+	}
+	res, err := s.Cache.get(ctx, key, &ct, load, load)
+	if err != nil {
+		return SymbolInfo{}, err
+	}
+	info := SymbolInfo{}
+	err = json.Unmarshal(res.Body, &info)
+	return info, err
+}
@@ -0,0 +1,185 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeInfoProxy 以 body 回應所有呼叫，分別計算經由 proxy 與直接呼叫交易所的次數。
+type fakeInfoProxy struct {
+	mu      sync.Mutex
+	body    string
+	invokes int
+	directs int
+}
+
+func (p *fakeInfoProxy) setBody(body string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.body = body
+}
+
+func (p *fakeInfoProxy) respond(counter *int) ExchangeApiResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	*counter++
+	return ExchangeApiResponse{IsSuccess: true, Body: []byte(p.body), ConnectorType: ExchangeConnectorTypeBinance}
+}
+
+func (p *fakeInfoProxy) Invoke(fn func(ct ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), con *ExchangeConnectorType, needStandbyConnector bool) (ExchangeApiResponse, error) {
+	return p.InvokeContext(context.Background(), fn, con, needStandbyConnector)
+}
+
+func (p *fakeInfoProxy) InvokeContext(context.Context, func(ct ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), *ExchangeConnectorType, bool) (ExchangeApiResponse, error) {
+	return p.respond(&p.invokes), nil
+}
+
+func (p *fakeInfoProxy) NowConnect() string {
+	return ExchangeConnectorTypeBinance.String()
+}
+
+func (p *fakeInfoProxy) invokeDirect(context.Context, ExchangeConnectorType, string, func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error)) (ExchangeApiResponse, error) {
+	return p.respond(&p.directs), nil
+}
+
+func noopInfoFn(ExchangeConnectorType, ExchangeConnector) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{}, nil
+}
+
+func TestExchangeInfoCacheSingleflight(t *testing.T) {
+	cache := NewExchangeInfoCache(ExchangeInfoCacheConfig{})
+	release := make(chan struct{})
+	var calls int32
+	load := func(context.Context, *ExchangeConnectorType) (ExchangeApiResponse, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return ExchangeApiResponse{IsSuccess: true, Body: []byte(`{}`), ConnectorType: ExchangeConnectorTypeBinance}, nil
+	}
+	key := exchangeInfoKey{Connector: ExchangeConnectorTypeBinance, Method: "FuturesExchangeInfo"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.get(context.Background(), key, nil, load, nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("loads = %d, want 1", calls)
+	}
+}
+
+func TestExchangeInfoCacheTTLAndMaxStale(t *testing.T) {
+	cache := NewExchangeInfoCache(ExchangeInfoCacheConfig{TTL: time.Minute, MaxStale: time.Minute})
+	key := exchangeInfoKey{Connector: ExchangeConnectorTypeBinance, Method: "CapitalCoinGetAll"}
+	var (
+		calls int
+		fail  error
+	)
+	load := func(context.Context, *ExchangeConnectorType) (ExchangeApiResponse, error) {
+		calls++
+		if fail != nil {
+			return ExchangeApiResponse{}, fail
+		}
+		return ExchangeApiResponse{IsSuccess: true, Body: []byte(`[]`), ConnectorType: ExchangeConnectorTypeBinance}, nil
+	}
+	age := func(d time.Duration) {
+		cache.mu.Lock()
+		cache.entries[key].loadedAt = time.Now().Add(-d)
+		cache.mu.Unlock()
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.get(ctx, key, nil, load, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("loads within TTL = %d, want 1", calls)
+	}
+
+	age(2 * time.Minute)
+	if _, err := cache.get(ctx, key, nil, load, nil); err != nil || calls != 2 {
+		t.Fatalf("expired entry: err=%v loads=%d, want reload", err, calls)
+	}
+
+	fail = errors.New("exchange down")
+	age(90 * time.Second)
+	if _, err := cache.get(ctx, key, nil, load, nil); err != nil {
+		t.Fatalf("stale entry within MaxStale: %v", err)
+	}
+	age(3 * time.Minute)
+	if _, err := cache.get(ctx, key, nil, load, nil); !errors.Is(err, fail) {
+		t.Fatalf("stale entry beyond MaxStale: err = %v, want load error", err)
+	}
+}
+
+func TestExchangeInfoCacheRefreshDiff(t *testing.T) {
+	var events []ExchangeInfoEvent
+	cache := NewExchangeInfoCache(ExchangeInfoCacheConfig{OnChange: func(e ExchangeInfoEvent) {
+		events = append(events, e)
+	}})
+	proxy := &fakeInfoProxy{body: `{"symbols":[{"symbol":"BTCUSDT","status":"TRADING"},{"symbol":"ETHUSDT","status":"TRADING"}]}`}
+	ctx := WithInvokeMethod(context.Background(), "FuturesExchangeInfo")
+	if _, err := cache.invoke(ctx, proxy, noopInfoFn, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.entries[exchangeInfoKey{Connector: ExchangeConnectorTypeBinance, Method: "FuturesExchangeInfo"}]; !ok {
+		t.Fatalf("entries = %v, want key from WithInvokeMethod", cache.entries)
+	}
+
+	proxy.setBody(`{"symbols":[{"symbol":"BTCUSDT","status":"BREAK"},{"symbol":"SOLUSDT","status":"TRADING"}]}`)
+	cache.Refresh(context.Background())
+
+	if proxy.invokes != 1 || proxy.directs != 1 {
+		t.Fatalf("invokes = %d, directs = %d; refresh should bypass the proxy", proxy.invokes, proxy.directs)
+	}
+	want := map[string]ExchangeInfoEventType{
+		"BTCUSDT": ExchangeInfoChanged,
+		"SOLUSDT": ExchangeInfoListed,
+		"ETHUSDT": ExchangeInfoDelisted,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for _, e := range events {
+		if want[e.Key] != e.Type || e.Method != "FuturesExchangeInfo" || e.Connector != ExchangeConnectorTypeBinance {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	}
+}
+
+func TestExchangeInfoCacheStop(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	idle := NewExchangeInfoCache(ExchangeInfoCacheConfig{})
+	if err := idle.Stop(ctx); err != nil {
+		t.Fatalf("stop without start: %v", err)
+	}
+
+	cache := NewExchangeInfoCache(ExchangeInfoCacheConfig{})
+	done := make(chan error, 1)
+	go func() { done <- cache.Start(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	if err := cache.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Stop(ctx); err != nil {
+		t.Fatalf("second stop: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,11 +2,18 @@ package failover
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const ExchangeConnectorKey = "exchange:connector"
@@ -16,16 +23,101 @@ const ExchangeConnectorErrTimeAt = "exchange:errTime"
 const ExchangeConnectorErrThreshold = 5
 const ExchangeConnectorErrTTL = time.Duration(30) * time.Second
 
+const tracerName = "github.com/yourorg/exchange-failover"
+
 type ExchangeApiProxyImpl struct {
 	BinanceImpl  ExchangeConnector
 	OKXImpl      ExchangeConnector
 	Cache        redis.UniversalClient
 	AlertService IAlertService
+	// AlertSink 接收所有告警，NewProxy 未設定 WithAlertSink 時以 AlertServiceSink 包裝 AlertService
+	AlertSink   AlertSink
+	Tracer      trace.TracerProvider
+	Journal     EventJournal
+	Config      Config
+	StateCache  *StateCache
+	Coordinator *Coordinator
+
+	// AlertRenderer 產生告警內容，未設定時使用 Config.AlertLocale 的預設範本
+	AlertRenderer *AlertRenderer
+
+	MaintenanceProbe func(connector ExchangeConnector) (ExchangeApiResponse, error)
+
+	// Normalizers 依 ConnectorType 把成功的回應轉成標準格式，未設定的交易所使用 DefaultResponseNormalizers
+	Normalizers map[ExchangeConnectorType]ResponseNormalizer
+	// Instruments 為 NewAdapter 與 OKXNormalizer 轉換交易對使用的 registry，未設定時使用 DefaultInstrumentRegistry
+	Instruments *InstrumentRegistry
+}
+
+func (proxy ExchangeApiProxyImpl) config() Config {
+	return proxy.Config.withDefaults()
+}
+
+func (proxy ExchangeApiProxyImpl) state() *StateStore {
+	state := NewStateStore(proxy.Cache, proxy.Config)
+	if proxy.StateCache != nil {
+		state.onChange = proxy.StateCache.invalidate
+	}
+	return state
 }
 
-func (proxy ExchangeApiProxyImpl) getConnector(con *ExchangeConnectorType, needStandbyConnector bool) (ct ExchangeConnectorType, connector ExchangeConnector, err error) {
-	ctx := context.Background()
+// snapshot 優先使用 StateCache，無可用快照時從 Redis 載入；Redis 無法連線時改用降級模式的本地狀態。
+func (proxy ExchangeApiProxyImpl) snapshot(ctx context.Context, state *StateStore) (StateSnapshot, bool, error) {
+	c := proxy.StateCache
+	now := time.Now()
+	if c.isDegraded() {
+		if !c.probeDue(now) {
+			return c.degradedSnapshot(now), true, nil
+		}
+	} else if snap, ok := c.Get(); ok {
+		return snap, true, nil
+	}
+
+	snap, err := state.Snapshot(ctx)
+	if err != nil {
+		if proxy.degrade(ctx, err) {
+			return c.degradedSnapshot(now), true, nil
+		}
+		return snap, false, err
+	}
+	c.remember(snap)
+	return proxy.reconcileDegraded(ctx, state, snap), false, nil
+}
 
+func (proxy ExchangeApiProxyImpl) recordEvent(ctx context.Context, event FailoverEvent) {
+	if proxy.Journal == nil {
+		return
+	}
+	event.InstanceID = proxy.config().InstanceID
+	// exchange:connector 尚未寫入時視為主交易所
+	if event.From == "" {
+		event.From = ExchangeConnectorTypeBinance
+	}
+	if event.To == "" {
+		event.To = ExchangeConnectorTypeBinance
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if err := proxy.Journal.Append(ctx, event); err != nil {
+		log.Infof("record failover event error: %v", err)
+	}
+}
+
+func (proxy ExchangeApiProxyImpl) tracer() trace.Tracer {
+	if proxy.Tracer == nil {
+		return trace.NewNoopTracerProvider().Tracer(tracerName)
+	}
+	return proxy.Tracer.Tracer(tracerName)
+}
+
+func (proxy ExchangeApiProxyImpl) startStateSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	return proxy.tracer().Start(ctx, "failover.state."+op, trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+	))
+}
+
+func (proxy ExchangeApiProxyImpl) getConnector(ctx context.Context, con *ExchangeConnectorType, needStandbyConnector bool, method string) (ct ExchangeConnectorType, connector ExchangeConnector, err error) {
 	if con != nil {
 		switch *con {
 		case ExchangeConnectorTypeBinance:
@@ -35,142 +127,369 @@ func (proxy ExchangeApiProxyImpl) getConnector(con *ExchangeConnectorType, needS
 		}
 	}
 
-	nowConnector, err := proxy.Cache.Get(ctx, ExchangeConnectorKey).Result()
-	if err != nil && err != redis.Nil {
+	state := proxy.state()
+	ctx, span := proxy.startStateSpan(ctx, "getConnector")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	snap, cached, err := proxy.snapshot(ctx, state)
+	if err != nil {
 		return "", nil, err
 	}
-	if nowConnector == ExchangeConnectorTypeBinance.String() || nowConnector == "" {
-		return ExchangeConnectorTypeBinance, proxy.BinanceImpl, nil
-	}
+	span.SetAttributes(
+		attribute.Bool("failover.state_cached", cached),
+		attribute.Bool("failover.degraded", proxy.StateCache.isDegraded()),
+	)
 
-	exist, err := proxy.Cache.Exists(ctx, ExchangeConnectorLockTime).Result()
+	pin, err := proxy.resolvePin(ctx, state, snap.Pin)
 	if err != nil {
 		return "", nil, err
 	}
-	if exist == 0 && needStandbyConnector {
-		return ExchangeConnectorTypeBinance, proxy.BinanceImpl, nil
+	if pin != nil {
+		span.SetAttributes(attribute.Bool("failover.pinned", true))
+		switch pin.Connector {
+		case ExchangeConnectorTypeBinance:
+			return ExchangeConnectorTypeBinance, proxy.BinanceImpl, nil
+		case ExchangeConnectorTypeOKX:
+			return ExchangeConnectorTypeOKX, proxy.OKXImpl, nil
+		}
 	}
 
-	return ExchangeConnectorTypeOKX, proxy.OKXImpl, nil
+	ct = stateConnector(snap, needStandbyConnector)
+	if rerouted, ok := maintenanceReroute(snap.Maintenance, ct, method); ok {
+		span.SetAttributes(attribute.Bool("failover.maintenance_reroute", true))
+		ct = rerouted
+	}
+	return ct, proxy.connectorOf(ct), nil
 }
 
-func (proxy ExchangeApiProxyImpl) addFailureCount(ct ExchangeConnectorType) error {
-	ctx := context.Background()
+// stateConnector 依 exchange:connector 與 LockTime 決定使用的交易所。
+func stateConnector(snap StateSnapshot, needStandbyConnector bool) ExchangeConnectorType {
+	if snap.Connector == ExchangeConnectorTypeBinance || snap.Connector == "" {
+		return ExchangeConnectorTypeBinance
+	}
+	if !snap.Locked(time.Now()) && needStandbyConnector {
+		return ExchangeConnectorTypeBinance
+	}
+	return ExchangeConnectorTypeOKX
+}
+
+func (proxy ExchangeApiProxyImpl) addFailureCount(ctx context.Context, ct ExchangeConnectorType, failureCode, method string) (switched bool, err error) {
+	state := proxy.state()
+	ctx, span := proxy.startStateSpan(ctx, "addFailureCount")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	if proxy.StateCache.isDegraded() {
+		return proxy.addLocalFailure(ctx, ct, failureCode, method), nil
+	}
 
-	nowConnector, err := proxy.Cache.Get(ctx, ExchangeConnectorKey).Result()
-	if err != nil && err != redis.Nil {
-		return err
+	nowConnector, gen, err := state.ConnectorState(ctx)
+	if err != nil {
+		if proxy.degrade(ctx, err) {
+			return proxy.addLocalFailure(ctx, ct, failureCode, method), nil
+		}
+		return false, err
 	}
 	log.Infof("addFailureCount nowConnector: %v", nowConnector)
-	if nowConnector == ExchangeConnectorTypeOKX.String() {
-		err = proxy.Cache.Set(ctx, ExchangeConnectorLockTime, time.Now(), ExchangeConnectorLockTimeTTL).Err()
-		if err != nil {
-			return err
+
+	if proxy.Coordinator.following(ctx) {
+		// coordinator 模式下 follower 只寫入錯誤並回報，由 leader 決定是否切換
+		if _, err = state.AddFailure(ctx, ct, failureCode, method); err != nil {
+			if proxy.degrade(ctx, err) {
+				return proxy.addLocalFailure(ctx, ct, failureCode, method), nil
+			}
+			return false, err
 		}
+		proxy.Coordinator.report(ctx, coordinatorReport{Kind: coordinatorReportFailure, Connector: ct, Code: failureCode, Method: method})
+		return false, nil
 	}
 
-	key := fmt.Sprintf("%v:%v:%v", ExchangeConnectorErrTimeAt, ct, time.Now().UnixMilli())
-	if err := proxy.Cache.Set(ctx, key, true, ExchangeConnectorErrTTL).Err(); err != nil {
-		return err
+	if nowConnector == ExchangeConnectorTypeOKX {
+		if gen, err = proxy.extendLock(ctx, state, gen); err != nil {
+			return false, err
+		}
 	}
 
-	key = fmt.Sprintf("%v:%v:*", ExchangeConnectorErrTimeAt, ct)
-	errTimestamps, err := proxy.Cache.Keys(ctx, key).Result()
+	errTimestamps, err := state.AddFailure(ctx, ct, failureCode, method)
+	if err != nil {
+		if proxy.degrade(ctx, err) {
+			return proxy.addLocalFailure(ctx, ct, failureCode, method), nil
+		}
+		return false, err
+	}
+	return proxy.evaluateFailures(ctx, state, ct, nowConnector, gen, errTimestamps)
+}
+
+// extendLock 在備援交易所上發生錯誤時延長 LockTime，避免切回仍異常的主交易所；
+// generation 已被其他 writer 改變時放棄延長並回傳最新的 generation，由之後的判斷以最新狀態處理。
+func (proxy ExchangeApiProxyImpl) extendLock(ctx context.Context, state *StateStore, gen int64) (int64, error) {
+	next, err := state.SetLock(ctx, gen)
+	if errors.Is(err, ErrStaleGeneration) {
+		log.Infof("skip extending lock: %v", err)
+		return next, nil
+	}
+	return next, err
+}
+
+// evaluateFailures 在主交易所 ct 的錯誤視窗達到閾值時切換到 OKX 並送出告警；gen 為讀取 nowConnector 時的 generation，
+// 期間狀態已被其他 writer 改變時放棄切換。已在 OKX 或錯誤來自 OKX 時只延長 LockTime（由呼叫端處理），不再切換。
+func (proxy ExchangeApiProxyImpl) evaluateFailures(ctx context.Context, state *StateStore, ct, nowConnector ExchangeConnectorType, gen int64, errTimestamps []string) (bool, error) {
+	if ct != ExchangeConnectorTypeBinance || connectorOrPrimary(nowConnector) != ExchangeConnectorTypeBinance {
+		return false, nil
+	}
+	pin, err := proxy.activePin(ctx, state)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if pin != nil {
+		// pin 期間只計數，不自動切換
+		return false, nil
 	}
 
-	if len(errTimestamps) >= ExchangeConnectorErrThreshold {
-		err = proxy.Cache.Set(ctx, ExchangeConnectorKey, ExchangeConnectorTypeOKX.String(), -1).Err()
+	cfg := proxy.config()
+	if len(errTimestamps) >= cfg.ErrThreshold {
+		gen, err = state.Transition(ctx, gen, ExchangeConnectorTypeOKX, cfg.LockTimeTTL)
+		if errors.Is(err, ErrStaleGeneration) {
+			log.Infof("skip switch to %v: %v", ExchangeConnectorTypeOKX, err)
+			return false, nil
+		}
 		if err != nil {
-			return err
+			return false, err
 		}
 
-		err = proxy.Cache.Set(ctx, ExchangeConnectorLockTime, time.Now(), ExchangeConnectorLockTimeTTL).Err()
+		from := ct
+		codes, methods := proxy.failureSummary(ctx, errTimestamps)
+		proxy.recordEvent(ctx, FailoverEvent{
+			Type:        FailoverEventSwitch,
+			From:        from,
+			To:          ExchangeConnectorTypeOKX,
+			Codes:       codes,
+			Methods:     methods,
+			WindowCount: len(errTimestamps),
+			Generation:  gen,
+		})
+
+		// 切回時以同一個 ConnectorSince 計算異常持續時間，告警可依此對應
+		since, err := state.ConnectorSince(ctx)
 		if err != nil {
-			return err
+			log.Infof("read connector since error: %v", err)
 		}
+		proxy.sendAlert(ctx, AlertTemplateSwitch, from.String(), AlertData{
+			From:        from,
+			To:          ExchangeConnectorTypeOKX,
+			WindowCount: len(errTimestamps),
+			Codes:       codes,
+			Methods:     methods,
+			LockTTL:     cfg.LockTimeTTL,
+			Generation:  gen,
+			OutageStart: since,
+		})
+		return true, nil
+	}
 
-		msg := "因 幣安 發生異常無法使用，先採用 OKX 進行避險、報價的執行。請通知第三方廠商做緊急處理。"
-		if innerErr := proxy.AlertService.SendErrorAlert("Binance", msg); innerErr != nil {
-			log.Infof("HandleRequestAlert error: %v", innerErr)
-		}
+	return false, nil
+}
+
+// failureSummary 讀出錯誤視窗內記錄的錯誤碼與方法（去重）。
+func (proxy ExchangeApiProxyImpl) failureSummary(ctx context.Context, keys []string) (codes, methods []string) {
+	records, err := proxy.state().failureRecords(ctx, keys)
+	if err != nil {
+		log.Infof("read failure window error: %v", err)
+		return nil, nil
 	}
+	return summarizeFailures(records)
+}
 
-	return nil
+func summarizeFailures(records []FailureRecord) (codes, methods []string) {
+	seenCode, seenMethod := map[string]bool{}, map[string]bool{}
+	for _, record := range records {
+		if record.Code != "" && !seenCode[record.Code] {
+			seenCode[record.Code] = true
+			codes = append(codes, record.Code)
+		}
+		if record.Method != "" && !seenMethod[record.Method] {
+			seenMethod[record.Method] = true
+			methods = append(methods, record.Method)
+		}
+	}
+	return codes, methods
 }
 
-func (proxy ExchangeApiProxyImpl) resetFailureCount(ct ExchangeConnectorType) error {
-	ctx := context.Background()
+func (proxy ExchangeApiProxyImpl) resetFailureCount(ctx context.Context, ct ExchangeConnectorType, method string) (recovered bool, err error) {
+	state := proxy.state()
+	ctx, span := proxy.startStateSpan(ctx, "resetFailureCount")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
 
-	existConnectorLockTime, err := proxy.Cache.Exists(ctx, ExchangeConnectorLockTime).Result()
+	if proxy.StateCache.isDegraded() {
+		return proxy.recoverLocally(ctx), nil
+	}
+
+	// 快照顯示不需要切回時省略 Redis 讀取，快照過舊時最多延後到下一次成功的呼叫
+	if snap, ok := proxy.StateCache.Get(); ok && (snap.Connector == ExchangeConnectorTypeBinance || snap.Locked(time.Now())) {
+		return false, nil
+	}
+
+	isLockOKX, err := state.Locked(ctx)
 	if err != nil {
-		return nil
+		return false, nil
 	}
-	isLockOKX := existConnectorLockTime != 0
 
-	nowConnector, err := proxy.Cache.Get(ctx, ExchangeConnectorKey).Result()
-	if err != nil && err != redis.Nil {
-		return err
+	nowConnector, gen, err := state.ConnectorState(ctx)
+	if err != nil {
+		return false, err
 	}
 
-	if nowConnector == ExchangeConnectorTypeBinance.String() || isLockOKX {
-		return nil
+	// exchange:connector 尚未寫入時已在主交易所，不需切回也不記錄 recovery
+	if connectorOrPrimary(nowConnector) == ExchangeConnectorTypeBinance || isLockOKX {
+		return false, nil
 	}
 
-	key := fmt.Sprintf("%v:%v:*", ExchangeConnectorErrTimeAt, ct)
-	errTimestamps, err := proxy.Cache.Keys(ctx, key).Result()
+	pin, err := proxy.activePin(ctx, state)
 	if err != nil {
-		return err
+		return false, err
 	}
-	for _, k := range errTimestamps {
-		err = proxy.Cache.Del(ctx, k).Err()
-		if err != nil {
-			return err
-		}
+	if pin != nil {
+		// pin 期間不自動切回，待 pin 結束後由下一次成功的呼叫切回
+		return false, nil
+	}
+
+	// 主交易所維護中或等待探測時不切回，LockTime 過期後仍由 probeMaintenance 探測成功才切回
+	if maintaining, err := proxy.primaryMaintaining(ctx, state); err != nil || maintaining {
+		return false, err
 	}
 
-	err = proxy.Cache.Set(context.Background(), ExchangeConnectorKey, ExchangeConnectorTypeBinance.String(), -1).Err()
+	if proxy.Coordinator.following(ctx) {
+		proxy.Coordinator.report(ctx, coordinatorReport{Kind: coordinatorReportSuccess, Connector: ct, Method: method})
+		return false, nil
+	}
+
+	outageStart, err := state.ConnectorSince(ctx)
 	if err != nil {
-		return err
+		log.Infof("read connector since error: %v", err)
 	}
 
-	if innerErr := proxy.AlertService.SendRecoveryAlert("Binance"); innerErr != nil {
-		log.Infof("HandleRequestAlert error: %v", innerErr)
+	gen, err = state.Transition(ctx, gen, ExchangeConnectorTypeBinance, 0)
+	if errors.Is(err, ErrStaleGeneration) {
+		log.Infof("skip recovery to %v: %v", ExchangeConnectorTypeBinance, err)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	cleared, err := state.ResetFailures(ctx, ct)
+	if err != nil {
+		return false, err
 	}
-	return nil
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:        FailoverEventRecovery,
+		From:        nowConnector,
+		To:          ExchangeConnectorTypeBinance,
+		Methods:     []string{method},
+		WindowCount: cleared,
+		Generation:  gen,
+	})
+
+	data := AlertData{
+		From:        nowConnector,
+		To:          ExchangeConnectorTypeBinance,
+		WindowCount: cleared,
+		Methods:     []string{method},
+		Generation:  gen,
+		OutageStart: outageStart,
+	}
+	if !outageStart.IsZero() {
+		data.OutageDuration = time.Since(outageStart)
+	}
+	proxy.sendAlert(ctx, AlertTemplateRecovery, ExchangeConnectorTypeBinance.String(), data)
+	return true, nil
 }
 
 func (proxy ExchangeApiProxyImpl) NowConnect() string {
-	nowConnector, err := proxy.Cache.Get(context.Background(), ExchangeConnectorKey).Result()
-	if err != nil && err != redis.Nil {
+	nowConnector, err := proxy.state().Connector(context.Background())
+	if err != nil {
 		return ExchangeConnectorTypeBinance.String()
 	}
 
-	return nowConnector
+	return nowConnector.String()
 }
 
 func (proxy ExchangeApiProxyImpl) Invoke(fn func(ct ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), con *ExchangeConnectorType, needStandbyConnector bool) (ExchangeApiResponse, error) {
-	cType, connector, err := proxy.getConnector(con, needStandbyConnector)
+	return proxy.InvokeContext(context.Background(), fn, con, needStandbyConnector)
+}
+
+func (proxy ExchangeApiProxyImpl) InvokeContext(ctx context.Context, fn func(ct ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), con *ExchangeConnectorType, needStandbyConnector bool) (res ExchangeApiResponse, err error) {
+	method := invokeMethod(ctx, fn)
+	ctx, span := proxy.tracer().Start(ctx, "failover.Invoke", trace.WithAttributes(
+		attribute.String("exchange.method", method),
+		attribute.Bool("failover.forced", con != nil),
+		attribute.Bool("failover.need_standby_connector", needStandbyConnector),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	cType, connector, err := proxy.getConnector(ctx, con, needStandbyConnector, method)
 	if err != nil {
 		return ExchangeApiResponse{}, fmt.Errorf("getConnector error: %w", err)
 	}
+	span.SetAttributes(attribute.String("exchange.connector", cType.String()))
 
+	_, connSpan := proxy.tracer().Start(ctx, "exchange."+cType.String()+"."+method, trace.WithSpanKind(trace.SpanKindClient))
 	apiResponse, err := fn(cType, connector)
+	if apiResponse.ConnectorType == "" {
+		apiResponse.ConnectorType = cType
+	}
+	connSpan.SetAttributes(
+		attribute.Bool("exchange.success", apiResponse.IsSuccess),
+		attribute.String("exchange.failure_code", apiResponse.FailureCode),
+	)
+	if err != nil {
+		connSpan.RecordError(err)
+	}
+	connSpan.End()
+
+	span.SetAttributes(attribute.String("exchange.failure_code", apiResponse.FailureCode))
 	if apiResponse.IsSuccess {
-		err = proxy.resetFailureCount(cType)
+		recovered, err := proxy.resetFailureCount(ctx, cType, method)
 		if err != nil {
 			return ExchangeApiResponse{}, fmt.Errorf("reset failure count err: %w", err)
 		}
+		span.SetAttributes(attribute.Bool("failover.recovered", recovered))
+		return proxy.normalize(method, apiResponse)
 	}
 	if !apiResponse.IsSuccess {
 		if connector.IsSystemAbnormal(apiResponse.FailureCode) {
-			err = proxy.addFailureCount(cType)
+			switched, err := proxy.addFailureCount(ctx, cType, apiResponse.FailureCode, method)
 			if err != nil {
 				return ExchangeApiResponse{}, fmt.Errorf("add failure count err: %w", err)
 			}
+			span.SetAttributes(attribute.Bool("failover.switched", switched))
 		}
 
+		// fn 回傳的錯誤（例如 *OrderValidationError）保留給呼叫端以 errors.As 判斷
+		if err != nil {
+			return ExchangeApiResponse{}, fmt.Errorf("call api error: ConnectorType=%v: %w", cType, err)
+		}
 		return ExchangeApiResponse{},
 			fmt.Errorf("call api error: IsSuccess=%v, Body=%v, FailureCode=%v, ConnectorType=%v",
 				apiResponse.IsSuccess, string(apiResponse.Body), apiResponse.FailureCode, apiResponse.ConnectorType)
@@ -179,3 +498,44 @@ func (proxy ExchangeApiProxyImpl) Invoke(fn func(ct ExchangeConnectorType, conne
 
 	return apiResponse, nil
 }
+
+// Events 查詢狀態切換事件紀錄。
+func (proxy ExchangeApiProxyImpl) Events(ctx context.Context, filter EventFilter) ([]FailoverEvent, error) {
+	if proxy.Journal == nil {
+		return nil, fmt.Errorf("event journal not configured")
+	}
+	return proxy.Journal.List(ctx, filter)
+}
+
+type invokeMethodKey struct{}
+
+// WithInvokeMethod 指定 InvokeContext 使用的方法名稱，用於 span 名稱、錯誤紀錄與維護期間的能力判斷。
+func WithInvokeMethod(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, invokeMethodKey{}, method)
+}
+
+// invokeMethod 優先使用 WithInvokeMethod 指定的名稱，未指定時由 closure 名稱推算。
+func invokeMethod(ctx context.Context, fn interface{}) string {
+	if method, ok := ctx.Value(invokeMethodKey{}).(string); ok && method != "" {
+		return method
+	}
+	return invokedMethod(fn)
+}
+
+// invokedMethod 由 Invoke 傳入的 closure 名稱推出呼叫端方法名稱，例如
+// "ExchangeApiAdapter.Klines.func1" -> "Klines"；巢狀 closure 無法推算，呼叫端應以 WithInvokeMethod 指定。
+func invokedMethod(fn interface{}) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	parts := strings.Split(name, ".")
+	for len(parts) > 1 && strings.HasPrefix(parts[len(parts)-1], "func") {
+		parts = parts[:len(parts)-1]
+	}
+	return parts[len(parts)-1]
+}
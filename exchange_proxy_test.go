@@ -0,0 +1,185 @@
+package failover
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// tickerConnector 只實作 SymbolPriceTicker，code 不為空時回傳系統異常。
+type tickerConnector struct {
+	ExchangeConnector
+
+	mu    sync.Mutex
+	code  string
+	calls int
+}
+
+func (c *tickerConnector) fail(code string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.code = code
+}
+
+func (c *tickerConnector) called() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *tickerConnector) IsSystemAbnormal(code string) bool { return code != "" }
+
+func (c *tickerConnector) SymbolPriceTicker() (ExchangeApiResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.code != "" {
+		return ExchangeApiResponse{FailureCode: c.code}, nil
+	}
+	return ExchangeApiResponse{IsSuccess: true, Body: []byte(`[]`)}, nil
+}
+
+type failoverTest struct {
+	proxy   ExchangeApiProxyImpl
+	mr      *miniredis.Miniredis
+	binance *tickerConnector
+	okx     *tickerConnector
+	sink    *recordingSink
+}
+
+func newFailoverTest(t *testing.T, opts ...ProxyOption) *failoverTest {
+	t.Helper()
+	mr, client := newTestRedis(t)
+	return newFailoverTestOn(t, mr, client, opts...)
+}
+
+// newFailoverTestOn 在同一個 Redis 上建立另一個 instance。
+func newFailoverTestOn(t *testing.T, mr *miniredis.Miniredis, client redis.UniversalClient, opts ...ProxyOption) *failoverTest {
+	t.Helper()
+	ft := &failoverTest{mr: mr, binance: &tickerConnector{}, okx: &tickerConnector{}, sink: &recordingSink{}}
+	cfg := DefaultConfig
+	cfg.ErrThreshold = 3
+	ft.proxy = NewProxy(append([]ProxyOption{
+		WithConfig(cfg),
+		WithPrimaryConnector(ft.binance),
+		WithStandbyConnector(ft.okx),
+		WithCache(client),
+		WithAlertSink(ft.sink),
+	}, opts...)...)
+	return ft
+}
+
+// ticker 以 needStandbyConnector=true 呼叫，con 不為 nil 時指定交易所。
+func (ft *failoverTest) ticker(con *ExchangeConnectorType) (ExchangeConnectorType, error) {
+	res, err := ft.proxy.InvokeContext(context.Background(), func(ct ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error) {
+		return connector.SymbolPriceTicker()
+	}, con, true)
+	return res.ConnectorType, err
+}
+
+// failures 連續呼叫 n 次，錯誤紀錄的 key 以毫秒區分，每次間隔避免互相覆蓋。
+func (ft *failoverTest) failures(t *testing.T, con *ExchangeConnectorType, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := ft.ticker(con); err == nil {
+			t.Fatalf("call %d: expected failure", i)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func (ft *failoverTest) connector(t *testing.T) (ExchangeConnectorType, int64) {
+	t.Helper()
+	ct, gen, err := ft.proxy.state().ConnectorState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return connectorOrPrimary(ct), gen
+}
+
+func (ft *failoverTest) events(t *testing.T, types ...FailoverEventType) []FailoverEvent {
+	t.Helper()
+	events, err := ft.proxy.Events(context.Background(), EventFilter{Types: types})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func (ft *failoverTest) alerts(name AlertTemplateName) []Alert {
+	var alerts []Alert
+	for _, alert := range ft.sink.alerts {
+		if alert.Type == name {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+func connectorPtr(ct ExchangeConnectorType) *ExchangeConnectorType {
+	return &ct
+}
+
+func TestPrimaryFailuresSwitchToStandby(t *testing.T) {
+	ft := newFailoverTest(t)
+	ft.binance.fail("-1001")
+	ft.failures(t, nil, 3)
+
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeOKX)
+	}
+	events := ft.events(t, FailoverEventSwitch)
+	if len(events) != 1 || events[0].From != ExchangeConnectorTypeBinance {
+		t.Fatalf("switch events = %+v", events)
+	}
+	if got := ft.alerts(AlertTemplateSwitch); len(got) != 1 {
+		t.Fatalf("switch alerts = %+v", got)
+	}
+}
+
+func TestStandbyFailuresDoNotSwitch(t *testing.T) {
+	ctx := context.Background()
+	ft := newFailoverTest(t)
+	state := ft.proxy.state()
+	if _, err := state.Transition(ctx, 0, ExchangeConnectorTypeOKX, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	_, before := ft.connector(t)
+
+	ft.okx.fail("50001")
+	ft.failures(t, nil, 5)
+
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeOKX)
+	}
+	if events := ft.events(t, FailoverEventSwitch); len(events) != 0 {
+		t.Fatalf("switch events = %+v", events)
+	}
+	if got := ft.alerts(AlertTemplateSwitch); len(got) != 0 {
+		t.Fatalf("switch alerts = %+v", got)
+	}
+	// OKX 上的錯誤只延長 LockTime
+	if _, after := ft.connector(t); after != before+5 {
+		t.Fatalf("generation = %v, want %v", after, before+5)
+	}
+	if ft.binance.called() != 0 {
+		t.Fatal("binance called while locked on okx")
+	}
+}
+
+func TestForcedStandbyFailuresDoNotSwitch(t *testing.T) {
+	ft := newFailoverTest(t)
+	ft.okx.fail("50001")
+	ft.failures(t, connectorPtr(ExchangeConnectorTypeOKX), 5)
+
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeBinance)
+	}
+	if events := ft.events(t, FailoverEventSwitch); len(events) != 0 {
+		t.Fatalf("switch events = %+v", events)
+	}
+}
@@ -0,0 +1,377 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrStaleGeneration 表示狀態在讀取後已被其他 writer 改變，本次寫入被拒絕。
+var ErrStaleGeneration = errors.New("failover state generation is stale")
+
+// ErrCrossSlot 表示在 Redis Cluster 上，需要原子寫入的狀態 key 不在同一個 hash slot。
+var ErrCrossSlot = errors.New("failover state keys are not in the same redis cluster slot")
+
+// FailureRecord 為錯誤視窗內的一筆系統異常紀錄，At 取自 key 上的時間戳記。
+type FailureRecord struct {
+	Code   string    `json:"code"`
+	Method string    `json:"method"`
+	At     time.Time `json:"at"`
+}
+
+type FailoverStatus struct {
+	Connector      ExchangeConnectorType                     `json:"connector"`
+	Generation     int64                                     `json:"generation"`
+	Locked         bool                                      `json:"locked"`
+	LockExpiresAt  *time.Time                                `json:"lockExpiresAt,omitempty"`
+	Pin            *ConnectorPin                             `json:"pin,omitempty"`
+	FailureWindows map[ExchangeConnectorType][]FailureRecord `json:"failureWindows"`
+}
+
+// Fingerprint 忽略 LockTime 剩餘時間的變化，用來判斷狀態是否實際改變。
+func (status FailoverStatus) Fingerprint() string {
+	key := fmt.Sprintf("%v|%v|%v", status.Connector, status.Generation, status.Locked)
+	if status.Pin != nil {
+		key += fmt.Sprintf("|pin:%v:%v", status.Pin.Connector, status.Pin.ExpiresAt.UnixMilli())
+	}
+	for _, ct := range []ExchangeConnectorType{ExchangeConnectorTypeBinance, ExchangeConnectorTypeOKX} {
+		records := status.FailureWindows[ct]
+		key += fmt.Sprintf("|%v:%v", ct, len(records))
+		if len(records) > 0 {
+			key += fmt.Sprintf(":%v", records[len(records)-1].At.UnixMilli())
+		}
+	}
+	return key
+}
+
+// StateStore 封裝 proxy 在 Redis 上的狀態讀寫，proxy 與管理介面共用同一份實作。
+type StateStore struct {
+	cache  redis.UniversalClient
+	config Config
+
+	onChange func()
+	// slotErr 在 Redis Cluster 上狀態 key 不共用 hash tag 時不為 nil，原子操作直接回傳
+	slotErr error
+}
+
+func NewStateStore(cache redis.UniversalClient, cfg Config) *StateStore {
+	cfg = cfg.withDefaults()
+	return &StateStore{
+		cache:   cache,
+		config:  cfg,
+		slotErr: checkSlot(cache, cfg.stateKeys()...),
+	}
+}
+
+// checkSlot 在 Redis Cluster 上確認 Lua script 與 MGET 一起操作的 key 共用同一個 hash tag，
+// 否則 Redis 會以 CROSSSLOT 拒絕；單機與 sentinel 不受限制。
+func checkSlot(cache redis.UniversalClient, keys ...string) error {
+	if _, ok := cache.(*redis.ClusterClient); !ok || len(keys) == 0 {
+		return nil
+	}
+	tag := hashTag(keys[0])
+	for _, key := range keys[1:] {
+		if hashTag(key) != tag {
+			return fmt.Errorf("%w: %q and %q, use WithRedisHashTag", ErrCrossSlot, keys[0], key)
+		}
+	}
+	return nil
+}
+
+// hashTag 依 Redis Cluster 的規則取出 key 中決定 slot 的部分。
+func hashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// Connector 回傳 exchange:connector 的原始值，尚未設定時為空字串。
+func (s *StateStore) Connector(ctx context.Context) (ExchangeConnectorType, error) {
+	nowConnector, err := s.cache.Get(ctx, s.config.RedisKeyConnector).Result()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+	return ExchangeConnectorType(nowConnector), nil
+}
+
+// ConnectorState 同時讀取 exchange:connector 與目前的 generation，作為 Transition 的依據。
+func (s *StateStore) ConnectorState(ctx context.Context) (ExchangeConnectorType, int64, error) {
+	if s.slotErr != nil {
+		return "", 0, s.slotErr
+	}
+	values, err := s.cache.MGet(ctx, s.config.RedisKeyConnector, s.config.RedisKeyGeneration).Result()
+	if err != nil {
+		return "", 0, err
+	}
+	nowConnector, _ := values[0].(string)
+	gen, err := parseGeneration(values[1])
+	if err != nil {
+		return "", 0, err
+	}
+	return ExchangeConnectorType(nowConnector), gen, nil
+}
+
+func parseGeneration(v interface{}) (int64, error) {
+	raw, ok := v.(string)
+	if !ok {
+		return 0, nil
+	}
+	gen, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("decode generation: %w", err)
+	}
+	return gen, nil
+}
+
+var transitionScript = redis.NewScript(`
+local gen = tonumber(redis.call("GET", KEYS[2]) or "0")
+if gen ~= tonumber(ARGV[1]) then
+	return {0, gen}
+end
+if redis.call("GET", KEYS[1]) ~= ARGV[2] then
+	redis.call("SET", KEYS[4], ARGV[4])
+end
+redis.call("SET", KEYS[1], ARGV[2])
+local ttl = tonumber(ARGV[3])
+if ttl > 0 then
+	redis.call("SET", KEYS[3], ARGV[4], "PX", ttl)
+elseif ttl < 0 then
+	redis.call("DEL", KEYS[3])
+end
+return {1, redis.call("INCR", KEYS[2])}
+`)
+
+// Transition 在 generation 仍為 expected 時寫入 exchange:connector 並遞增 generation，回傳新的 generation；
+// 已被其他 writer 改變時回傳 ErrStaleGeneration。lockTTL > 0 時同時設定 LockTime，< 0 時清除，0 時不變。
+// 交易所改變時一併記錄切換時間，供 ConnectorSince 計算異常持續時間。
+func (s *StateStore) Transition(ctx context.Context, expected int64, ct ExchangeConnectorType, lockTTL time.Duration) (int64, error) {
+	if s.slotErr != nil {
+		return 0, s.slotErr
+	}
+	lockMs := lockTTL.Milliseconds()
+	if lockTTL > 0 && lockMs == 0 {
+		lockMs = 1
+	} else if lockTTL < 0 {
+		lockMs = -1
+	}
+	result, err := transitionScript.Run(ctx, s.cache,
+		[]string{s.config.RedisKeyConnector, s.config.RedisKeyGeneration, s.config.RedisKeyLockTime, s.config.RedisKeyConnectorSince},
+		expected, ct.String(), lockMs, time.Now().Format(time.RFC3339Nano)).Int64Slice()
+	if err != nil {
+		return 0, err
+	}
+	if result[0] == 0 {
+		return result[1], fmt.Errorf("expected generation %d, got %d: %w", expected, result[1], ErrStaleGeneration)
+	}
+	s.changed(ctx, "connector")
+	return result[1], nil
+}
+
+// ConnectorSince 回傳目前交易所經 Transition 切換過去的時間，沒有紀錄時為零值。
+func (s *StateStore) ConnectorSince(ctx context.Context) (time.Time, error) {
+	raw, err := s.cache.Get(ctx, s.config.RedisKeyConnectorSince).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, raw)
+}
+
+func (s *StateStore) Locked(ctx context.Context) (bool, error) {
+	exist, err := s.cache.Exists(ctx, s.config.RedisKeyLockTime).Result()
+	if err != nil {
+		return false, err
+	}
+	return exist != 0, nil
+}
+
+// LockTTL 回傳 lock 剩餘時間，沒有 lock 時為 0。
+func (s *StateStore) LockTTL(ctx context.Context) (time.Duration, error) {
+	ttl, err := s.cache.PTTL(ctx, s.config.RedisKeyLockTime).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+var casWriteScript = redis.NewScript(`
+local gen = tonumber(redis.call("GET", KEYS[1]) or "0")
+if gen ~= tonumber(ARGV[1]) then
+	return {0, gen}
+end
+local ttl = tonumber(ARGV[3])
+if ttl > 0 then
+	redis.call("SET", KEYS[2], ARGV[2], "PX", ttl)
+else
+	redis.call("DEL", KEYS[2])
+end
+return {1, redis.call("INCR", KEYS[1])}
+`)
+
+// casWrite 在 generation 仍為 expected 時設定（ttl > 0）或刪除（ttl <= 0）key 並遞增 generation，
+// 讓 LockTime 與 pin 的寫入和 Transition 一樣不會覆蓋其他 writer 的決定。
+func (s *StateStore) casWrite(ctx context.Context, expected int64, key, value string, ttl time.Duration, what string) (int64, error) {
+	if s.slotErr != nil {
+		return 0, s.slotErr
+	}
+	ms := ttl.Milliseconds()
+	if ttl > 0 && ms == 0 {
+		ms = 1
+	}
+	result, err := casWriteScript.Run(ctx, s.cache, []string{s.config.RedisKeyGeneration, key}, expected, value, ms).Int64Slice()
+	if err != nil {
+		return 0, err
+	}
+	if result[0] == 0 {
+		return result[1], fmt.Errorf("expected generation %d, got %d: %w", expected, result[1], ErrStaleGeneration)
+	}
+	s.changed(ctx, what)
+	return result[1], nil
+}
+
+// SetLock 在 generation 仍為 expected 時設定 LockTimeTTL 長度的 LockTime，回傳新的 generation。
+func (s *StateStore) SetLock(ctx context.Context, expected int64) (int64, error) {
+	return s.SetLockFor(ctx, expected, s.config.LockTimeTTL)
+}
+
+// SetLockFor 設定指定長度的 LockTime，用於維護期間持續鎖在備援交易所。
+func (s *StateStore) SetLockFor(ctx context.Context, expected int64, ttl time.Duration) (int64, error) {
+	if ttl <= 0 {
+		return 0, fmt.Errorf("lock ttl must be positive")
+	}
+	return s.casWrite(ctx, expected, s.config.RedisKeyLockTime, time.Now().Format(time.RFC3339Nano), ttl, "lock")
+}
+
+func (s *StateStore) ClearLock(ctx context.Context, expected int64) (int64, error) {
+	return s.casWrite(ctx, expected, s.config.RedisKeyLockTime, "", 0, "lock")
+}
+
+func (s *StateStore) failurePattern(ct ExchangeConnectorType) string {
+	return fmt.Sprintf("%v:%v:*", s.config.RedisKeyErrTimeAt, ct)
+}
+
+// AddFailure 寫入一筆錯誤紀錄，回傳目前錯誤視窗內的 key。
+func (s *StateStore) AddFailure(ctx context.Context, ct ExchangeConnectorType, failureCode, method string) ([]string, error) {
+	record, err := json.Marshal(FailureRecord{Code: failureCode, Method: method})
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%v:%v:%v", s.config.RedisKeyErrTimeAt, ct, time.Now().UnixMilli())
+	if err := s.cache.Set(ctx, key, record, s.config.ErrTTL).Err(); err != nil {
+		return nil, err
+	}
+
+	return s.cache.Keys(ctx, s.failurePattern(ct)).Result()
+}
+
+func (s *StateStore) FailureWindow(ctx context.Context, ct ExchangeConnectorType) ([]FailureRecord, error) {
+	keys, err := s.failureKeys(ctx, ct)
+	if err != nil {
+		return nil, err
+	}
+	return s.failureRecords(ctx, keys)
+}
+
+func (s *StateStore) failureKeys(ctx context.Context, ct ExchangeConnectorType) ([]string, error) {
+	return s.cache.Keys(ctx, s.failurePattern(ct)).Result()
+}
+
+func (s *StateStore) failureRecords(ctx context.Context, keys []string) ([]FailureRecord, error) {
+	records := []FailureRecord{}
+	if len(keys) == 0 {
+		return records, nil
+	}
+	values, err := s.cache.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			// key 在 KEYS 與 MGET 之間過期
+			continue
+		}
+		record := FailureRecord{}
+		// 舊版本寫入的值為 "1"，解析失敗時只保留時間
+		_ = json.Unmarshal([]byte(raw), &record)
+		if ms, err := strconv.ParseInt(keys[i][strings.LastIndex(keys[i], ":")+1:], 10, 64); err == nil {
+			record.At = time.UnixMilli(ms)
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].At.Before(records[j].At)
+	})
+	return records, nil
+}
+
+// ResetFailures 清空錯誤視窗，回傳清除的筆數。
+func (s *StateStore) ResetFailures(ctx context.Context, ct ExchangeConnectorType) (int, error) {
+	keys, err := s.cache.Keys(ctx, s.failurePattern(ct)).Result()
+	if err != nil {
+		return 0, err
+	}
+	for _, k := range keys {
+		if err := s.cache.Del(ctx, k).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+func (s *StateStore) Status(ctx context.Context) (FailoverStatus, error) {
+	nowConnector, gen, err := s.ConnectorState(ctx)
+	if err != nil {
+		return FailoverStatus{}, err
+	}
+	if nowConnector == "" {
+		nowConnector = ExchangeConnectorTypeBinance
+	}
+	status := FailoverStatus{
+		Connector:      nowConnector,
+		Generation:     gen,
+		FailureWindows: map[ExchangeConnectorType][]FailureRecord{},
+	}
+
+	if status.Pin, err = s.Pin(ctx); err != nil {
+		return FailoverStatus{}, err
+	}
+	if status.Pin != nil && status.Pin.Expired(time.Now()) {
+		status.Pin = nil
+	}
+
+	ttl, err := s.LockTTL(ctx)
+	if err != nil {
+		return FailoverStatus{}, err
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		status.Locked = true
+		status.LockExpiresAt = &expiresAt
+	}
+
+	for _, ct := range []ExchangeConnectorType{ExchangeConnectorTypeBinance, ExchangeConnectorTypeOKX} {
+		records, err := s.FailureWindow(ctx, ct)
+		if err != nil {
+			return FailoverStatus{}, err
+		}
+		status.FailureWindows[ct] = records
+	}
+	return status, nil
+}
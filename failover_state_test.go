@@ -0,0 +1,146 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestHashTag(t *testing.T) {
+	cases := map[string]string{
+		"exchange:connector":    "exchange:connector",
+		"{exchange}:connector":  "exchange",
+		"a{b}{c}":               "b",
+		"{}:connector":          "{}:connector",
+		"exchange:{connector":   "exchange:{connector",
+		"prefix:{group-1}:lock": "group-1",
+	}
+	for key, want := range cases {
+		if got := hashTag(key); got != want {
+			t.Errorf("hashTag(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestCheckSlot(t *testing.T) {
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"127.0.0.1:0"}})
+	defer cluster.Close()
+	single := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer single.Close()
+
+	if err := checkSlot(cluster, DefaultConfig.stateKeys()...); !errors.Is(err, ErrCrossSlot) {
+		t.Fatalf("default keys on cluster: got %v, want ErrCrossSlot", err)
+	}
+	if err := checkSlot(single, DefaultConfig.stateKeys()...); err != nil {
+		t.Fatalf("default keys on single node: %v", err)
+	}
+
+	cfg := DefaultConfig
+	WithRedisHashTag("exchange")(&cfg)
+	if err := checkSlot(cluster, cfg.stateKeys()...); err != nil {
+		t.Fatalf("hash tagged keys on cluster: %v", err)
+	}
+	if err := NewStateStore(cluster, DefaultConfig).slotErr; !errors.Is(err, ErrCrossSlot) {
+		t.Fatalf("state store on cluster: got %v, want ErrCrossSlot", err)
+	}
+}
+
+func TestStaleGenerationLeavesStateUnchanged(t *testing.T) {
+	ctx := context.Background()
+	writes := map[string]func(s *StateStore, expected int64) (int64, error){
+		"Transition": func(s *StateStore, expected int64) (int64, error) {
+			return s.Transition(ctx, expected, ExchangeConnectorTypeBinance, -1)
+		},
+		"SetLockFor": func(s *StateStore, expected int64) (int64, error) {
+			return s.SetLockFor(ctx, expected, time.Hour)
+		},
+		"ClearLock": func(s *StateStore, expected int64) (int64, error) {
+			return s.ClearLock(ctx, expected)
+		},
+	}
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			mr, client := newTestRedis(t)
+			s := NewStateStore(client, DefaultConfig)
+			gen, err := s.Transition(ctx, 0, ExchangeConnectorTypeOKX, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lock, _ := mr.Get(s.config.RedisKeyLockTime)
+			lockTTL := mr.TTL(s.config.RedisKeyLockTime)
+
+			got, err := write(s, gen-1)
+			if !errors.Is(err, ErrStaleGeneration) {
+				t.Fatalf("err = %v, want ErrStaleGeneration", err)
+			}
+			if got != gen {
+				t.Fatalf("returned generation = %v, want current %v", got, gen)
+			}
+
+			ct, current, err := s.ConnectorState(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ct != ExchangeConnectorTypeOKX || current != gen {
+				t.Fatalf("state = %v/%v, want %v/%v", ct, current, ExchangeConnectorTypeOKX, gen)
+			}
+			if after, _ := mr.Get(s.config.RedisKeyLockTime); after != lock {
+				t.Fatalf("lock = %q, want %q", after, lock)
+			}
+			if after := mr.TTL(s.config.RedisKeyLockTime); after != lockTTL {
+				t.Fatalf("lock ttl = %v, want %v", after, lockTTL)
+			}
+		})
+	}
+}
+
+func TestConcurrentTransitionsHaveOneWinner(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestRedis(t)
+	s := NewStateStore(client, DefaultConfig)
+	_, gen, err := s.ConnectorState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 8
+	errs := make(chan error, writers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		ct := ExchangeConnectorTypeOKX
+		if i%2 == 1 {
+			ct = ExchangeConnectorTypeBinance
+		}
+		wg.Add(1)
+		go func(ct ExchangeConnectorType) {
+			defer wg.Done()
+			<-start
+			_, err := s.Transition(ctx, gen, ct, time.Minute)
+			errs <- err
+		}(ct)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	winners := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			winners++
+		case !errors.Is(err, ErrStaleGeneration):
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if winners != 1 {
+		t.Fatalf("winners = %v, want 1", winners)
+	}
+	if _, current, _ := s.ConnectorState(ctx); current != gen+1 {
+		t.Fatalf("generation = %v, want %v", current, gen+1)
+	}
+}
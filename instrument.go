@@ -0,0 +1,518 @@
+package failover
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+type MarketType string
+
+const (
+	MarketTypeSpot MarketType = "spot"
+	// MarketTypeUSDTPerp 為以報價資產保證金、結算的永續合約（USDT-M）
+	MarketTypeUSDTPerp MarketType = "usdt_perp"
+	// MarketTypeCoinPerp 為以基礎資產保證金、結算的永續合約（COIN-M）
+	MarketTypeCoinPerp MarketType = "coin_perp"
+)
+
+func (m MarketType) String() string {
+	return string(m)
+}
+
+func ParseMarketType(s string) (MarketType, error) {
+	switch MarketType(s) {
+	case MarketTypeSpot, MarketTypeUSDTPerp, MarketTypeCoinPerp:
+		return MarketType(s), nil
+	}
+	return "", fmt.Errorf("unknown market type %q", s)
+}
+
+var ErrUnknownInstrument = errors.New("unknown instrument")
+
+// Instrument 為與交易所無關的交易標的，ID 格式為 BASE/QUOTE:market，例如 BTC/USDT:usdt_perp。
+type Instrument struct {
+	Base   string
+	Quote  string
+	Market MarketType
+}
+
+func (i Instrument) ID() string {
+	return fmt.Sprintf("%v/%v:%v", i.Base, i.Quote, i.Market)
+}
+
+func (i Instrument) String() string {
+	return i.ID()
+}
+
+func ParseInstrumentID(id string) (Instrument, error) {
+	pair, market, ok := strings.Cut(id, ":")
+	if !ok {
+		return Instrument{}, fmt.Errorf("invalid instrument id %q", id)
+	}
+	base, quote, ok := strings.Cut(pair, "/")
+	if !ok || base == "" || quote == "" {
+		return Instrument{}, fmt.Errorf("invalid instrument id %q", id)
+	}
+	m, err := ParseMarketType(market)
+	if err != nil {
+		return Instrument{}, fmt.Errorf("invalid instrument id %q: %w", id, err)
+	}
+	return Instrument{Base: strings.ToUpper(base), Quote: strings.ToUpper(quote), Market: m}, nil
+}
+
+// instrumentNotation 為交易所的預設命名規則，registry 沒有登記的交易對以此轉換。
+type instrumentNotation struct {
+	format func(Instrument) string
+	// parse 的 market 用於無法從名稱判斷市場時（例如 Binance 的現貨與 USDT-M 同名）
+	parse func(native string, market MarketType, quotes []string) (Instrument, bool)
+}
+
+var instrumentNotations = map[ExchangeConnectorType]instrumentNotation{
+	ExchangeConnectorTypeBinance: {format: binanceSymbol, parse: parseBinanceSymbol},
+	ExchangeConnectorTypeOKX:     {format: okxInstID, parse: parseOKXInstID},
+}
+
+// defaultQuoteAssets 用來切開 BTCUSDT 這類沒有分隔符號的名稱，長的優先比對。
+var defaultQuoteAssets = []string{"FDUSD", "USDT", "USDC", "BUSD", "TUSD", "USD", "BTC", "ETH", "BNB", "EUR", "TRY"}
+
+func binanceSymbol(i Instrument) string {
+	if i.Market == MarketTypeCoinPerp {
+		return i.Base + i.Quote + "_PERP"
+	}
+	return i.Base + i.Quote
+}
+
+func parseBinanceSymbol(native string, market MarketType, quotes []string) (Instrument, bool) {
+	native = strings.ToUpper(native)
+	if pair, ok := strings.CutSuffix(native, "_PERP"); ok {
+		native, market = pair, MarketTypeCoinPerp
+	}
+	if market == "" {
+		market = MarketTypeSpot
+	}
+	for _, quote := range quotes {
+		if base, ok := strings.CutSuffix(native, quote); ok && base != "" {
+			return Instrument{Base: base, Quote: quote, Market: market}, true
+		}
+	}
+	return Instrument{}, false
+}
+
+func okxInstID(i Instrument) string {
+	if i.Market == MarketTypeSpot {
+		return i.Base + "-" + i.Quote
+	}
+	return i.Base + "-" + i.Quote + "-SWAP"
+}
+
+func parseOKXInstID(native string, _ MarketType, _ []string) (Instrument, bool) {
+	parts := strings.Split(strings.ToUpper(native), "-")
+	switch {
+	case len(parts) == 2:
+		return Instrument{Base: parts[0], Quote: parts[1], Market: MarketTypeSpot}, true
+	case len(parts) == 3 && parts[2] == "SWAP":
+		market := MarketTypeUSDTPerp
+		if parts[1] == "USD" {
+			market = MarketTypeCoinPerp
+		}
+		return Instrument{Base: parts[0], Quote: parts[1], Market: market}, true
+	}
+	return Instrument{}, false
+}
+
+type instrumentEntry struct {
+	native   string
+	override bool
+}
+
+// InstrumentRegistry 記錄標準交易標的與各交易所原生名稱（BTCUSDT、BTC-USDT、BTC-USDT-SWAP、XBTUSDT…）的對應。
+// 對應來源依優先順序為覆寫檔、交易所的 exchange info、交易所的預設命名規則。
+type InstrumentRegistry struct {
+	mu      sync.RWMutex
+	natives map[ExchangeConnectorType]map[Instrument]instrumentEntry
+	// canonical 以原生名稱與市場查詢，Binance 的現貨與 USDT-M 合約同名
+	canonical map[ExchangeConnectorType]map[string]map[MarketType]Instrument
+	// aliases 為資產別名，例如 XBT 對應 BTC
+	aliases map[string]string
+	quotes  []string
+	// contractValues 為以張為單位的 USDT 本位合約每張的基礎資產數量，例如 OKX BTC-USDT-SWAP 為 0.01
+	contractValues map[ExchangeConnectorType]map[string]decimal.Decimal
+}
+
+// DefaultInstrumentRegistry 為 adapter 與 normalizer 未指定 registry 時使用的 registry。
+var DefaultInstrumentRegistry = NewInstrumentRegistry()
+
+func NewInstrumentRegistry() *InstrumentRegistry {
+	return &InstrumentRegistry{
+		natives:        map[ExchangeConnectorType]map[Instrument]instrumentEntry{},
+		canonical:      map[ExchangeConnectorType]map[string]map[MarketType]Instrument{},
+		aliases:        map[string]string{},
+		quotes:         append([]string{}, defaultQuoteAssets...),
+		contractValues: map[ExchangeConnectorType]map[string]decimal.Decimal{},
+	}
+}
+
+// Register 登記 inst 在 ct 的原生名稱，已由覆寫檔登記的對應不會被取代。
+func (r *InstrumentRegistry) Register(ct ExchangeConnectorType, inst Instrument, native string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.register(ct, inst, native, false)
+}
+
+func (r *InstrumentRegistry) register(ct ExchangeConnectorType, inst Instrument, native string, override bool) {
+	if r.natives[ct] == nil {
+		r.natives[ct] = map[Instrument]instrumentEntry{}
+		r.canonical[ct] = map[string]map[MarketType]Instrument{}
+	}
+	if old, ok := r.natives[ct][inst]; ok {
+		if old.override && !override {
+			return
+		}
+		delete(r.canonical[ct][old.native], inst.Market)
+	}
+	r.natives[ct][inst] = instrumentEntry{native: native, override: override}
+	if r.canonical[ct][native] == nil {
+		r.canonical[ct][native] = map[MarketType]Instrument{}
+	}
+	r.canonical[ct][native][inst.Market] = inst
+	if !r.hasQuote(inst.Quote) {
+		r.quotes = append(r.quotes, inst.Quote)
+		sort.SliceStable(r.quotes, func(i, j int) bool { return len(r.quotes[i]) > len(r.quotes[j]) })
+	}
+}
+
+func (r *InstrumentRegistry) hasQuote(quote string) bool {
+	for _, q := range r.quotes {
+		if q == quote {
+			return true
+		}
+	}
+	return false
+}
+
+// Alias 登記資產別名，解析原生名稱時把 alias 視為 asset。
+func (r *InstrumentRegistry) Alias(alias, asset string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[strings.ToUpper(alias)] = strings.ToUpper(asset)
+}
+
+func (r *InstrumentRegistry) asset(a string) string {
+	if asset, ok := r.aliases[a]; ok {
+		return asset
+	}
+	return a
+}
+
+// Native 回傳 inst 在 ct 的原生名稱。
+func (r *InstrumentRegistry) Native(ct ExchangeConnectorType, inst Instrument) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if entry, ok := r.natives[ct][inst]; ok {
+		return entry.native, nil
+	}
+	if notation, ok := instrumentNotations[ct]; ok {
+		return notation.format(inst), nil
+	}
+	return "", fmt.Errorf("%w: %v on %v", ErrUnknownInstrument, inst, ct)
+}
+
+// Instrument 以 ct 的原生名稱查詢標準交易標的；market 用於名稱無法區分市場時，空字串視為現貨。
+func (r *InstrumentRegistry) Instrument(ct ExchangeConnectorType, native string, market MarketType) (Instrument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	byMarket := r.canonical[ct][native]
+	if inst, ok := byMarket[market]; ok {
+		return inst, nil
+	}
+	// 未指定 market 且原生名稱只對應一個市場時（例如 BTC-USDT-SWAP），直接使用
+	if market == "" && len(byMarket) == 1 {
+		for _, inst := range byMarket {
+			return inst, nil
+		}
+	}
+	if notation, ok := instrumentNotations[ct]; ok {
+		if inst, ok := notation.parse(native, market, r.quotes); ok {
+			inst.Base, inst.Quote = r.asset(inst.Base), r.asset(inst.Quote)
+			return inst, nil
+		}
+	}
+	return Instrument{}, fmt.Errorf("%w: %v on %v", ErrUnknownInstrument, native, ct)
+}
+
+// Symbol 把呼叫端傳入的交易對轉成 ct 的原生名稱。symbol 可為標準 ID（BTC/USDT:usdt_perp）
+// 或 Binance 格式（BTCUSDT），後者以 market 判斷市場；無法解析時原樣回傳。
+func (r *InstrumentRegistry) Symbol(ct ExchangeConnectorType, symbol string, market MarketType) string {
+	if symbol == "" {
+		return symbol
+	}
+	inst, err := r.Resolve(symbol, market)
+	if err != nil {
+		return symbol
+	}
+	native, err := r.Native(ct, inst)
+	if err != nil {
+		return symbol
+	}
+	return native
+}
+
+// Resolve 解析呼叫端傳入的交易對，symbol 可為標準 ID 或 Binance 格式，後者以 market 判斷市場。
+func (r *InstrumentRegistry) Resolve(symbol string, market MarketType) (Instrument, error) {
+	if inst, err := ParseInstrumentID(symbol); err == nil {
+		return inst, nil
+	}
+	return r.Instrument(ExchangeConnectorTypeBinance, symbol, market)
+}
+
+// CanonicalSymbol 把 ct 的原生名稱轉成標準回應使用的 Binance 格式；無法解析時原樣回傳。
+func (r *InstrumentRegistry) CanonicalSymbol(ct ExchangeConnectorType, native string, market MarketType) string {
+	inst, err := r.Instrument(ct, native, market)
+	if err != nil {
+		return native
+	}
+	symbol, err := r.Native(ExchangeConnectorTypeBinance, inst)
+	if err != nil {
+		return native
+	}
+	return symbol
+}
+
+// SetContractValue 登記 ct 的原生合約每張的基礎資產數量。
+func (r *InstrumentRegistry) SetContractValue(ct ExchangeConnectorType, native string, value decimal.Decimal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setContractValue(ct, native, value)
+}
+
+func (r *InstrumentRegistry) setContractValue(ct ExchangeConnectorType, native string, value decimal.Decimal) {
+	if r.contractValues[ct] == nil {
+		r.contractValues[ct] = map[string]decimal.Decimal{}
+	}
+	r.contractValues[ct][native] = value
+}
+
+// ContractValue 回傳 ct 的原生合約每張的基礎資產數量，由 SetContractValue 或 exchange info 登記。
+func (r *InstrumentRegistry) ContractValue(ct ExchangeConnectorType, native string) (decimal.Decimal, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	value, ok := r.contractValues[ct][native]
+	return value, ok
+}
+
+// Instruments 回傳 ct 已登記的交易標的與原生名稱。
+func (r *InstrumentRegistry) Instruments(ct ExchangeConnectorType) map[Instrument]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make(map[Instrument]string, len(r.natives[ct]))
+	for inst, entry := range r.natives[ct] {
+		result[inst] = entry.native
+	}
+	return result
+}
+
+// InstrumentLoader 從交易所 exchange info 的原始回應取出交易標的與原生名稱。
+type InstrumentLoader func(body []byte) (map[Instrument]string, error)
+
+var DefaultInstrumentLoaders = map[ExchangeConnectorType]InstrumentLoader{
+	ExchangeConnectorTypeBinance: loadBinanceInstruments,
+	ExchangeConnectorTypeOKX:     loadOKXInstruments,
+}
+
+// contractValueLoaders 從 exchange info 取出以張為單位的 USDT 本位合約面值，Binance 的數量即為基礎資產，不需登記。
+var contractValueLoaders = map[ExchangeConnectorType]func(body []byte) (map[string]decimal.Decimal, error){
+	ExchangeConnectorTypeOKX: loadOKXContractValues,
+}
+
+// LoadExchangeInfo 以 DefaultInstrumentLoaders 解析 ct 的 exchange info 原始回應並登記，回傳登記的數量。
+func (r *InstrumentRegistry) LoadExchangeInfo(ct ExchangeConnectorType, body []byte) (int, error) {
+	loader, ok := DefaultInstrumentLoaders[ct]
+	if !ok {
+		return 0, fmt.Errorf("no instrument loader for %v", ct)
+	}
+	instruments, err := loader(body)
+	if err != nil {
+		return 0, fmt.Errorf("load %v instruments: %w", ct, err)
+	}
+	var contractValues map[string]decimal.Decimal
+	if load, ok := contractValueLoaders[ct]; ok {
+		if contractValues, err = load(body); err != nil {
+			return 0, fmt.Errorf("load %v contract values: %w", ct, err)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for inst, native := range instruments {
+		inst.Base, inst.Quote = r.asset(inst.Base), r.asset(inst.Quote)
+		r.register(ct, inst, native, false)
+	}
+	for native, value := range contractValues {
+		r.setContractValue(ct, native, value)
+	}
+	return len(instruments), nil
+}
+
+// SpotExchangeInfoConnector 為 connector 可選實作的介面。ExchangeConnector 只有 FuturesExchangeInfo，
+// 實作 SpotExchangeInfo 的 connector 由 LoadConnector 一併載入現貨交易對。
+type SpotExchangeInfoConnector interface {
+	SpotExchangeInfo() (ExchangeApiResponse, error)
+}
+
+// LoadConnector 以 connector 的 FuturesExchangeInfo("") 取得完整的 exchange info 後登記；connector 實作
+// SpotExchangeInfoConnector 時也登記現貨，否則現貨只依覆寫檔與預設命名規則轉換。
+func (r *InstrumentRegistry) LoadConnector(ct ExchangeConnectorType, connector ExchangeConnector) (int, error) {
+	n, err := r.loadResponse(ct, func() (ExchangeApiResponse, error) { return connector.FuturesExchangeInfo("") })
+	if err != nil {
+		return 0, err
+	}
+	if spot, ok := connector.(SpotExchangeInfoConnector); ok {
+		m, err := r.loadResponse(ct, spot.SpotExchangeInfo)
+		if err != nil {
+			return n, fmt.Errorf("spot: %w", err)
+		}
+		n += m
+	}
+	return n, nil
+}
+
+func (r *InstrumentRegistry) loadResponse(ct ExchangeConnectorType, fetch func() (ExchangeApiResponse, error)) (int, error) {
+	res, err := fetch()
+	if err != nil {
+		return 0, err
+	}
+	if !res.IsSuccess {
+		return 0, fmt.Errorf("load %v instruments: failure code %v", ct, res.FailureCode)
+	}
+	return r.LoadExchangeInfo(ct, res.Body)
+}
+
+// loadBinanceInstruments 解析現貨或合約的 exchangeInfo，只登記現貨與永續合約。
+func loadBinanceInstruments(body []byte) (map[Instrument]string, error) {
+	var raw interface{}
+	if err := decodePayload(body, &raw); err != nil {
+		return nil, err
+	}
+	result := map[Instrument]string{}
+	for _, v := range newPayloadReader(raw).list("symbols") {
+		s := newPayloadReader(v)
+		market := MarketTypeSpot
+		switch s.str("contractType") {
+		case "":
+		case "PERPETUAL":
+			market = MarketTypeUSDTPerp
+			if strings.HasSuffix(s.str("symbol"), "_PERP") {
+				market = MarketTypeCoinPerp
+			}
+		default:
+			continue
+		}
+		result[Instrument{Base: s.str("baseAsset"), Quote: s.str("quoteAsset"), Market: market}] = s.str("symbol")
+	}
+	return result, nil
+}
+
+// loadOKXInstruments 解析 /api/v5/public/instruments；永續合約以 uly 判斷基礎與報價資產。
+func loadOKXInstruments(body []byte) (map[Instrument]string, error) {
+	rows, err := okxData(body)
+	if err != nil {
+		return nil, err
+	}
+	result := map[Instrument]string{}
+	for _, v := range rows {
+		s := newPayloadReader(v)
+		switch s.str("instType") {
+		case "SPOT":
+			result[Instrument{Base: s.str("baseCcy"), Quote: s.str("quoteCcy"), Market: MarketTypeSpot}] = s.str("instId")
+		case "SWAP":
+			base, quote, ok := strings.Cut(s.str("uly"), "-")
+			if !ok {
+				continue
+			}
+			market := MarketTypeUSDTPerp
+			if s.str("settleCcy") == base {
+				market = MarketTypeCoinPerp
+			}
+			result[Instrument{Base: base, Quote: quote, Market: market}] = s.str("instId")
+		}
+	}
+	return result, nil
+}
+
+// loadOKXContractValues 取出 USDT 本位（linear）永續合約的 ctVal；幣本位合約與 Binance COIN-M 同樣以張為單位，不需換算。
+func loadOKXContractValues(body []byte) (map[string]decimal.Decimal, error) {
+	rows, err := okxData(body)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]decimal.Decimal{}
+	for _, v := range rows {
+		s := newPayloadReader(v)
+		if !okxLinearSwap(s) {
+			continue
+		}
+		ctVal, err := okxContractValue(s)
+		if err != nil {
+			return nil, err
+		}
+		result[s.str("instId")] = ctVal
+	}
+	return result, nil
+}
+
+// InstrumentOverrides 為覆寫檔的格式，例如：
+//
+//	{
+//	  "aliases": {"XBT": "BTC"},
+//	  "instruments": [
+//	    {"id": "BTC/USDT:usdt_perp", "symbols": {"Binance": "BTCUSDT", "OKX": "BTC-USDT-SWAP", "BitMEX": "XBTUSDT"}}
+//	  ]
+//	}
+type InstrumentOverrides struct {
+	Aliases     map[string]string `json:"aliases"`
+	Instruments []struct {
+		ID      string                           `json:"id"`
+		Symbols map[ExchangeConnectorType]string `json:"symbols"`
+	} `json:"instruments"`
+}
+
+// LoadOverrides 讀取 JSON 覆寫檔；覆寫的對應優先於 exchange info 與預設命名規則。
+func (r *InstrumentRegistry) LoadOverrides(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read instrument overrides: %w", err)
+	}
+	overrides := InstrumentOverrides{}
+	if err := json.Unmarshal(raw, &overrides); err != nil {
+		return fmt.Errorf("parse instrument overrides %v: %w", path, err)
+	}
+	return r.ApplyOverrides(overrides)
+}
+
+func (r *InstrumentRegistry) ApplyOverrides(overrides InstrumentOverrides) error {
+	instruments := make([]Instrument, len(overrides.Instruments))
+	for i, o := range overrides.Instruments {
+		inst, err := ParseInstrumentID(o.ID)
+		if err != nil {
+			return err
+		}
+		instruments[i] = inst
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for alias, asset := range overrides.Aliases {
+		r.aliases[strings.ToUpper(alias)] = strings.ToUpper(asset)
+	}
+	for i, o := range overrides.Instruments {
+		for ct, native := range o.Symbols {
+			r.register(ct, instruments[i], native, true)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package failover
+
+import (
+	"testing"
+)
+
+const exchangeConnectorTypeBitMEX ExchangeConnectorType = "BitMEX"
+
+func TestInstrumentSymbol(t *testing.T) {
+	r := NewInstrumentRegistry()
+	cases := []struct {
+		ct     ExchangeConnectorType
+		symbol string
+		market MarketType
+		want   string
+	}{
+		{ExchangeConnectorTypeOKX, "BTC/USDT:usdt_perp", "", "BTC-USDT-SWAP"},
+		{ExchangeConnectorTypeOKX, "BTC/USD:coin_perp", "", "BTC-USD-SWAP"},
+		{ExchangeConnectorTypeOKX, "btc/usdt:spot", "", "BTC-USDT"},
+		{ExchangeConnectorTypeOKX, "BTCUSDT", MarketTypeSpot, "BTC-USDT"},
+		{ExchangeConnectorTypeOKX, "BTCUSDT", MarketTypeUSDTPerp, "BTC-USDT-SWAP"},
+		{ExchangeConnectorTypeOKX, "BTCUSDT", "", "BTC-USDT"},
+		{ExchangeConnectorTypeOKX, "ETHFDUSD", MarketTypeSpot, "ETH-FDUSD"},
+		{ExchangeConnectorTypeOKX, "BTCUSD_PERP", "", "BTC-USD-SWAP"},
+		{ExchangeConnectorTypeBinance, "BTC/USD:coin_perp", "", "BTCUSD_PERP"},
+		{ExchangeConnectorTypeBinance, "BTC/USDT:usdt_perp", "", "BTCUSDT"},
+		// 無法解析或沒有命名規則時原樣回傳
+		{ExchangeConnectorTypeOKX, "", MarketTypeSpot, ""},
+		{ExchangeConnectorTypeOKX, "UNKNOWN", MarketTypeSpot, "UNKNOWN"},
+		{exchangeConnectorTypeBitMEX, "BTC/USDT:usdt_perp", "", "BTC/USDT:usdt_perp"},
+	}
+	for _, c := range cases {
+		if got := r.Symbol(c.ct, c.symbol, c.market); got != c.want {
+			t.Errorf("Symbol(%v, %q, %q) = %q, want %q", c.ct, c.symbol, c.market, got, c.want)
+		}
+	}
+}
+
+func TestInstrumentCanonicalSymbol(t *testing.T) {
+	r := NewInstrumentRegistry()
+	r.Alias("XBT", "BTC")
+	cases := []struct {
+		ct     ExchangeConnectorType
+		native string
+		market MarketType
+		want   string
+	}{
+		{ExchangeConnectorTypeOKX, "BTC-USDT", "", "BTCUSDT"},
+		{ExchangeConnectorTypeOKX, "BTC-USDT-SWAP", "", "BTCUSDT"},
+		{ExchangeConnectorTypeOKX, "BTC-USD-SWAP", "", "BTCUSD_PERP"},
+		{ExchangeConnectorTypeOKX, "XBT-USDT", "", "BTCUSDT"},
+		{ExchangeConnectorTypeBinance, "BTCUSD_PERP", "", "BTCUSD_PERP"},
+		{ExchangeConnectorTypeBinance, "ETHUSDT", MarketTypeUSDTPerp, "ETHUSDT"},
+		// 無法解析或沒有命名規則時原樣回傳
+		{ExchangeConnectorTypeOKX, "BTC-USDT-240628", "", "BTC-USDT-240628"},
+		{exchangeConnectorTypeBitMEX, "XBTUSDT", "", "XBTUSDT"},
+	}
+	for _, c := range cases {
+		if got := r.CanonicalSymbol(c.ct, c.native, c.market); got != c.want {
+			t.Errorf("CanonicalSymbol(%v, %q, %q) = %q, want %q", c.ct, c.native, c.market, got, c.want)
+		}
+	}
+}
+
+func TestInstrumentOverridesTakePrecedence(t *testing.T) {
+	r := NewInstrumentRegistry()
+	perp := Instrument{Base: "BTC", Quote: "USDT", Market: MarketTypeUSDTPerp}
+	overrides := InstrumentOverrides{Aliases: map[string]string{"xbt": "btc"}}
+	overrides.Instruments = append(overrides.Instruments, struct {
+		ID      string                           `json:"id"`
+		Symbols map[ExchangeConnectorType]string `json:"symbols"`
+	}{ID: perp.ID(), Symbols: map[ExchangeConnectorType]string{
+		ExchangeConnectorTypeOKX:    "BTC-USDT-SWAP-OVERRIDE",
+		exchangeConnectorTypeBitMEX: "XBTUSDT",
+	}})
+	if err := r.ApplyOverrides(overrides); err != nil {
+		t.Fatal(err)
+	}
+
+	// exchange info 與 Register 都不會取代覆寫的對應
+	body := []byte(`{"code":"0","data":[{"instType":"SWAP","instId":"BTC-USDT-SWAP","uly":"BTC-USDT","settleCcy":"USDT","ctType":"linear","ctVal":"0.01"}]}`)
+	if _, err := r.LoadExchangeInfo(ExchangeConnectorTypeOKX, body); err != nil {
+		t.Fatal(err)
+	}
+	r.Register(ExchangeConnectorTypeOKX, perp, "BTC-USDT-SWAP-REGISTER")
+
+	cases := []struct {
+		ct   ExchangeConnectorType
+		want string
+	}{
+		{ExchangeConnectorTypeOKX, "BTC-USDT-SWAP-OVERRIDE"},
+		{exchangeConnectorTypeBitMEX, "XBTUSDT"},
+		{ExchangeConnectorTypeBinance, "BTCUSDT"},
+	}
+	for _, c := range cases {
+		if got, err := r.Native(c.ct, perp); err != nil || got != c.want {
+			t.Errorf("Native(%v) = %q, %v, want %q", c.ct, got, err, c.want)
+		}
+	}
+	if got := r.CanonicalSymbol(exchangeConnectorTypeBitMEX, "XBTUSDT", ""); got != "BTCUSDT" {
+		t.Errorf("CanonicalSymbol(BitMEX, XBTUSDT) = %q, want BTCUSDT", got)
+	}
+	// 別名也套用於預設命名規則
+	if got := r.CanonicalSymbol(ExchangeConnectorTypeOKX, "XBT-USDT", ""); got != "BTCUSDT" {
+		t.Errorf("CanonicalSymbol(OKX, XBT-USDT) = %q, want BTCUSDT", got)
+	}
+
+	overrides.Instruments[0].ID = "BTCUSDT"
+	if err := r.ApplyOverrides(overrides); err == nil {
+		t.Fatal("invalid instrument id: expected error")
+	}
+}
+
+func TestLoadOKXInstruments(t *testing.T) {
+	body := []byte(`{"code":"0","data":[
+		{"instType":"SPOT","instId":"BTC-USDT","baseCcy":"BTC","quoteCcy":"USDT"},
+		{"instType":"SWAP","instId":"BTC-USDT-SWAP","uly":"BTC-USDT","settleCcy":"USDT","ctType":"linear","ctVal":"0.01"},
+		{"instType":"SWAP","instId":"BTC-USD-SWAP","uly":"BTC-USD","settleCcy":"BTC","ctType":"inverse","ctVal":"100"},
+		{"instType":"SWAP","instId":"BROKEN-SWAP","uly":"BROKEN"},
+		{"instType":"FUTURES","instId":"BTC-USDT-240628","uly":"BTC-USDT","settleCcy":"USDT"}
+	]}`)
+	got, err := loadOKXInstruments(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[Instrument]string{
+		{Base: "BTC", Quote: "USDT", Market: MarketTypeSpot}:     "BTC-USDT",
+		{Base: "BTC", Quote: "USDT", Market: MarketTypeUSDTPerp}: "BTC-USDT-SWAP",
+		{Base: "BTC", Quote: "USD", Market: MarketTypeCoinPerp}:  "BTC-USD-SWAP",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("instruments = %v, want %v", got, want)
+	}
+	for inst, native := range want {
+		if got[inst] != native {
+			t.Errorf("%v = %q, want %q", inst, got[inst], native)
+		}
+	}
+
+	if _, err := loadOKXInstruments([]byte(`{"code":"50011","msg":"too many requests","data":[]}`)); err == nil {
+		t.Fatal("okx error response: expected error")
+	}
+}
+
+// exchangeInfoConnector 回傳固定的合約與現貨 exchange info。
+type exchangeInfoConnector struct {
+	ExchangeConnector
+	futures, spot string
+}
+
+func (c exchangeInfoConnector) FuturesExchangeInfo(string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{IsSuccess: true, Body: []byte(c.futures)}, nil
+}
+
+func (c exchangeInfoConnector) SpotExchangeInfo() (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{IsSuccess: true, Body: []byte(c.spot)}, nil
+}
+
+// futuresOnlyConnector 沒有實作 SpotExchangeInfoConnector。
+type futuresOnlyConnector struct {
+	ExchangeConnector
+	futures string
+}
+
+func (c futuresOnlyConnector) FuturesExchangeInfo(string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{IsSuccess: true, Body: []byte(c.futures)}, nil
+}
+
+func TestLoadConnectorIncludesSpot(t *testing.T) {
+	futures := `{"symbols":[{"symbol":"BTCUSDT","baseAsset":"BTC","quoteAsset":"USDT","contractType":"PERPETUAL"},
+		{"symbol":"BTCUSDT_240628","baseAsset":"BTC","quoteAsset":"USDT","contractType":"CURRENT_QUARTER"}]}`
+	spot := `{"symbols":[{"symbol":"1000SATSFDUSD","baseAsset":"1000SATS","quoteAsset":"FDUSD"}]}`
+	satsSpot := Instrument{Base: "1000SATS", Quote: "FDUSD", Market: MarketTypeSpot}
+
+	r := NewInstrumentRegistry()
+	n, err := r.LoadConnector(ExchangeConnectorTypeBinance, exchangeInfoConnector{futures: futures, spot: spot})
+	if err != nil || n != 2 {
+		t.Fatalf("LoadConnector = %v, %v, want 2", n, err)
+	}
+	if got := r.Instruments(ExchangeConnectorTypeBinance)[satsSpot]; got != "1000SATSFDUSD" {
+		t.Fatalf("spot instrument = %q", got)
+	}
+
+	// 只有合約 exchange info 時不登記現貨
+	r = NewInstrumentRegistry()
+	n, err = r.LoadConnector(ExchangeConnectorTypeBinance, futuresOnlyConnector{futures: futures})
+	if err != nil || n != 1 {
+		t.Fatalf("LoadConnector = %v, %v, want 1", n, err)
+	}
+	if _, ok := r.Instruments(ExchangeConnectorTypeBinance)[satsSpot]; ok {
+		t.Fatal("spot instrument registered without spot exchange info")
+	}
+}
@@ -0,0 +1,148 @@
+package failover
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type IntervalUnit byte
+
+const (
+	IntervalMinute IntervalUnit = 'm'
+	IntervalHour   IntervalUnit = 'h'
+	IntervalDay    IntervalUnit = 'd'
+	IntervalWeek   IntervalUnit = 'w'
+	IntervalMonth  IntervalUnit = 'M'
+)
+
+var intervalUnitDurations = map[IntervalUnit]time.Duration{
+	IntervalMinute: time.Minute,
+	IntervalHour:   time.Hour,
+	IntervalDay:    24 * time.Hour,
+	IntervalWeek:   7 * 24 * time.Hour,
+}
+
+// Interval 為 K 線週期，例如 1m、5m、1h、4h、1d、1w、1M（月）。
+type Interval struct {
+	Count int
+	Unit  IntervalUnit
+}
+
+func ParseInterval(s string) (Interval, error) {
+	if len(s) < 2 {
+		return Interval{}, fmt.Errorf("invalid interval %q", s)
+	}
+	unit := IntervalUnit(s[len(s)-1])
+	if _, ok := intervalUnitDurations[unit]; !ok && unit != IntervalMonth {
+		return Interval{}, fmt.Errorf("invalid interval %q: unknown unit", s)
+	}
+	count, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || count <= 0 {
+		return Interval{}, fmt.Errorf("invalid interval %q", s)
+	}
+	return Interval{Count: count, Unit: unit}, nil
+}
+
+func (i Interval) String() string {
+	return fmt.Sprintf("%d%c", i.Count, i.Unit)
+}
+
+// Native 回傳 ct 的週期代碼。OKX 的小時以上週期為大寫，6 小時以上預設以香港時間（UTC+8）切分，
+// 因此使用 utc 結尾的代碼，與 Binance 的 UTC 切分一致。
+func (i Interval) Native(ct ExchangeConnectorType) string {
+	if ct != ExchangeConnectorTypeOKX {
+		return i.String()
+	}
+	switch i.Unit {
+	case IntervalMinute:
+		return i.String()
+	case IntervalHour:
+		if i.Count >= 6 {
+			return fmt.Sprintf("%dHutc", i.Count)
+		}
+		return fmt.Sprintf("%dH", i.Count)
+	case IntervalDay:
+		return fmt.Sprintf("%dDutc", i.Count)
+	case IntervalWeek:
+		return fmt.Sprintf("%dWutc", i.Count)
+	}
+	return fmt.Sprintf("%dMutc", i.Count)
+}
+
+// nativeInterval 把 interval 轉成 ct 的週期代碼，無法解析時原樣回傳。
+func nativeInterval(ct ExchangeConnectorType, interval string) string {
+	i, err := ParseInterval(interval)
+	if err != nil {
+		return interval
+	}
+	return i.Native(ct)
+}
+
+// unixMonday 為 Binance 週 K 的起算點，週 K 從週一 00:00 開始。
+var unixMonday = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// Open 回傳 t 所在 K 線的開始時間。offset 為切分 K 線使用的時區與 UTC 的差，例如 UTC+8 為 8 * time.Hour；
+// 分鐘、小時與日 K 自 Unix epoch 起算，週 K 自週一起算，月 K 以日曆月份切分。
+func (i Interval) Open(t time.Time, offset time.Duration) time.Time {
+	local := t.UTC().Add(offset)
+	var open time.Time
+	if i.Unit == IntervalMonth {
+		months := (local.Year()-1970)*12 + int(local.Month()) - 1
+		months -= mod(months, i.Count)
+		open = time.Date(1970, time.Month(months+1), 1, 0, 0, 0, 0, time.UTC)
+	} else {
+		base := time.Unix(0, 0).UTC()
+		if i.Unit == IntervalWeek {
+			base = unixMonday
+		}
+		size := time.Duration(i.Count) * intervalUnitDurations[i.Unit]
+		elapsed := local.Sub(base)
+		open = base.Add(elapsed - time.Duration(mod(int64(elapsed), int64(size))))
+	}
+	return open.Add(-offset).In(t.Location())
+}
+
+// Close 回傳 t 所在 K 線的收盤時間，即下一根 K 線的開始時間。
+func (i Interval) Close(t time.Time, offset time.Duration) time.Time {
+	open := i.Open(t, offset)
+	if i.Unit == IntervalMonth {
+		local := open.UTC().Add(offset)
+		return local.AddDate(0, i.Count, 0).Add(-offset).In(t.Location())
+	}
+	return open.Add(time.Duration(i.Count) * intervalUnitDurations[i.Unit])
+}
+
+// Remaining 回傳 now 所在 K 線距離收盤的時間。
+func (i Interval) Remaining(now time.Time, offset time.Duration) time.Duration {
+	return i.Close(now, offset).Sub(now)
+}
+
+func mod[T int | int64](a, b T) T {
+	return (a%b + b) % b
+}
+
+// klineLimits 為各交易所單次 K 線請求的筆數上限。
+var klineLimits = map[ExchangeConnectorType]uint64{
+	ExchangeConnectorTypeBinance: 1000,
+	ExchangeConnectorTypeOKX:     300,
+}
+
+// PriceHistoryIntervalLimit 回傳 intervalLetter（m、h、d、w、M）對應的 1 單位週期，以及 ct 單次請求的 K 線筆數上限；
+// ct 未知時回傳所有交易所都能一次取得的筆數。
+func PriceHistoryIntervalLimit(ct ExchangeConnectorType, intervalLetter string) (Interval, uint64, error) {
+	interval, err := ParseInterval("1" + intervalLetter)
+	if err != nil {
+		return Interval{}, 0, err
+	}
+	if limit, ok := klineLimits[ct]; ok {
+		return interval, limit, nil
+	}
+	limit := uint64(0)
+	for _, l := range klineLimits {
+		if limit == 0 || l < limit {
+			limit = l
+		}
+	}
+	return interval, limit, nil
+}
@@ -0,0 +1,103 @@
+package failover
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntervalOpenClose(t *testing.T) {
+	utc8 := 8 * time.Hour
+	cases := []struct {
+		name      string
+		interval  string
+		t         time.Time
+		offset    time.Duration
+		open      time.Time
+		closeTime time.Time
+	}{
+		{
+			name: "month end", interval: "1M",
+			t:    time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC),
+			open: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "month start", interval: "1M",
+			t:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			open: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "year end", interval: "1M",
+			t:    time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC),
+			open: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "month with offset", interval: "1M", offset: utc8,
+			// UTC+8 已是 3 月 1 日
+			t:    time.Date(2024, 2, 29, 16, 0, 0, 0, time.UTC),
+			open: time.Date(2024, 2, 29, 16, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 3, 31, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "quarter", interval: "3M",
+			t:    time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC),
+			open: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday", interval: "1w",
+			t:    time.Date(2024, 3, 3, 23, 59, 59, 0, time.UTC),
+			open: time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "monday", interval: "1w",
+			t:    time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			open: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "week with offset", interval: "1w", offset: utc8,
+			// UTC+8 的週一 00:00 為 UTC 週日 16:00
+			t:    time.Date(2024, 3, 3, 16, 0, 0, 0, time.UTC),
+			open: time.Date(2024, 3, 3, 16, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 3, 10, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "four hours", interval: "4h",
+			t:    time.Date(2024, 3, 4, 7, 59, 0, 0, time.UTC),
+			open: time.Date(2024, 3, 4, 4, 0, 0, 0, time.UTC), closeTime: time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			i, err := ParseInterval(c.interval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := i.Open(c.t, c.offset); !got.Equal(c.open) {
+				t.Fatalf("Open = %v, want %v", got, c.open)
+			}
+			if got := i.Close(c.t, c.offset); !got.Equal(c.closeTime) {
+				t.Fatalf("Close = %v, want %v", got, c.closeTime)
+			}
+			if got := i.Remaining(c.t, c.offset); got != c.closeTime.Sub(c.t) {
+				t.Fatalf("Remaining = %v", got)
+			}
+		})
+	}
+}
+
+func TestPriceHistoryIntervalLimit(t *testing.T) {
+	cases := map[ExchangeConnectorType]uint64{
+		ExchangeConnectorTypeBinance: 1000,
+		ExchangeConnectorTypeOKX:     300,
+		"unknown":                    300,
+	}
+	for ct, want := range cases {
+		i, limit, err := PriceHistoryIntervalLimit(ct, "h")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i.String() != "1h" || limit != want {
+			t.Fatalf("%v: got %v %d, want 1h %d", ct, i, limit, want)
+		}
+	}
+	if _, _, err := PriceHistoryIntervalLimit(ExchangeConnectorTypeBinance, "x"); err == nil {
+		t.Fatal("expected error for unknown unit")
+	}
+}
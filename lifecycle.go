@@ -0,0 +1,64 @@
+package failover
+
+import (
+	"context"
+	"sync"
+)
+
+// lifecycle 管理背景服務的 Start/Stop：Stop 可重複呼叫，Start 未執行時 Stop 直接返回，
+// Stop 之後才呼叫的 Start 也會立即結束。
+type lifecycle struct {
+	mu       sync.Mutex
+	started  bool
+	stopOnce sync.Once
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// begin 回傳 Start 是否應執行；已執行過或已 Stop 時回傳 false。回傳 true 時呼叫端結束前須呼叫 end。
+func (l *lifecycle) begin() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.started {
+		return false
+	}
+	l.started = true
+	select {
+	case <-l.stop:
+		close(l.done)
+		return false
+	default:
+		return true
+	}
+}
+
+func (l *lifecycle) end() {
+	close(l.done)
+}
+
+// shutdown 通知 Start 結束並等待，ctx 到期時回傳 ctx.Err()。
+func (l *lifecycle) shutdown(ctx context.Context) error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	l.mu.Lock()
+	started := l.started
+	l.mu.Unlock()
+	if !started {
+		return nil
+	}
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
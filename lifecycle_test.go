@@ -0,0 +1,63 @@
+package failover
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLifecycleStopWithoutStart(t *testing.T) {
+	l := newLifecycle()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.shutdown(ctx); err != nil {
+		t.Fatalf("shutdown without start: %v", err)
+	}
+	if err := l.shutdown(ctx); err != nil {
+		t.Fatalf("second shutdown: %v", err)
+	}
+	if l.begin() {
+		t.Fatal("begin after shutdown should not run")
+	}
+}
+
+func TestLifecycleStopTwice(t *testing.T) {
+	l := newLifecycle()
+	if !l.begin() {
+		t.Fatal("first begin should run")
+	}
+	if l.begin() {
+		t.Fatal("second begin should not run")
+	}
+	go func() {
+		defer l.end()
+		<-l.stop
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if err := l.shutdown(ctx); err != nil {
+			t.Fatalf("shutdown %d: %v", i, err)
+		}
+	}
+}
+
+func TestServicesStopWithoutStart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	services := map[string]interface{ Stop(context.Context) error }{
+		"StateCache":            &StateCache{run: newLifecycle()},
+		"Coordinator":           &Coordinator{run: newLifecycle()},
+		"ThrottledAlertService": &ThrottledAlertService{run: newLifecycle()},
+		"MaintenanceScheduler":  &MaintenanceScheduler{run: newLifecycle()},
+	}
+	for name, s := range services {
+		if err := s.Stop(ctx); err != nil {
+			t.Fatalf("%v stop without start: %v", name, err)
+		}
+		if err := s.Stop(ctx); err != nil {
+			t.Fatalf("%v second stop: %v", name, err)
+		}
+	}
+}
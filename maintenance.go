@@ -0,0 +1,505 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+type MaintenancePhase string
+
+const (
+	MaintenancePhaseScheduled MaintenancePhase = "scheduled"
+	MaintenancePhaseActive    MaintenancePhase = "active"
+	MaintenancePhaseProbing   MaintenancePhase = "probing"
+	MaintenancePhaseCompleted MaintenancePhase = "completed"
+	MaintenancePhaseCancelled MaintenancePhase = "cancelled"
+)
+
+// MaintenanceWindow 為交易所預告的維護時段。Capabilities 為空時整個交易所切走，
+// 否則只有對應功能的呼叫改走另一個交易所。
+type MaintenanceWindow struct {
+	ID            string                `json:"id"`
+	Connector     ExchangeConnectorType `json:"connector"`
+	Start         time.Time             `json:"start"`
+	End           time.Time             `json:"end"`
+	Capabilities  []Capability          `json:"capabilities,omitempty"`
+	Operator      string                `json:"operator,omitempty"`
+	Reason        string                `json:"reason,omitempty"`
+	Phase         MaintenancePhase      `json:"phase"`
+	ProbeFailures int                   `json:"probeFailures,omitempty"`
+	// Announced 表示已送出登記告警；coordinator 模式下由 follower 登記時交給 leader 送出
+	Announced bool `json:"announced,omitempty"`
+}
+
+func (w MaintenanceWindow) covers(ct ExchangeConnectorType, capability Capability) bool {
+	if w.Connector != ct || (w.Phase != MaintenancePhaseActive && w.Phase != MaintenancePhaseProbing) {
+		return false
+	}
+	if len(w.Capabilities) == 0 {
+		return true
+	}
+	for _, c := range w.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *StateStore) MaintenanceWindows(ctx context.Context) ([]MaintenanceWindow, error) {
+	values, err := s.cache.HGetAll(ctx, s.config.RedisKeyMaintenance).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeMaintenanceWindows(values)
+}
+
+func decodeMaintenanceWindows(values map[string]string) ([]MaintenanceWindow, error) {
+	windows := []MaintenanceWindow{}
+	for id, raw := range values {
+		w := MaintenanceWindow{}
+		if err := json.Unmarshal([]byte(raw), &w); err != nil {
+			return nil, fmt.Errorf("decode maintenance window %v: %w", id, err)
+		}
+		windows = append(windows, w)
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].Start.Before(windows[j].Start)
+	})
+	return windows, nil
+}
+
+func (s *StateStore) MaintenanceWindow(ctx context.Context, id string) (*MaintenanceWindow, error) {
+	raw, err := s.cache.HGet(ctx, s.config.RedisKeyMaintenance, id).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	w := MaintenanceWindow{}
+	if err := json.Unmarshal([]byte(raw), &w); err != nil {
+		return nil, fmt.Errorf("decode maintenance window %v: %w", id, err)
+	}
+	return &w, nil
+}
+
+func (s *StateStore) SaveMaintenanceWindow(ctx context.Context, w MaintenanceWindow) error {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	if err := s.cache.HSet(ctx, s.config.RedisKeyMaintenance, w.ID, data).Err(); err != nil {
+		return err
+	}
+	s.changed(ctx, "maintenance")
+	return nil
+}
+
+func (s *StateStore) DeleteMaintenanceWindow(ctx context.Context, id string) error {
+	if err := s.cache.HDel(ctx, s.config.RedisKeyMaintenance, id).Err(); err != nil {
+		return err
+	}
+	s.changed(ctx, "maintenance")
+	return nil
+}
+
+// tryAcquire 以 SET NX 取得短期互斥，避免多個 instance 同時推進同一個維護時段。
+func (s *StateStore) tryAcquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.cache.SetNX(ctx, key, s.config.InstanceID, ttl).Result()
+}
+
+func (s *StateStore) release(ctx context.Context, key string) error {
+	return s.cache.Del(ctx, key).Err()
+}
+
+func (proxy ExchangeApiProxyImpl) ScheduleMaintenance(ctx context.Context, w MaintenanceWindow) (MaintenanceWindow, error) {
+	if _, err := ParseConnectorType(w.Connector.String()); err != nil {
+		return MaintenanceWindow{}, err
+	}
+	if !w.End.After(w.Start) {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance end %v must be after start %v", w.End, w.Start)
+	}
+	if w.End.Before(time.Now()) {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window already ended at %v", w.End)
+	}
+	for _, c := range w.Capabilities {
+		switch c {
+		case CapabilityMarketData, CapabilitySpotTrading, CapabilityFuturesTrading, CapabilityWallet:
+		default:
+			return MaintenanceWindow{}, fmt.Errorf("unknown capability %q", c)
+		}
+	}
+	if w.ID == "" {
+		w.ID = fmt.Sprintf("mw-%v-%v", w.Connector, time.Now().UnixNano())
+	}
+	w.Phase = MaintenancePhaseScheduled
+	w.ProbeFailures = 0
+	w.Announced = !proxy.Coordinator.following(ctx)
+
+	if err := proxy.state().SaveMaintenanceWindow(ctx, w); err != nil {
+		return MaintenanceWindow{}, err
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:     FailoverEventMaintenanceScheduled,
+		From:     w.Connector,
+		To:       otherConnector(w.Connector),
+		Operator: w.Operator,
+		Reason:   w.Reason,
+	})
+	if w.Announced {
+		proxy.announceMaintenance(ctx, w)
+	}
+	return w, nil
+}
+
+func (proxy ExchangeApiProxyImpl) announceMaintenance(ctx context.Context, w MaintenanceWindow) {
+	proxy.sendAlert(ctx, AlertTemplateMaintenanceScheduled, w.Connector.String(), AlertData{
+		From:         w.Connector,
+		To:           otherConnector(w.Connector),
+		Start:        w.Start,
+		End:          w.End,
+		Capabilities: capabilityNames(w.Capabilities),
+		Operator:     w.Operator,
+		Reason:       w.Reason,
+	})
+}
+
+// CancelMaintenance 取消維護時段。已切走的狀態不會自動切回，交由一般的恢復流程處理。
+func (proxy ExchangeApiProxyImpl) CancelMaintenance(ctx context.Context, id, operator, reason string) error {
+	state := proxy.state()
+	w, err := state.MaintenanceWindow(ctx, id)
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		return fmt.Errorf("maintenance window %v not found", id)
+	}
+	w.Phase = MaintenancePhaseCancelled
+	if err := state.SaveMaintenanceWindow(ctx, *w); err != nil {
+		return err
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:     FailoverEventMaintenanceCancelled,
+		From:     w.Connector,
+		To:       w.Connector,
+		Operator: operator,
+		Reason:   reason,
+	})
+	return nil
+}
+
+func (proxy ExchangeApiProxyImpl) MaintenanceWindows(ctx context.Context) ([]MaintenanceWindow, error) {
+	return proxy.state().MaintenanceWindows(ctx)
+}
+
+// maintenanceReroute 若 ct 正處於涵蓋 method 的維護時段，回傳應改走的交易所。
+func maintenanceReroute(windows []MaintenanceWindow, ct ExchangeConnectorType, method string) (ExchangeConnectorType, bool) {
+	capability := MethodCapability(method)
+	other := otherConnector(ct)
+	for _, w := range windows {
+		if !w.covers(ct, capability) {
+			continue
+		}
+		for _, o := range windows {
+			if o.covers(other, capability) {
+				// 兩邊都在維護，維持原本的選擇
+				return ct, false
+			}
+		}
+		return other, true
+	}
+	return ct, false
+}
+
+// advanceMaintenance 推進單一維護時段的狀態，由 MaintenanceScheduler 定期呼叫。
+// coordinator 模式下只有 leader 推進狀態與送出告警。
+func (proxy ExchangeApiProxyImpl) advanceMaintenance(ctx context.Context, id string, now time.Time) error {
+	if proxy.Coordinator.following(ctx) {
+		return nil
+	}
+	cfg := proxy.config()
+	state := proxy.state()
+
+	lockKey := fmt.Sprintf("%v:%v:lock", cfg.RedisKeyMaintenance, id)
+	acquired, err := state.tryAcquire(ctx, lockKey, cfg.MaintenanceCheckInterval)
+	if err != nil || !acquired {
+		return err
+	}
+	defer func() {
+		if err := state.release(ctx, lockKey); err != nil {
+			log.Infof("release maintenance lock error: %v", err)
+		}
+	}()
+
+	// 取得互斥後重新讀取，避免使用其他 instance 推進前的舊資料
+	current, err := state.MaintenanceWindow(ctx, id)
+	if err != nil || current == nil {
+		return err
+	}
+	w := *current
+
+	if w.Phase == MaintenancePhaseScheduled && !w.Announced {
+		w.Announced = true
+		if err := state.SaveMaintenanceWindow(ctx, w); err != nil {
+			return err
+		}
+		proxy.announceMaintenance(ctx, w)
+	}
+
+	switch {
+	case w.Phase == MaintenancePhaseScheduled && !now.Before(w.Start.Add(-cfg.MaintenanceLeadTime)):
+		return proxy.startMaintenance(ctx, w, now)
+	case w.Phase == MaintenancePhaseActive && !now.Before(w.End):
+		w.Phase = MaintenancePhaseProbing
+		if err := state.SaveMaintenanceWindow(ctx, w); err != nil {
+			return err
+		}
+		return proxy.probeMaintenance(ctx, w)
+	case w.Phase == MaintenancePhaseProbing:
+		return proxy.probeMaintenance(ctx, w)
+	case (w.Phase == MaintenancePhaseCompleted || w.Phase == MaintenancePhaseCancelled) && now.Sub(w.End) > cfg.MaintenanceRetention:
+		return state.DeleteMaintenanceWindow(ctx, w.ID)
+	}
+	return nil
+}
+
+func (proxy ExchangeApiProxyImpl) startMaintenance(ctx context.Context, w MaintenanceWindow, now time.Time) error {
+	state := proxy.state()
+	other := otherConnector(w.Connector)
+
+	// 先切換再標記為 active；generation 衝突時回傳錯誤，由下一次 RunOnce 以最新狀態重試
+	from, gen, err := state.ConnectorState(ctx)
+	if err != nil {
+		return err
+	}
+	if len(w.Capabilities) == 0 {
+		var lockTTL time.Duration
+		if other == ExchangeConnectorTypeOKX {
+			// LockTime 撐到維護結束，期間不會嘗試切回
+			lockTTL = w.End.Sub(now)
+		}
+		if connectorOrPrimary(from) == w.Connector {
+			if gen, err = state.Transition(ctx, gen, other, lockTTL); err != nil {
+				return err
+			}
+		} else if lockTTL > 0 {
+			if gen, err = state.SetLockFor(ctx, gen, lockTTL); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Phase = MaintenancePhaseActive
+	if err := state.SaveMaintenanceWindow(ctx, w); err != nil {
+		return err
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:       FailoverEventMaintenanceStart,
+		From:       w.Connector,
+		To:         other,
+		Generation: gen,
+		Operator:   w.Operator,
+		Reason:     w.Reason,
+	})
+	proxy.sendAlert(ctx, AlertTemplateMaintenanceStart, w.Connector.String(), AlertData{
+		From:         w.Connector,
+		To:           other,
+		Start:        w.Start,
+		End:          w.End,
+		Capabilities: capabilityNames(w.Capabilities),
+		Generation:   gen,
+		Operator:     w.Operator,
+		Reason:       w.Reason,
+	})
+	return nil
+}
+
+func (proxy ExchangeApiProxyImpl) probeMaintenance(ctx context.Context, w MaintenanceWindow) error {
+	state := proxy.state()
+	res, err := proxy.Probe(w.Connector)
+	if err != nil || !res.IsSuccess {
+		w.ProbeFailures++
+		if saveErr := state.SaveMaintenanceWindow(ctx, w); saveErr != nil {
+			return saveErr
+		}
+		if w.Connector == ExchangeConnectorTypeBinance && len(w.Capabilities) == 0 {
+			_, gen, err := state.ConnectorState(ctx)
+			if err != nil {
+				return err
+			}
+			if _, err := proxy.extendLock(ctx, state, gen); err != nil {
+				return err
+			}
+		}
+		if !probeAlertDue(w.ProbeFailures) {
+			return nil
+		}
+		proxy.sendAlert(ctx, AlertTemplateMaintenanceProbeFailed, w.Connector.String(), AlertData{
+			From:          w.Connector,
+			To:            otherConnector(w.Connector),
+			ProbeFailures: w.ProbeFailures,
+			FailureCode:   res.FailureCode,
+			Err:           fmt.Sprint(err),
+		})
+		return nil
+	}
+
+	nowConnector, gen, err := state.ConnectorState(ctx)
+	if err != nil {
+		return err
+	}
+	switched := int64(0)
+	if len(w.Capabilities) == 0 && w.Connector == ExchangeConnectorTypeBinance && nowConnector == ExchangeConnectorTypeOKX {
+		if gen, err = state.Transition(ctx, gen, ExchangeConnectorTypeBinance, -1); err != nil {
+			return err
+		}
+		if _, err := state.ResetFailures(ctx, ExchangeConnectorTypeBinance); err != nil {
+			return err
+		}
+		switched = gen
+	}
+
+	w.Phase = MaintenancePhaseCompleted
+	if err := state.SaveMaintenanceWindow(ctx, w); err != nil {
+		return err
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:       FailoverEventMaintenanceEnd,
+		From:       otherConnector(w.Connector),
+		To:         w.Connector,
+		Generation: gen,
+		Operator:   w.Operator,
+		Reason:     w.Reason,
+	})
+	proxy.sendAlert(ctx, AlertTemplateMaintenanceEnd, w.Connector.String(), AlertData{
+		From:       otherConnector(w.Connector),
+		To:         w.Connector,
+		Generation: switched,
+		Operator:   w.Operator,
+		Reason:     w.Reason,
+	})
+	return nil
+}
+
+// primaryMaintaining 回傳主交易所是否有整個切走、尚未探測成功（active 或 probing）的維護時段。
+func (proxy ExchangeApiProxyImpl) primaryMaintaining(ctx context.Context, state *StateStore) (bool, error) {
+	windows, err := state.MaintenanceWindows(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, w := range windows {
+		if len(w.Capabilities) == 0 && w.covers(ExchangeConnectorTypeBinance, "") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// probeAlertDue 決定第 failures 次探測失敗是否告警：第一次失敗時告警，之後只在 2、4、8… 次時提醒，
+// 避免維護延長時每次檢查都送出告警。
+func probeAlertDue(failures int) bool {
+	return failures > 0 && failures&(failures-1) == 0
+}
+
+func defaultMaintenanceProbe(connector ExchangeConnector) (ExchangeApiResponse, error) {
+	return connector.SymbolPriceTicker()
+}
+
+// Probe 以 MaintenanceProbe（預設 SymbolPriceTicker）直接呼叫 ct 的 connector，不經過 InvokeContext，
+// 不記錄錯誤也不影響切換狀態。
+func (proxy ExchangeApiProxyImpl) Probe(ct ExchangeConnectorType) (ExchangeApiResponse, error) {
+	connector := proxy.connectorOf(ct)
+	if connector == nil {
+		return ExchangeApiResponse{}, fmt.Errorf("no connector configured for %v", ct)
+	}
+	probe := proxy.MaintenanceProbe
+	if probe == nil {
+		probe = defaultMaintenanceProbe
+	}
+	return probe(connector)
+}
+
+func (proxy ExchangeApiProxyImpl) connectorOf(ct ExchangeConnectorType) ExchangeConnector {
+	if ct == ExchangeConnectorTypeOKX {
+		return proxy.OKXImpl
+	}
+	return proxy.BinanceImpl
+}
+
+func otherConnector(ct ExchangeConnectorType) ExchangeConnectorType {
+	if ct == ExchangeConnectorTypeOKX {
+		return ExchangeConnectorTypeBinance
+	}
+	return ExchangeConnectorTypeOKX
+}
+
+// MaintenanceScheduler 定期推進維護時段並檢查 pin 是否到期，實作 Kratos transport.Server，
+// 可直接加入 kratos.Server(...)。
+type MaintenanceScheduler struct {
+	proxy    ExchangeApiProxyImpl
+	interval time.Duration
+	run      *lifecycle
+}
+
+func NewMaintenanceScheduler(proxy ExchangeApiProxyImpl) *MaintenanceScheduler {
+	return &MaintenanceScheduler{
+		proxy:    proxy,
+		interval: proxy.config().MaintenanceCheckInterval,
+		run:      newLifecycle(),
+	}
+}
+
+func (m *MaintenanceScheduler) Start(ctx context.Context) error {
+	if !m.run.begin() {
+		return nil
+	}
+	defer m.run.end()
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-m.run.stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *MaintenanceScheduler) Stop(ctx context.Context) error {
+	return m.run.shutdown(ctx)
+}
+
+func (m *MaintenanceScheduler) RunOnce(ctx context.Context) {
+	// 沒有流量時也要能發現 pin 到期
+	if _, err := m.proxy.activePin(ctx, m.proxy.state()); err != nil {
+		log.Infof("check pin expiry error: %v", err)
+	}
+
+	windows, err := m.proxy.state().MaintenanceWindows(ctx)
+	if err != nil {
+		if err != redis.Nil {
+			log.Infof("load maintenance windows error: %v", err)
+		}
+		return
+	}
+	now := time.Now()
+	for _, w := range windows {
+		if err := m.proxy.advanceMaintenance(ctx, w.ID, now); err != nil {
+			log.Infof("advance maintenance %v error: %v", w.ID, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package failover
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func (ft *failoverTest) maintenancePhase(t *testing.T, id string) MaintenancePhase {
+	t.Helper()
+	w, err := ft.proxy.state().MaintenanceWindow(context.Background(), id)
+	if err != nil || w == nil {
+		t.Fatalf("maintenance window %v: %v, %v", id, w, err)
+	}
+	return w.Phase
+}
+
+func TestMaintenanceFailsBackOnlyAfterProbe(t *testing.T) {
+	ctx := context.Background()
+	ft := newFailoverTest(t)
+	start := time.Now()
+	end := start.Add(time.Hour)
+	w, err := ft.proxy.ScheduleMaintenance(ctx, MaintenanceWindow{
+		Connector: ExchangeConnectorTypeBinance,
+		Start:     start,
+		End:       end,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 開始：切到 OKX 並鎖到維護結束
+	if err := ft.proxy.advanceMaintenance(ctx, w.ID, start); err != nil {
+		t.Fatal(err)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeOKX)
+	}
+	if phase := ft.maintenancePhase(t, w.ID); phase != MaintenancePhaseActive {
+		t.Fatalf("phase = %v, want %v", phase, MaintenancePhaseActive)
+	}
+
+	// LockTime 已過期但尚未探測：成功的呼叫不會切回
+	ft.mr.FastForward(2 * time.Hour)
+	if ct, err := ft.ticker(nil); err != nil || ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("ticker = %v, %v, want %v", ct, err, ExchangeConnectorTypeOKX)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("failed back before probe: connector = %v", ct)
+	}
+
+	// 探測失敗：維持 OKX
+	ft.binance.fail("-1001")
+	if err := ft.proxy.advanceMaintenance(ctx, w.ID, end.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if phase := ft.maintenancePhase(t, w.ID); phase != MaintenancePhaseProbing {
+		t.Fatalf("phase = %v, want %v", phase, MaintenancePhaseProbing)
+	}
+	ft.mr.FastForward(time.Hour)
+	if _, err := ft.ticker(nil); err != nil {
+		t.Fatal(err)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("failed back after failed probe: connector = %v", ct)
+	}
+	if got := ft.events(t, FailoverEventRecovery); len(got) != 0 {
+		t.Fatalf("recovery events = %+v", got)
+	}
+
+	// 探測成功才切回
+	ft.binance.fail("")
+	if err := ft.proxy.advanceMaintenance(ctx, w.ID, end.Add(2*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeBinance)
+	}
+	if phase := ft.maintenancePhase(t, w.ID); phase != MaintenancePhaseCompleted {
+		t.Fatalf("phase = %v, want %v", phase, MaintenancePhaseCompleted)
+	}
+	if got := ft.alerts(AlertTemplateMaintenanceEnd); len(got) != 1 {
+		t.Fatalf("maintenance end alerts = %+v", got)
+	}
+}
+
+func TestCapabilityMaintenanceDoesNotHoldFailback(t *testing.T) {
+	ctx := context.Background()
+	ft := newFailoverTest(t)
+	start := time.Now()
+	w, err := ft.proxy.ScheduleMaintenance(ctx, MaintenanceWindow{
+		Connector:    ExchangeConnectorTypeBinance,
+		Start:        start,
+		End:          start.Add(time.Hour),
+		Capabilities: []Capability{CapabilityFuturesTrading},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ft.proxy.advanceMaintenance(ctx, w.ID, start); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有部分功能維護時，自動切換後仍照一般流程切回
+	state := ft.proxy.state()
+	_, gen := ft.connector(t)
+	if _, err := state.Transition(ctx, gen, ExchangeConnectorTypeOKX, -1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ft.ticker(nil); err != nil {
+		t.Fatal(err)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeBinance)
+	}
+}
+
+func TestProbeDoesNotRecordFailures(t *testing.T) {
+	ctx := context.Background()
+	ft := newFailoverTest(t)
+	ft.binance.fail("-1001")
+	for i := 0; i < 5; i++ {
+		if res, err := ft.proxy.Probe(ExchangeConnectorTypeBinance); err != nil || res.IsSuccess {
+			t.Fatalf("probe = %+v, %v, want failure response", res, err)
+		}
+	}
+	records, err := ft.proxy.state().FailureWindow(ctx, ExchangeConnectorTypeBinance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("failure window = %v records, want 0", len(records))
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeBinance)
+	}
+
+	if _, err := NewProxy(WithMaintenanceProbe(defaultMaintenanceProbe)).Probe(ExchangeConnectorTypeOKX); err == nil {
+		t.Fatal("probe without connector: expected error")
+	}
+}
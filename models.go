@@ -0,0 +1,193 @@
+package failover
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 以下為 ExchangeApiV2 回傳的型別，欄位與交易所無關；ConnectorType 為實際處理請求的交易所。
+
+type Kline struct {
+	OpenTime    time.Time       `json:"openTime"`
+	Open        decimal.Decimal `json:"open"`
+	High        decimal.Decimal `json:"high"`
+	Low         decimal.Decimal `json:"low"`
+	Close       decimal.Decimal `json:"close"`
+	Volume      decimal.Decimal `json:"volume"`
+	CloseTime   time.Time       `json:"closeTime"`
+	QuoteVolume decimal.Decimal `json:"quoteVolume"`
+	Trades      int64           `json:"trades"`
+}
+
+type OrderResult struct {
+	ConnectorType ExchangeConnectorType `json:"connectorType"`
+	Symbol        string                `json:"symbol"`
+	OrderID       string                `json:"orderId"`
+	ClientOrderID string                `json:"clientOrderId,omitempty"`
+	Side          string                `json:"side"`
+	Type          string                `json:"type"`
+	Status        string                `json:"status"`
+	Price         decimal.Decimal       `json:"price"`
+	AvgPrice      decimal.Decimal       `json:"avgPrice"`
+	OrigQty       decimal.Decimal       `json:"origQty"`
+	ExecutedQty   decimal.Decimal       `json:"executedQty"`
+	// CumQuote 為已成交的報價資產數量
+	CumQuote   decimal.Decimal `json:"cumQuote"`
+	UpdateTime time.Time       `json:"updateTime"`
+}
+
+type Balance struct {
+	Asset       string          `json:"asset"`
+	Free        decimal.Decimal `json:"free"`
+	Locked      decimal.Decimal `json:"locked"`
+	Freeze      decimal.Decimal `json:"freeze"`
+	Withdrawing decimal.Decimal `json:"withdrawing"`
+}
+
+type Position struct {
+	Symbol           string          `json:"symbol"`
+	PositionSide     string          `json:"positionSide"`
+	PositionAmt      decimal.Decimal `json:"positionAmt"`
+	EntryPrice       decimal.Decimal `json:"entryPrice"`
+	MarkPrice        decimal.Decimal `json:"markPrice"`
+	UnrealizedProfit decimal.Decimal `json:"unrealizedProfit"`
+	LiquidationPrice decimal.Decimal `json:"liquidationPrice"`
+	Leverage         decimal.Decimal `json:"leverage"`
+	MarginType       string          `json:"marginType"`
+	UpdateTime       time.Time       `json:"updateTime"`
+}
+
+type FuturesAsset struct {
+	Asset            string          `json:"asset"`
+	WalletBalance    decimal.Decimal `json:"walletBalance"`
+	UnrealizedProfit decimal.Decimal `json:"unrealizedProfit"`
+	MarginBalance    decimal.Decimal `json:"marginBalance"`
+	AvailableBalance decimal.Decimal `json:"availableBalance"`
+}
+
+type FuturesAccount struct {
+	ConnectorType         ExchangeConnectorType `json:"connectorType"`
+	TotalWalletBalance    decimal.Decimal       `json:"totalWalletBalance"`
+	TotalUnrealizedProfit decimal.Decimal       `json:"totalUnrealizedProfit"`
+	TotalMarginBalance    decimal.Decimal       `json:"totalMarginBalance"`
+	AvailableBalance      decimal.Decimal       `json:"availableBalance"`
+	MaxWithdrawAmount     decimal.Decimal       `json:"maxWithdrawAmount"`
+	Assets                []FuturesAsset        `json:"assets"`
+	Positions             []Position            `json:"positions"`
+}
+
+// Bill 為合約帳戶的資金流水（手續費、資金費、已實現損益、劃轉等）。
+type Bill struct {
+	Symbol  string          `json:"symbol"`
+	Type    string          `json:"type"`
+	Amount  decimal.Decimal `json:"amount"`
+	Asset   string          `json:"asset"`
+	Info    string          `json:"info,omitempty"`
+	TranID  string          `json:"tranId"`
+	TradeID string          `json:"tradeId,omitempty"`
+	Time    time.Time       `json:"time"`
+}
+
+type Trade struct {
+	Symbol          string          `json:"symbol"`
+	ID              string          `json:"id"`
+	OrderID         string          `json:"orderId"`
+	Side            string          `json:"side"`
+	Price           decimal.Decimal `json:"price"`
+	Qty             decimal.Decimal `json:"qty"`
+	QuoteQty        decimal.Decimal `json:"quoteQty"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commissionAsset"`
+	RealizedPnl     decimal.Decimal `json:"realizedPnl"`
+	IsBuyer         bool            `json:"isBuyer"`
+	IsMaker         bool            `json:"isMaker"`
+	Time            time.Time       `json:"time"`
+}
+
+type Commission struct {
+	Symbol          string          `json:"symbol"`
+	MakerCommission decimal.Decimal `json:"makerCommission"`
+	TakerCommission decimal.Decimal `json:"takerCommission"`
+}
+
+type Transfer struct {
+	TranID string          `json:"tranId"`
+	Asset  string          `json:"asset"`
+	Amount decimal.Decimal `json:"amount"`
+	Type   string          `json:"type"`
+	Status string          `json:"status"`
+	Time   time.Time       `json:"time"`
+}
+
+type Withdrawal struct {
+	ID           string          `json:"id"`
+	Coin         string          `json:"coin"`
+	Network      string          `json:"network"`
+	Address      string          `json:"address"`
+	Amount       decimal.Decimal `json:"amount"`
+	Fee          decimal.Decimal `json:"transactionFee"`
+	TxID         string          `json:"txId"`
+	Status       int             `json:"status"`
+	ApplyTime    time.Time       `json:"applyTime"`
+	CompleteTime time.Time       `json:"completeTime"`
+}
+
+type CoinNetwork struct {
+	Network                 string          `json:"network"`
+	Name                    string          `json:"name"`
+	IsDefault               bool            `json:"isDefault"`
+	DepositEnable           bool            `json:"depositEnable"`
+	WithdrawEnable          bool            `json:"withdrawEnable"`
+	WithdrawFee             decimal.Decimal `json:"withdrawFee"`
+	WithdrawMin             decimal.Decimal `json:"withdrawMin"`
+	WithdrawMax             decimal.Decimal `json:"withdrawMax"`
+	WithdrawIntegerMultiple decimal.Decimal `json:"withdrawIntegerMultiple"`
+}
+
+type CoinConfig struct {
+	Coin     string          `json:"coin"`
+	Name     string          `json:"name"`
+	Free     decimal.Decimal `json:"free"`
+	Locked   decimal.Decimal `json:"locked"`
+	Networks []CoinNetwork   `json:"networkList"`
+}
+
+type Ticker struct {
+	Symbol string          `json:"symbol"`
+	Price  decimal.Decimal `json:"price"`
+	Time   time.Time       `json:"time"`
+}
+
+// SymbolFilter 為交易對的下單限制，依 FilterType 使用不同欄位（PRICE_FILTER、LOT_SIZE、MIN_NOTIONAL…）。
+type SymbolFilter struct {
+	FilterType string          `json:"filterType"`
+	MinPrice   decimal.Decimal `json:"minPrice"`
+	MaxPrice   decimal.Decimal `json:"maxPrice"`
+	TickSize   decimal.Decimal `json:"tickSize"`
+	MinQty     decimal.Decimal `json:"minQty"`
+	MaxQty     decimal.Decimal `json:"maxQty"`
+	StepSize   decimal.Decimal `json:"stepSize"`
+	Notional   decimal.Decimal `json:"notional"`
+}
+
+type SymbolInfo struct {
+	Symbol            string         `json:"symbol"`
+	BaseAsset         string         `json:"baseAsset"`
+	QuoteAsset        string         `json:"quoteAsset"`
+	Status            string         `json:"status"`
+	ContractType      string         `json:"contractType,omitempty"`
+	PricePrecision    int32          `json:"pricePrecision"`
+	QuantityPrecision int32          `json:"quantityPrecision"`
+	Filters           []SymbolFilter `json:"filters"`
+}
+
+// Filter 回傳指定類型的 filter。
+func (s SymbolInfo) Filter(filterType string) (SymbolFilter, bool) {
+	for _, f := range s.Filters {
+		if f.FilterType == filterType {
+			return f, true
+		}
+	}
+	return SymbolFilter{}, false
+}
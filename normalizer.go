@@ -0,0 +1,116 @@
+package failover
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/shopspring/decimal"
+)
+
+// ResponseNormalizer 把交易所的原始回應轉成標準格式。標準格式即 Binance API 的回應（K 線可為 Binance 的陣列
+// 或 openTime、open、high… 的物件），ExchangeApiAdapter 與 ExchangeApiV2Adapter 只解析標準格式，因此不論由哪個交易所處理，結果都相同。
+// method 為 ExchangeConnector 的方法名稱，未處理的方法應原樣回傳 body。
+type ResponseNormalizer interface {
+	Normalize(method string, body []byte) ([]byte, error)
+}
+
+type ResponseNormalizerFunc func(method string, body []byte) ([]byte, error)
+
+func (f ResponseNormalizerFunc) Normalize(method string, body []byte) ([]byte, error) {
+	return f(method, body)
+}
+
+// DefaultResponseNormalizers 為各交易所預設的 normalizer；Binance 的回應即為標準格式，不需轉換。
+var DefaultResponseNormalizers = map[ExchangeConnectorType]ResponseNormalizer{
+	ExchangeConnectorTypeOKX: OKXNormalizer{},
+}
+
+// ErrExchangeRejected 表示交易所以錯誤碼拒絕請求（例如 OKX 的 code、sCode 不為 0），操作未生效。
+var ErrExchangeRejected = errors.New("exchange rejected request")
+
+// orderMethods 為會改變交易所狀態的方法；回應成功時操作已生效，標準化失敗不應讓呼叫端誤以為失敗而重送。
+var orderMethods = map[string]bool{
+	"FutureTrade":            true,
+	"SpotTrade":              true,
+	"SpotCancelOrder":        true,
+	"SpotCancelAllOrders":    true,
+	"SpotAmendOrder":         true,
+	"FuturesCancelOrder":     true,
+	"FuturesCancelAllOrders": true,
+	"FuturesAmendOrder":      true,
+	"FuturesTransfer":        true,
+	"SpotWithdraw":           true,
+}
+
+// normalizedKlines 把 K 線轉成標準格式的物件，數值以字串表示，時間為毫秒 timestamp。
+func normalizedKlines(klines []Kline) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(klines))
+	for _, k := range klines {
+		result = append(result, map[string]interface{}{
+			"openTime":    normalizedMillis(k.OpenTime),
+			"open":        k.Open.String(),
+			"high":        k.High.String(),
+			"low":         k.Low.String(),
+			"close":       k.Close.String(),
+			"volume":      k.Volume.String(),
+			"closeTime":   normalizedMillis(k.CloseTime),
+			"quoteVolume": k.QuoteVolume.String(),
+			"trades":      k.Trades,
+		})
+	}
+	return result
+}
+
+// responseNormalizer 優先使用 normalizers 的設定（值為 nil 表示 connector 已回傳標準格式），其次為預設值。
+func responseNormalizer(normalizers map[ExchangeConnectorType]ResponseNormalizer, ct ExchangeConnectorType) ResponseNormalizer {
+	if n, ok := normalizers[ct]; ok {
+		return n
+	}
+	return DefaultResponseNormalizers[ct]
+}
+
+func normalizeResponse(normalizers map[ExchangeConnectorType]ResponseNormalizer, method string, res ExchangeApiResponse) (ExchangeApiResponse, error) {
+	n := responseNormalizer(normalizers, res.ConnectorType)
+	if n == nil {
+		return res, nil
+	}
+	body, err := n.Normalize(method, res.Body)
+	if err != nil {
+		return ExchangeApiResponse{}, fmt.Errorf("normalize %v response from %v: %w", method, res.ConnectorType, err)
+	}
+	res.Body = body
+	return res, nil
+}
+
+// normalize 轉換成功的回應；orderMethods 的回應格式無法解析時記錄錯誤並回傳原始回應，
+// 交易所拒絕（ErrExchangeRejected）時操作未生效，照常回傳錯誤。
+func (proxy ExchangeApiProxyImpl) normalize(method string, res ExchangeApiResponse) (ExchangeApiResponse, error) {
+	normalized, err := normalizeResponse(proxy.Normalizers, method, res)
+	if err != nil && orderMethods[method] && !errors.Is(err, ErrExchangeRejected) {
+		log.Errorf("%v, return raw response", err)
+		return res, nil
+	}
+	return normalized, err
+}
+
+// normalizedMillis 回傳標準格式使用的毫秒 timestamp，零值時間為 0。
+func normalizedMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// decimalPlaces 回傳 tick size 之類的數值的小數位數，例如 "0.010" 為 2。
+func decimalPlaces(d decimal.Decimal) int32 {
+	places := -d.Exponent()
+	for places > 0 && d.Equal(d.Truncate(places-1)) {
+		places--
+	}
+	if places < 0 {
+		return 0
+	}
+	return places
+}
@@ -0,0 +1,558 @@
+package failover
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OKXNormalizer 把 OKX v5 API 的回應（{"code":"0","data":[...]}，或 connector 已取出的 data）轉成標準格式。
+type OKXNormalizer struct {
+	// Instruments 用來把 instId 轉成標準格式的交易對，未設定時使用 DefaultInstrumentRegistry
+	Instruments *InstrumentRegistry
+}
+
+var okxNormalizers = map[string]func(n OKXNormalizer, rows []interface{}) (interface{}, error){
+	"Klines":                            okxKlines,
+	"FutureTrade":                       okxFirst(okxPlacedOrder),
+	"SpotTrade":                         okxFirst(okxPlacedOrder),
+	"GetUSDTMFuturesPrecision":          okxFirst(okxPrecision),
+	"GetSpotPrecision":                  okxFirst(okxPrecision),
+	"FuturesExchangeInfo":               okxExchangeInfo,
+	"GetFuturesBills":                   okxEach(okxBill),
+	"FuturesTransfer":                   okxFirst(okxTransferID),
+	"FuturesAccount":                    okxFirst(okxFuturesAccount),
+	"FuturesAccountPositionRisk":        okxEach(okxPosition),
+	"SpotAllOrders":                     okxEach(okxOrder),
+	"SpotAccountTradeList":              okxEach(okxFill),
+	"PerpAccountTradeList":              okxEach(okxFill),
+	"GetCommission":                     okxEach(okxCommission),
+	"SpotAccountInternalTransferRecord": okxEach(okxTransfer),
+	"SpotWithdraw":                      okxFirst(okxWithdrawID),
+	"SpotWithdrawRecord":                okxEach(okxWithdrawal),
+	"CapitalCoinGetAll":                 okxCurrencies,
+	"SpotAssets":                        okxBalances,
+	"NewestQuoteTicker":                 okxFirst(okxTicker),
+	"SymbolPriceTicker":                 okxEach(okxTicker),
+	"SpotCancelOrder":                   okxFirst(okxCanceledOrder),
+	"SpotCancelAllOrders":               okxEach(okxCanceledOrder),
+	"SpotQueryOrder":                    okxFirst(okxOrder),
+	"SpotOpenOrders":                    okxEach(okxOrder),
+	"SpotAmendOrder":                    okxFirst(okxAmendedOrder),
+	"FuturesCancelOrder":                okxFirst(okxCanceledOrder),
+	"FuturesCancelAllOrders":            okxEach(okxCanceledOrder),
+	"FuturesQueryOrder":                 okxFirst(okxOrder),
+	"FuturesOpenOrders":                 okxEach(okxOrder),
+	"FuturesAmendOrder":                 okxFirst(okxAmendedOrder),
+}
+
+func (n OKXNormalizer) Normalize(method string, body []byte) ([]byte, error) {
+	fn, ok := okxNormalizers[method]
+	if !ok {
+		return body, nil
+	}
+	rows, err := okxData(body)
+	if err != nil {
+		return nil, err
+	}
+	v, err := fn(n, rows)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// okxData 取出 OKX 回應的 data；code 不為 "0" 時回傳錯誤。
+func okxData(body []byte) ([]interface{}, error) {
+	var raw interface{}
+	if err := decodePayload(body, &raw); err != nil {
+		return nil, err
+	}
+	switch v := raw.(type) {
+	case []interface{}:
+		return v, nil
+	case map[string]interface{}:
+		if _, ok := v["data"]; !ok {
+			return []interface{}{v}, nil
+		}
+		r := newPayloadReader(v)
+		if code := r.str("code"); code != "" && code != "0" {
+			// 下單類 API 的 code 只表示整批失敗，原因在逐筆的 sCode
+			if rows := r.list("data"); len(rows) > 0 {
+				if err := okxOrderError(newPayloadReader(rows[0])); err != nil {
+					return nil, err
+				}
+			}
+			return nil, fmt.Errorf("okx error %v: %v: %w", code, r.str("msg"), ErrExchangeRejected)
+		}
+		return r.list("data"), nil
+	}
+	return nil, fmt.Errorf("unexpected okx payload")
+}
+
+func okxFirst(fn func(n OKXNormalizer, r *payloadReader) (interface{}, error)) func(n OKXNormalizer, rows []interface{}) (interface{}, error) {
+	return func(n OKXNormalizer, rows []interface{}) (interface{}, error) {
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("okx response has no data")
+		}
+		return fn(n, newPayloadReader(rows[0]))
+	}
+}
+
+func okxEach(fn func(n OKXNormalizer, r *payloadReader) (interface{}, error)) func(n OKXNormalizer, rows []interface{}) (interface{}, error) {
+	return func(n OKXNormalizer, rows []interface{}) (interface{}, error) {
+		return convertList(rows, func(v interface{}) (interface{}, error) {
+			return fn(n, newPayloadReader(v))
+		})
+	}
+}
+
+func (n OKXNormalizer) instruments() *InstrumentRegistry {
+	if n.Instruments == nil {
+		return DefaultInstrumentRegistry
+	}
+	return n.Instruments
+}
+
+// symbol 把 OKX 的 instId（BTC-USDT、BTC-USDT-SWAP）轉成標準格式使用的 Binance 交易對。
+func (n OKXNormalizer) symbol(instID string) string {
+	return n.instruments().CanonicalSymbol(ExchangeConnectorTypeOKX, instID, "")
+}
+
+// okxLinearSwap 回傳 instruments 回應的一列是否為 USDT 本位（linear）永續合約。OKX 合約的 sz、lotSz、minSz
+// 以張為單位，標準格式（Binance USDT-M）以基礎資產為單位；幣本位（inverse）合約與 Binance COIN-M 同樣以張為單位，不需換算。
+func okxLinearSwap(r *payloadReader) bool {
+	return r.str("instType") == "SWAP" && r.str("ctType") != "inverse"
+}
+
+// okxContractValue 回傳 instruments 回應的一列每張合約的基礎資產數量（ctVal），不需換算時為 1。
+func okxContractValue(r *payloadReader) (decimal.Decimal, error) {
+	if !okxLinearSwap(r) {
+		return decimal.NewFromInt(1), nil
+	}
+	ctVal := r.decimal("ctVal")
+	if r.err != nil {
+		return decimal.Zero, r.err
+	}
+	if !ctVal.IsPositive() {
+		return decimal.Zero, fmt.Errorf("okx %v: missing ctVal, cannot convert contracts to base quantity", r.str("instId"))
+	}
+	return ctVal, nil
+}
+
+// contractValue 回傳 instID 每張合約的基礎資產數量，取自 registry 由 OKX instruments 登記的 ctVal。
+// USDT 本位合約尚未登記時回傳錯誤，不把張數當成基礎資產數量回傳。
+func (n OKXNormalizer) contractValue(instID string) (decimal.Decimal, error) {
+	instruments := n.instruments()
+	inst, err := instruments.Instrument(ExchangeConnectorTypeOKX, instID, "")
+	if err != nil || inst.Market != MarketTypeUSDTPerp {
+		return decimal.NewFromInt(1), nil
+	}
+	if ctVal, ok := instruments.ContractValue(ExchangeConnectorTypeOKX, instID); ok {
+		return ctVal, nil
+	}
+	return decimal.Zero, fmt.Errorf("%w: contract value of %v on %v, load OKX instruments with InstrumentRegistry.LoadConnector",
+		ErrUnknownInstrument, instID, ExchangeConnectorTypeOKX)
+}
+
+// okxQuantity 把 key 的張數乘上 ctVal 換算成基礎資產數量；ctVal 為 1 或欄位為空時保留原始字串。
+func okxQuantity(r *payloadReader, key string, ctVal decimal.Decimal) string {
+	raw := r.str(key)
+	if raw == "" || ctVal.Equal(decimal.NewFromInt(1)) {
+		return raw
+	}
+	return r.decimal(key).Mul(ctVal).String()
+}
+
+var okxOrderStatus = map[string]string{
+	"live":             "NEW",
+	"partially_filled": "PARTIALLY_FILLED",
+	"filled":           "FILLED",
+	"canceled":         "CANCELED",
+	"mmp_canceled":     "CANCELED",
+}
+
+var okxOrderType = map[string]string{
+	"market":    "MARKET",
+	"limit":     "LIMIT",
+	"post_only": "LIMIT_MAKER",
+	"fok":       "LIMIT",
+	"ioc":       "LIMIT",
+}
+
+// okxKlines 轉換 [ts, o, h, l, c, vol, volCcy, volCcyQuote, confirm] 格式的 K 線；OKX 由新到舊排序，
+// 標準格式由舊到新。回應不含週期，closeTime 以相鄰 K 線的間隔推算，只有一根時為 0。
+func okxKlines(n OKXNormalizer, rows []interface{}) (interface{}, error) {
+	klines := make([]Kline, 0, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		row, ok := rows[i].([]interface{})
+		if !ok || len(row) < 6 {
+			return nil, fmt.Errorf("item %d: invalid okx kline", i)
+		}
+		m := map[string]interface{}{"openTime": row[0], "open": row[1], "high": row[2], "low": row[3], "close": row[4], "volume": row[5]}
+		if len(row) > 7 {
+			m["quoteVolume"] = row[7]
+		}
+		k, err := toKline(m)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		klines = append(klines, k)
+	}
+	for i := range klines {
+		var interval time.Duration
+		switch {
+		case i+1 < len(klines):
+			interval = klines[i+1].OpenTime.Sub(klines[i].OpenTime)
+		case i > 0:
+			interval = klines[i].OpenTime.Sub(klines[i-1].OpenTime)
+		}
+		if interval > 0 {
+			klines[i].CloseTime = klines[i].OpenTime.Add(interval - time.Millisecond)
+		}
+	}
+	return normalizedKlines(klines), nil
+}
+
+func okxPlacedOrder(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	return okxOrderAck(r, "NEW")
+}
+
+// okxCanceledOrder 轉換撤單結果，OKX 只回傳訂單編號，其餘欄位需另外以 QueryOrder 查詢。
+func okxCanceledOrder(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	return okxOrderAck(r, "CANCELED")
+}
+
+// okxAmendedOrder 轉換改單結果；改單為非同步處理，狀態需另外以 QueryOrder 查詢。
+func okxAmendedOrder(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	return okxOrderAck(r, "")
+}
+
+// okxOrderError 在逐筆結果的 sCode 不為 0 時回傳錯誤。
+func okxOrderError(r *payloadReader) error {
+	if code := r.str("sCode"); code != "" && code != "0" {
+		return fmt.Errorf("okx order error %v: %v: %w", code, r.str("sMsg"), ErrExchangeRejected)
+	}
+	return nil
+}
+
+// okxOrderAck 轉換下單、撤單、改單的逐筆結果，sCode 不為 0 時回傳錯誤。
+func okxOrderAck(r *payloadReader, status string) (interface{}, error) {
+	if err := okxOrderError(r); err != nil {
+		return nil, err
+	}
+	ack := map[string]interface{}{
+		"orderId":       r.str("ordId"),
+		"clientOrderId": r.str("clOrdId"),
+		"transactTime":  normalizedMillis(r.time("ts")),
+	}
+	if status != "" {
+		ack["status"] = status
+	}
+	return ack, r.err
+}
+
+func okxPrecision(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	ctVal, err := okxContractValue(r)
+	if err != nil {
+		return nil, err
+	}
+	pricePrecision := decimalPlaces(r.decimal("tickSz"))
+	return map[string]interface{}{
+		"pricePrecision":         pricePrecision,
+		"quantityPrecision":      decimalPlaces(r.decimal("lotSz").Mul(ctVal)),
+		"quoteQuantityPrecision": pricePrecision,
+	}, r.err
+}
+
+func okxExchangeInfo(n OKXNormalizer, rows []interface{}) (interface{}, error) {
+	symbols, err := convertList(rows, func(v interface{}) (interface{}, error) {
+		r := newPayloadReader(v)
+		ctVal, err := okxContractValue(r)
+		if err != nil {
+			return nil, err
+		}
+		stepSize := okxQuantity(r, "lotSz", ctVal)
+		base, quote, contractType := r.str("baseCcy"), r.str("quoteCcy"), ""
+		if r.str("instType") == "SWAP" {
+			base, quote, _ = strings.Cut(r.str("uly"), "-")
+			contractType = "PERPETUAL"
+		}
+		status := "BREAK"
+		if r.str("state") == "live" {
+			status = "TRADING"
+		}
+		return map[string]interface{}{
+			"symbol":            n.symbol(r.str("instId")),
+			"baseAsset":         base,
+			"quoteAsset":        quote,
+			"status":            status,
+			"contractType":      contractType,
+			"pricePrecision":    decimalPlaces(r.decimal("tickSz")),
+			"quantityPrecision": decimalPlaces(r.decimal("lotSz").Mul(ctVal)),
+			"filters": []map[string]interface{}{
+				{"filterType": "PRICE_FILTER", "tickSize": r.str("tickSz"), "minPrice": "0", "maxPrice": "0"},
+				{"filterType": "LOT_SIZE", "stepSize": stepSize, "minQty": okxQuantity(r, "minSz", ctVal), "maxQty": okxQuantity(r, "maxLmtSz", ctVal)},
+				{"filterType": "MARKET_LOT_SIZE", "stepSize": stepSize, "minQty": okxQuantity(r, "minSz", ctVal), "maxQty": okxQuantity(r, "maxMktSz", ctVal)},
+			},
+		}, r.err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"symbols": symbols}, nil
+}
+
+var okxBillTypes = map[string]string{
+	"1": "TRANSFER",
+	"2": "REALIZED_PNL",
+	"8": "FUNDING_FEE",
+}
+
+func okxBill(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	billType := r.str("type")
+	if t, ok := okxBillTypes[billType]; ok {
+		billType = t
+	}
+	return map[string]interface{}{
+		"symbol":     n.symbol(r.str("instId")),
+		"incomeType": billType,
+		"income":     r.decimal("balChg").String(),
+		"asset":      r.str("ccy"),
+		"tranId":     r.str("billId"),
+		"tradeId":    r.str("tradeId"),
+		"time":       normalizedMillis(r.time("ts")),
+	}, r.err
+}
+
+func okxTransferID(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	return map[string]interface{}{"tranId": r.str("transId")}, r.err
+}
+
+func okxFuturesAccount(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	totalEq := r.decimal("totalEq")
+	upl := r.decimal("upl")
+	available := decimal.Zero
+	assets, err := convertList(r.list("details"), func(v interface{}) (interface{}, error) {
+		d := newPayloadReader(v)
+		availEq := d.decimal("availEq", "availBal")
+		if d.str("ccy") == "USDT" {
+			available = availEq
+		}
+		return map[string]interface{}{
+			"asset":            d.str("ccy"),
+			"walletBalance":    d.decimal("cashBal").String(),
+			"unrealizedProfit": d.decimal("upl").String(),
+			"marginBalance":    d.decimal("eq").String(),
+			"availableBalance": availEq.String(),
+		}, d.err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("details: %w", err)
+	}
+	return map[string]interface{}{
+		"totalWalletBalance":    totalEq.Sub(upl).String(),
+		"totalUnrealizedProfit": upl.String(),
+		"totalMarginBalance":    totalEq.String(),
+		"availableBalance":      available.String(),
+		"maxWithdrawAmount":     available.String(),
+		"assets":                assets,
+		"positions":             []interface{}{},
+	}, r.err
+}
+
+func okxPosition(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	ctVal, err := n.contractValue(r.str("instId"))
+	if err != nil {
+		return nil, err
+	}
+	side := strings.ToUpper(r.str("posSide"))
+	if side == "NET" || side == "" {
+		side = "BOTH"
+	}
+	return map[string]interface{}{
+		"symbol":           n.symbol(r.str("instId")),
+		"positionSide":     side,
+		"positionAmt":      r.decimal("pos").Mul(ctVal).String(),
+		"entryPrice":       r.decimal("avgPx").String(),
+		"markPrice":        r.decimal("markPx").String(),
+		"unRealizedProfit": r.decimal("upl").String(),
+		"liquidationPrice": r.decimal("liqPx").String(),
+		"leverage":         r.decimal("lever").String(),
+		"marginType":       r.str("mgnMode"),
+		"updateTime":       normalizedMillis(r.time("uTime")),
+	}, r.err
+}
+
+func okxOrder(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	ctVal, err := n.contractValue(r.str("instId"))
+	if err != nil {
+		return nil, err
+	}
+	filled := r.decimal("accFillSz").Mul(ctVal)
+	avgPrice := r.decimal("avgPx")
+	return map[string]interface{}{
+		"symbol":              n.symbol(r.str("instId")),
+		"orderId":             r.str("ordId"),
+		"clientOrderId":       r.str("clOrdId"),
+		"side":                strings.ToUpper(r.str("side")),
+		"type":                okxOrderType[r.str("ordType")],
+		"status":              okxOrderStatus[r.str("state")],
+		"price":               r.decimal("px").String(),
+		"avgPrice":            avgPrice.String(),
+		"origQty":             r.decimal("sz").Mul(ctVal).String(),
+		"executedQty":         filled.String(),
+		"cummulativeQuoteQty": filled.Mul(avgPrice).String(),
+		"time":                normalizedMillis(r.time("cTime")),
+		"updateTime":          normalizedMillis(r.time("uTime")),
+	}, r.err
+}
+
+// okxFill 轉換成交明細；OKX 的 fee 為負數表示扣除，標準格式的 commission 為正數。
+func okxFill(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	ctVal, err := n.contractValue(r.str("instId"))
+	if err != nil {
+		return nil, err
+	}
+	price := r.decimal("fillPx")
+	qty := r.decimal("fillSz").Mul(ctVal)
+	side := strings.ToUpper(r.str("side"))
+	return map[string]interface{}{
+		"symbol":          n.symbol(r.str("instId")),
+		"id":              r.str("tradeId"),
+		"orderId":         r.str("ordId"),
+		"side":            side,
+		"price":           price.String(),
+		"qty":             qty.String(),
+		"quoteQty":        price.Mul(qty).String(),
+		"commission":      r.decimal("fee").Neg().String(),
+		"commissionAsset": r.str("feeCcy"),
+		"realizedPnl":     r.decimal("fillPnl").String(),
+		"isBuyer":         side == "BUY",
+		"isMaker":         r.str("execType") == "M",
+		"time":            normalizedMillis(r.time("ts", "fillTime")),
+	}, r.err
+}
+
+// okxCommission 轉換手續費率；OKX 以負數表示收取手續費，標準格式以正數表示。
+func okxCommission(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	return map[string]interface{}{
+		"symbol":          n.symbol(r.str("instId")),
+		"makerCommission": r.decimal("maker").Neg().String(),
+		"takerCommission": r.decimal("taker").Neg().String(),
+	}, r.err
+}
+
+func okxTransfer(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	return map[string]interface{}{
+		"tranId":    r.str("transId", "billId"),
+		"asset":     r.str("ccy"),
+		"amount":    r.decimal("amt", "balChg").String(),
+		"type":      r.str("type"),
+		"status":    strings.ToUpper(r.str("state")),
+		"timestamp": normalizedMillis(r.time("ts")),
+	}, r.err
+}
+
+func okxWithdrawID(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	return map[string]interface{}{"id": r.str("wdId")}, r.err
+}
+
+// okxWithdrawalStatus 把 OKX 的提幣狀態轉成 Binance 的狀態碼（1 取消、4 處理中、5 失敗、6 完成）。
+func okxWithdrawalStatus(state string) int {
+	switch state {
+	case "2":
+		return 6
+	case "-1":
+		return 5
+	case "-2", "-3":
+		return 1
+	}
+	return 4
+}
+
+func okxWithdrawal(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	applyTime := ""
+	if t := r.time("ts"); !t.IsZero() {
+		applyTime = t.UTC().Format("2006-01-02 15:04:05")
+	}
+	return map[string]interface{}{
+		"id":             r.str("wdId"),
+		"coin":           r.str("ccy"),
+		"network":        r.str("chain"),
+		"address":        r.str("to"),
+		"amount":         r.decimal("amt").String(),
+		"transactionFee": r.decimal("fee").String(),
+		"txId":           r.str("txId"),
+		"status":         okxWithdrawalStatus(r.str("state")),
+		"applyTime":      applyTime,
+	}, r.err
+}
+
+// okxCurrencies 把 OKX 每個鏈一筆的幣種資料依幣種合併成 networkList。
+func okxCurrencies(n OKXNormalizer, rows []interface{}) (interface{}, error) {
+	coins := map[string]map[string]interface{}{}
+	networks := map[string][]interface{}{}
+	for _, v := range rows {
+		r := newPayloadReader(v)
+		ccy := r.str("ccy")
+		if _, ok := coins[ccy]; !ok {
+			coins[ccy] = map[string]interface{}{"coin": ccy, "name": r.str("name"), "free": "0", "locked": "0"}
+		}
+		networks[ccy] = append(networks[ccy], map[string]interface{}{
+			"network":                 r.str("chain"),
+			"name":                    r.str("chain"),
+			"isDefault":               r.bool("mainNet"),
+			"depositEnable":           r.bool("canDep"),
+			"withdrawEnable":          r.bool("canWd"),
+			"withdrawFee":             r.decimal("minFee", "fee").String(),
+			"withdrawMin":             r.decimal("minWd").String(),
+			"withdrawMax":             r.decimal("maxWd").String(),
+			"withdrawIntegerMultiple": r.decimal("wdTickSz").String(),
+		})
+		if r.err != nil {
+			return nil, fmt.Errorf("%v: %w", ccy, r.err)
+		}
+	}
+	names := make([]string, 0, len(coins))
+	for ccy := range coins {
+		names = append(names, ccy)
+	}
+	sort.Strings(names)
+	result := make([]interface{}, 0, len(names))
+	for _, ccy := range names {
+		coins[ccy]["networkList"] = networks[ccy]
+		result = append(result, coins[ccy])
+	}
+	return result, nil
+}
+
+// okxBalances 接受資金帳戶的餘額清單，或交易帳戶 balance 的 details。
+func okxBalances(n OKXNormalizer, rows []interface{}) (interface{}, error) {
+	if len(rows) == 1 {
+		if details := newPayloadReader(rows[0]).list("details"); details != nil {
+			rows = details
+		}
+	}
+	return okxEach(func(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+		return map[string]interface{}{
+			"asset":       r.str("ccy"),
+			"free":        r.decimal("availBal").String(),
+			"locked":      r.decimal("frozenBal").String(),
+			"freeze":      "0",
+			"withdrawing": "0",
+		}, r.err
+	})(n, rows)
+}
+
+func okxTicker(n OKXNormalizer, r *payloadReader) (interface{}, error) {
+	return map[string]interface{}{
+		"symbol": n.symbol(r.str("instId")),
+		"price":  r.decimal("last").String(),
+		"time":   normalizedMillis(r.time("ts")),
+	}, r.err
+}
@@ -0,0 +1,142 @@
+package failover
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBinanceResponsePassesThrough(t *testing.T) {
+	body := []byte(`[[1700000000000,"1.0","2.0","0.5","1.5","10",1700000059999,"15",3]]`)
+	res, err := ExchangeApiProxyImpl{}.normalize("Klines", ExchangeApiResponse{ConnectorType: ExchangeConnectorTypeBinance, IsSuccess: true, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Body) != string(body) {
+		t.Fatalf("body = %s, want unchanged", res.Body)
+	}
+	klines, err := decodeList(res.Body, toKline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := normalizedKlines(klines)[0]["close"]; got != "1.5" {
+		t.Fatalf("close = %v", got)
+	}
+}
+
+func TestOrderNormalizeErrorReturnsRawResponse(t *testing.T) {
+	body := []byte(`{"code":"0","data":"unexpected"}`)
+	res := ExchangeApiResponse{ConnectorType: ExchangeConnectorTypeOKX, IsSuccess: true, Body: body}
+
+	got, err := ExchangeApiProxyImpl{}.normalize("SpotTrade", res)
+	if err != nil {
+		t.Fatalf("order normalize error should not fail: %v", err)
+	}
+	if string(got.Body) != string(body) {
+		t.Fatalf("body = %s, want raw response", got.Body)
+	}
+	if _, err := (ExchangeApiProxyImpl{}).normalize("SpotQueryOrder", res); err == nil {
+		t.Fatal("query normalize error should be returned")
+	}
+}
+
+func TestOrderRejectionIsReturned(t *testing.T) {
+	cases := map[string]string{
+		"batch code with sCode": `{"code":"1","msg":"All operations failed","data":[{"sCode":"51008","sMsg":"Insufficient balance"}]}`,
+		"sCode only":            `{"code":"0","data":[{"ordId":"","sCode":"51008","sMsg":"Insufficient balance"}]}`,
+		"code only":             `{"code":"50011","msg":"Rate limit reached","data":[]}`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			res := ExchangeApiResponse{ConnectorType: ExchangeConnectorTypeOKX, IsSuccess: true, Body: []byte(body)}
+			_, err := ExchangeApiProxyImpl{}.normalize("SpotTrade", res)
+			if !errors.Is(err, ErrExchangeRejected) {
+				t.Fatalf("err = %v, want ErrExchangeRejected", err)
+			}
+		})
+	}
+
+	res := ExchangeApiResponse{ConnectorType: ExchangeConnectorTypeOKX, IsSuccess: true, Body: []byte(cases["batch code with sCode"])}
+	if _, err := (ExchangeApiProxyImpl{}).normalize("SpotTrade", res); err == nil || !strings.Contains(err.Error(), "51008") {
+		t.Fatalf("err = %v, want sCode 51008", err)
+	}
+}
+
+func TestOKXSwapQuantitiesInBaseUnits(t *testing.T) {
+	body := []byte(`{"code":"0","data":[
+		{"instType":"SWAP","instId":"BTC-USDT-SWAP","uly":"BTC-USDT","ctType":"linear","ctVal":"0.01","ctValCcy":"BTC","tickSz":"0.1","lotSz":"1","minSz":"1","maxLmtSz":"100000","maxMktSz":"3000","state":"live"},
+		{"instType":"SWAP","instId":"BTC-USD-SWAP","uly":"BTC-USD","ctType":"inverse","ctVal":"100","ctValCcy":"USD","tickSz":"0.1","lotSz":"1","minSz":"1","state":"live"},
+		{"instType":"SPOT","instId":"BTC-USDT","baseCcy":"BTC","quoteCcy":"USDT","tickSz":"0.1","lotSz":"0.00000001","minSz":"0.00001","state":"live"}
+	]}`)
+	out, err := OKXNormalizer{}.Normalize("FuturesExchangeInfo", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := map[string][]struct {
+		Symbol            string              `json:"symbol"`
+		QuantityPrecision int32               `json:"quantityPrecision"`
+		Filters           []map[string]string `json:"filters"`
+	}{}
+	if err := json.Unmarshal(out, &info); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		stepSize, minQty, maxQty string
+		precision                int32
+	}{
+		{"0.01", "0.01", "1000", 2},
+		// 幣本位合約與 Binance COIN-M 同樣以張為單位
+		{"1", "1", "", 0},
+		{"0.00000001", "0.00001", "", 8},
+	}
+	for i, w := range want {
+		s := info["symbols"][i]
+		lot := s.Filters[1]
+		if lot["stepSize"] != w.stepSize || lot["minQty"] != w.minQty || lot["maxQty"] != w.maxQty || s.QuantityPrecision != w.precision {
+			t.Errorf("%v: lot = %v, precision = %v, want %+v", s.Symbol, lot, s.QuantityPrecision, w)
+		}
+	}
+
+	missing := []byte(`{"code":"0","data":[{"instType":"SWAP","instId":"ETH-USDT-SWAP","uly":"ETH-USDT","lotSz":"1"}]}`)
+	if _, err := (OKXNormalizer{}).Normalize("GetUSDTMFuturesPrecision", missing); err == nil {
+		t.Fatal("swap without ctVal: expected error")
+	}
+}
+
+func TestOKXSwapOrderQuantitiesUseContractValue(t *testing.T) {
+	order := []byte(`{"code":"0","data":[{"instType":"SWAP","instId":"BTC-USDT-SWAP","ordId":"1","side":"buy","ordType":"limit","state":"partially_filled","px":"30000","sz":"5","accFillSz":"2","avgPx":"30000"}]}`)
+
+	registry := NewInstrumentRegistry()
+	if _, err := (OKXNormalizer{Instruments: registry}).Normalize("FuturesQueryOrder", order); !errors.Is(err, ErrUnknownInstrument) {
+		t.Fatalf("err = %v, want ErrUnknownInstrument without contract value", err)
+	}
+
+	instruments := []byte(`{"code":"0","data":[{"instType":"SWAP","instId":"BTC-USDT-SWAP","uly":"BTC-USDT","settleCcy":"USDT","ctType":"linear","ctVal":"0.01"}]}`)
+	if _, err := registry.LoadExchangeInfo(ExchangeConnectorTypeOKX, instruments); err != nil {
+		t.Fatal(err)
+	}
+	out, err := OKXNormalizer{Instruments: registry}.Normalize("FuturesQueryOrder", order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["origQty"] != "0.05" || got["executedQty"] != "0.02" || got["cummulativeQuoteQty"] != "600" {
+		t.Fatalf("order = %v", got)
+	}
+
+	spot := []byte(`{"code":"0","data":[{"instType":"SPOT","instId":"BTC-USDT","ordId":"2","sz":"0.5","accFillSz":"0.5","avgPx":"30000"}]}`)
+	out, err = OKXNormalizer{Instruments: registry}.Normalize("SpotQueryOrder", spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["origQty"] != "0.5" {
+		t.Fatalf("spot origQty = %v, want 0.5", got["origQty"])
+	}
+}
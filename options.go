@@ -1,27 +1,200 @@
 package failover
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Config struct {
-	ErrThreshold       int
-	ErrTTL             time.Duration
-	LockTimeTTL        time.Duration
-	RedisKeyConnector  string
-	RedisKeyLockTime   string
-	RedisKeyErrTimeAt  string
+	ErrThreshold           int
+	ErrTTL                 time.Duration
+	LockTimeTTL            time.Duration
+	RedisKeyConnector      string
+	RedisKeyGeneration     string
+	RedisKeyConnectorSince string
+	RedisKeyLockTime       string
+	RedisKeyErrTimeAt      string
+	RedisKeyEvents         string
+	RedisKeyPin            string
+	RedisKeyMaintenance    string
+	RedisChannelState      string
+	RedisKeyLeader         string
+	RedisChannelReports    string
+	EventMaxLen            int64
+	InstanceID             string
+	// Group 為這組主備交易所的名稱，會帶在告警上供 sink 分流
+	Group string
+
+	// RedisKeyOrders 為訂單所在交易所紀錄的 key 前綴，OrderRouteTTL 為紀錄保留的時間
+	RedisKeyOrders string
+	OrderRouteTTL  time.Duration
+
+	MaintenanceLeadTime      time.Duration
+	MaintenanceCheckInterval time.Duration
+	MaintenanceRetention     time.Duration
+
+	StateReconcileInterval time.Duration
+	DegradedProbeInterval  time.Duration
+
+	LeaderLeaseTTL      time.Duration
+	CoordinatorInterval time.Duration
+
+	// AlertLocale 為告警範本的語系，支援 zh-TW 與 en
+	AlertLocale string
+
+	// resolved 表示已由 withDefaults 補齊，NewProxy 建立的 proxy 不需每次操作重新補齊
+	resolved bool
 }
 
 var DefaultConfig = Config{
-	ErrThreshold:      5,
-	ErrTTL:            30 * time.Second,
-	LockTimeTTL:       30 * time.Minute,
-	RedisKeyConnector: "exchange:connector",
-	RedisKeyLockTime:  "exchange:lockTime",
-	RedisKeyErrTimeAt: "exchange:errTime",
+	ErrThreshold:           5,
+	ErrTTL:                 30 * time.Second,
+	LockTimeTTL:            30 * time.Minute,
+	RedisKeyConnector:      "exchange:connector",
+	RedisKeyGeneration:     "exchange:generation",
+	RedisKeyConnectorSince: "exchange:connectorSince",
+	RedisKeyLockTime:       "exchange:lockTime",
+	RedisKeyErrTimeAt:      "exchange:errTime",
+	RedisKeyEvents:         "exchange:events",
+	RedisKeyPin:            "exchange:pin",
+	RedisKeyMaintenance:    "exchange:maintenance",
+	RedisChannelState:      "exchange:state",
+	RedisKeyLeader:         "exchange:leader",
+	RedisChannelReports:    "exchange:reports",
+	EventMaxLen:            10000,
+	Group:                  "default",
+
+	RedisKeyOrders: "exchange:orders",
+	OrderRouteTTL:  30 * 24 * time.Hour,
+
+	MaintenanceLeadTime:      time.Minute,
+	MaintenanceCheckInterval: 15 * time.Second,
+	MaintenanceRetention:     7 * 24 * time.Hour,
+
+	StateReconcileInterval: 30 * time.Second,
+	DegradedProbeInterval:  5 * time.Second,
+
+	LeaderLeaseTTL:      10 * time.Second,
+	CoordinatorInterval: time.Second,
+
+	AlertLocale: AlertLocaleZhTW,
+}
+
+// stateKeys 回傳由 Lua script 或 MGET 一起操作、在 Redis Cluster 上必須位於同一個 slot 的 key。
+func (c Config) stateKeys() []string {
+	return []string{c.RedisKeyConnector, c.RedisKeyGeneration, c.RedisKeyConnectorSince, c.RedisKeyLockTime, c.RedisKeyPin}
+}
+
+// withDefaults 以 DefaultConfig 補齊未設定的欄位，InstanceID 預設為 hostname-pid。
+func (c Config) withDefaults() Config {
+	if c.resolved {
+		return c
+	}
+	if c.ErrThreshold == 0 {
+		c.ErrThreshold = DefaultConfig.ErrThreshold
+	}
+	if c.ErrTTL == 0 {
+		c.ErrTTL = DefaultConfig.ErrTTL
+	}
+	if c.LockTimeTTL == 0 {
+		c.LockTimeTTL = DefaultConfig.LockTimeTTL
+	}
+	if c.RedisKeyConnector == "" {
+		c.RedisKeyConnector = DefaultConfig.RedisKeyConnector
+	}
+	if c.RedisKeyGeneration == "" {
+		c.RedisKeyGeneration = DefaultConfig.RedisKeyGeneration
+	}
+	if c.RedisKeyConnectorSince == "" {
+		c.RedisKeyConnectorSince = DefaultConfig.RedisKeyConnectorSince
+	}
+	if c.RedisKeyLockTime == "" {
+		c.RedisKeyLockTime = DefaultConfig.RedisKeyLockTime
+	}
+	if c.RedisKeyErrTimeAt == "" {
+		c.RedisKeyErrTimeAt = DefaultConfig.RedisKeyErrTimeAt
+	}
+	if c.RedisKeyEvents == "" {
+		c.RedisKeyEvents = DefaultConfig.RedisKeyEvents
+	}
+	if c.RedisKeyPin == "" {
+		c.RedisKeyPin = DefaultConfig.RedisKeyPin
+	}
+	if c.RedisKeyMaintenance == "" {
+		c.RedisKeyMaintenance = DefaultConfig.RedisKeyMaintenance
+	}
+	if c.RedisChannelState == "" {
+		c.RedisChannelState = DefaultConfig.RedisChannelState
+	}
+	if c.RedisKeyLeader == "" {
+		c.RedisKeyLeader = DefaultConfig.RedisKeyLeader
+	}
+	if c.RedisChannelReports == "" {
+		c.RedisChannelReports = DefaultConfig.RedisChannelReports
+	}
+	if c.RedisKeyOrders == "" {
+		c.RedisKeyOrders = DefaultConfig.RedisKeyOrders
+	}
+	if c.OrderRouteTTL == 0 {
+		c.OrderRouteTTL = DefaultConfig.OrderRouteTTL
+	}
+	if c.MaintenanceLeadTime == 0 {
+		c.MaintenanceLeadTime = DefaultConfig.MaintenanceLeadTime
+	}
+	if c.MaintenanceCheckInterval == 0 {
+		c.MaintenanceCheckInterval = DefaultConfig.MaintenanceCheckInterval
+	}
+	if c.MaintenanceRetention == 0 {
+		c.MaintenanceRetention = DefaultConfig.MaintenanceRetention
+	}
+	if c.StateReconcileInterval == 0 {
+		c.StateReconcileInterval = DefaultConfig.StateReconcileInterval
+	}
+	if c.DegradedProbeInterval == 0 {
+		c.DegradedProbeInterval = DefaultConfig.DegradedProbeInterval
+	}
+	if c.LeaderLeaseTTL == 0 {
+		c.LeaderLeaseTTL = DefaultConfig.LeaderLeaseTTL
+	}
+	if c.CoordinatorInterval == 0 {
+		c.CoordinatorInterval = DefaultConfig.CoordinatorInterval
+	}
+	if c.Group == "" {
+		c.Group = DefaultConfig.Group
+	}
+	if c.AlertLocale == "" {
+		c.AlertLocale = DefaultConfig.AlertLocale
+	}
+	if c.EventMaxLen == 0 {
+		c.EventMaxLen = DefaultConfig.EventMaxLen
+	}
+	if c.InstanceID == "" {
+		c.InstanceID = defaultInstanceID()
+	}
+	c.resolved = true
+	return c
+}
+
+var (
+	instanceIDOnce sync.Once
+	instanceID     string
+)
+
+// defaultInstanceID 回傳 hostname-pid，只在第一次呼叫時查詢 hostname。
+func defaultInstanceID() string {
+	instanceIDOnce.Do(func() {
+		hostname, _ := os.Hostname()
+		instanceID = fmt.Sprintf("%v-%v", hostname, os.Getpid())
+	})
+	return instanceID
 }
 
 type Option func(*Config)
@@ -52,14 +225,125 @@ func WithRedisKeys(connector, lockTime, errTimeAt string) Option {
 	}
 }
 
+func WithRedisKeyGeneration(key string) Option {
+	return func(c *Config) {
+		c.RedisKeyGeneration = key
+	}
+}
+
+func WithRedisKeyConnectorSince(key string) Option {
+	return func(c *Config) {
+		c.RedisKeyConnectorSince = key
+	}
+}
+
+// WithRedisHashTag 以 {tag}: 為前綴設定 connector、generation、connectorSince、lockTime 與 pin 的 key，
+// 讓它們在 Redis Cluster 上落在同一個 slot；使用 Cluster 時必須設定，否則狀態寫入會回傳 ErrCrossSlot。
+func WithRedisHashTag(tag string) Option {
+	return func(c *Config) {
+		prefix := "{" + tag + "}:"
+		c.RedisKeyConnector = prefix + "connector"
+		c.RedisKeyGeneration = prefix + "generation"
+		c.RedisKeyConnectorSince = prefix + "connectorSince"
+		c.RedisKeyLockTime = prefix + "lockTime"
+		c.RedisKeyPin = prefix + "pin"
+	}
+}
+
+func WithGroup(group string) Option {
+	return func(c *Config) {
+		c.Group = group
+	}
+}
+
+// WithAlertLocale 設定告警範本的語系（zh-TW、en）。
+func WithAlertLocale(locale string) Option {
+	return func(c *Config) {
+		c.AlertLocale = locale
+	}
+}
+
+func WithRedisKeyEvents(key string) Option {
+	return func(c *Config) {
+		c.RedisKeyEvents = key
+	}
+}
+
+func WithRedisKeyPin(key string) Option {
+	return func(c *Config) {
+		c.RedisKeyPin = key
+	}
+}
+
+// WithRedisChannelState 設定狀態變更通知使用的 pub/sub channel。
+func WithRedisChannelState(channel string) Option {
+	return func(c *Config) {
+		c.RedisChannelState = channel
+	}
+}
+
+// WithStateReconcileInterval 設定 StateCache 定期從 Redis 重新載入的間隔。
+func WithStateReconcileInterval(interval time.Duration) Option {
+	return func(c *Config) {
+		c.StateReconcileInterval = interval
+	}
+}
+
+// WithDegradedProbeInterval 設定 Redis 無法連線時重新嘗試連線的間隔。
+func WithDegradedProbeInterval(interval time.Duration) Option {
+	return func(c *Config) {
+		c.DegradedProbeInterval = interval
+	}
+}
+
+// WithOrderRouteTTL 設定訂單所在交易所的紀錄保留多久，應長於掛單可能存在的時間。
+func WithOrderRouteTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.OrderRouteTTL = ttl
+	}
+}
+
+func WithLeaderLeaseTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.LeaderLeaseTTL = ttl
+	}
+}
+
+func WithMaintenanceLeadTime(lead time.Duration) Option {
+	return func(c *Config) {
+		c.MaintenanceLeadTime = lead
+	}
+}
+
+func WithEventMaxLen(maxLen int64) Option {
+	return func(c *Config) {
+		c.EventMaxLen = maxLen
+	}
+}
+
+func WithInstanceID(id string) Option {
+	return func(c *Config) {
+		c.InstanceID = id
+	}
+}
+
 type ProxyOption func(*proxyOptions)
 
 type proxyOptions struct {
-	primaryConnector   ExchangeConnector
-	standbyConnector   ExchangeConnector
-	cache              redis.UniversalClient
-	alertService       IAlertService
-	config             Config
+	primaryConnector ExchangeConnector
+	standbyConnector ExchangeConnector
+	cache            redis.UniversalClient
+	alertService     IAlertService
+	alertSink        AlertSink
+	tracerProvider   trace.TracerProvider
+	eventJournal     EventJournal
+	maintenanceProbe func(ExchangeConnector) (ExchangeApiResponse, error)
+	coordinator      bool
+	alertThrottle    *AlertThrottleConfig
+	alertTemplates   map[AlertTemplateName]string
+	normalizers      map[ExchangeConnectorType]ResponseNormalizer
+	instruments      *InstrumentRegistry
+	config           Config
 }
 
 func WithPrimaryConnector(c ExchangeConnector) ProxyOption {
@@ -86,29 +370,302 @@ func WithAlertService(s IAlertService) ProxyOption {
 	}
 }
 
+// WithAlertSink 設定接收結構化告警的 AlertSink，優先於 WithAlertService。
+func WithAlertSink(s AlertSink) ProxyOption {
+	return func(o *proxyOptions) {
+		o.alertSink = s
+	}
+}
+
+// WithTracerProvider 設定 Invoke 使用的 TracerProvider，傳入 Kratos 使用的同一個 provider
+// 即可讓交易所呼叫出現在既有的 trace 中。
+func WithTracerProvider(tp trace.TracerProvider) ProxyOption {
+	return func(o *proxyOptions) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithEventJournal 設定狀態切換事件的寫入目標，未設定時使用 cache 上的 Redis Stream。
+func WithEventJournal(j EventJournal) ProxyOption {
+	return func(o *proxyOptions) {
+		o.eventJournal = j
+	}
+}
+
+// WithMaintenanceProbe 設定維護結束後切回前的探測呼叫，預設為 SymbolPriceTicker。
+func WithMaintenanceProbe(probe func(ExchangeConnector) (ExchangeApiResponse, error)) ProxyOption {
+	return func(o *proxyOptions) {
+		o.maintenanceProbe = probe
+	}
+}
+
+// WithAlertThrottle 以 ThrottledAlertService 包裝 AlertService，在所有 instance 間共用 cooldown 與摘要；
+// 需同時設定 WithCache。
+func WithAlertThrottle(cfg AlertThrottleConfig) ProxyOption {
+	return func(o *proxyOptions) {
+		o.alertThrottle = &cfg
+	}
+}
+
+// WithAlertTemplates 以 text/template 覆蓋 Config.AlertLocale 語系下的個別告警範本。
+func WithAlertTemplates(templates map[AlertTemplateName]string) ProxyOption {
+	return func(o *proxyOptions) {
+		o.alertTemplates = templates
+	}
+}
+
+// WithResponseNormalizer 設定 ct 回應的 normalizer，取代 DefaultResponseNormalizers；
+// connector 已回傳標準格式時傳入 nil 即可略過轉換。
+func WithResponseNormalizer(ct ExchangeConnectorType, n ResponseNormalizer) ProxyOption {
+	return func(o *proxyOptions) {
+		if o.normalizers == nil {
+			o.normalizers = map[ExchangeConnectorType]ResponseNormalizer{}
+		}
+		o.normalizers[ct] = n
+	}
+}
+
+// WithInstrumentRegistry 設定轉換交易對使用的 registry，取代 DefaultInstrumentRegistry。
+func WithInstrumentRegistry(r *InstrumentRegistry) ProxyOption {
+	return func(o *proxyOptions) {
+		o.instruments = r
+	}
+}
+
+// WithCoordinator 啟用 coordinator 模式，需將 proxy.Coordinator 加入 kratos.Server(...) 參與選舉。
+func WithCoordinator() ProxyOption {
+	return func(o *proxyOptions) {
+		o.coordinator = true
+	}
+}
+
 func WithConfig(cfg Config) ProxyOption {
 	return func(o *proxyOptions) {
 		o.config = cfg
 	}
 }
 
+// NewProxy 建立 proxy；告警語系或範本無效時記錄錯誤並使用預設範本，
+// 需要在啟動時失敗的呼叫端可先以 ValidateProxyOptions 檢查。
 func NewProxy(opts ...ProxyOption) ExchangeApiProxyImpl {
-	options := proxyOptions{
-		config: DefaultConfig,
+	options := newProxyOptions(opts)
+	if options.eventJournal == nil && options.cache != nil {
+		options.eventJournal = NewRedisEventJournal(options.cache, options.config.RedisKeyEvents, options.config.EventMaxLen)
 	}
-	for _, opt := range opts {
-		opt(&options)
+	renderer, err := NewAlertRenderer(options.config.AlertLocale, options.alertTemplates)
+	if err != nil {
+		log.Errorf("alert templates error, use default: %v", err)
+		renderer = defaultAlertRenderer
+	}
+	if options.alertSink == nil && options.alertService != nil {
+		options.alertSink = NewAlertServiceSink(options.alertService)
+	}
+	if options.alertThrottle != nil && options.alertSink != nil && options.cache != nil {
+		throttle := *options.alertThrottle
+		if throttle.Renderer == nil {
+			throttle.Renderer = renderer
+		}
+		throttled := NewThrottledAlertSink(options.alertSink, options.cache, throttle)
+		options.alertSink = throttled
+		if options.alertService != nil {
+			options.alertService = throttled
+		}
+	}
+	var stateCache *StateCache
+	if options.cache != nil {
+		stateCache = NewStateCache(options.cache, options.config)
 	}
-	return ExchangeApiProxyImpl{
+	proxy := ExchangeApiProxyImpl{
 		BinanceImpl:  options.primaryConnector,
 		OKXImpl:      options.standbyConnector,
 		Cache:        options.cache,
 		AlertService: options.alertService,
+		AlertSink:    options.alertSink,
+		Tracer:       options.tracerProvider,
+		Journal:      options.eventJournal,
+		Config:       options.config,
+		StateCache:   stateCache,
+
+		AlertRenderer: renderer,
+
+		MaintenanceProbe: options.maintenanceProbe,
+		Normalizers:      options.normalizers,
+		Instruments:      options.instruments,
+	}
+	if options.instruments != nil {
+		if _, ok := proxy.Normalizers[ExchangeConnectorTypeOKX]; !ok {
+			if proxy.Normalizers == nil {
+				proxy.Normalizers = map[ExchangeConnectorType]ResponseNormalizer{}
+			}
+			proxy.Normalizers[ExchangeConnectorTypeOKX] = OKXNormalizer{Instruments: options.instruments}
+		}
+	}
+	if options.coordinator && options.cache != nil {
+		proxy.Coordinator = newCoordinator(options.cache, options.config)
+		proxy.Coordinator.proxy = proxy
+	}
+	return proxy
+}
+
+// ValidateProxyOptions 檢查 NewProxy 會略過的設定錯誤：告警語系或範本無效，
+// 或 Redis Cluster 上的狀態 key 不在同一個 slot。
+func ValidateProxyOptions(opts ...ProxyOption) error {
+	options := newProxyOptions(opts)
+	if _, err := NewAlertRenderer(options.config.AlertLocale, options.alertTemplates); err != nil {
+		return fmt.Errorf("alert templates: %w", err)
+	}
+	if options.cache != nil {
+		if err := checkSlot(options.cache, options.config.stateKeys()...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newProxyOptions(opts []ProxyOption) proxyOptions {
+	options := proxyOptions{
+		config: DefaultConfig,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.config = options.config.withDefaults()
+	return options
+}
+
+// AdminAuthorizer 檢查管理 API 的請求，回傳錯誤時拒絕；mutating 表示請求會修改狀態。
+// 回傳 Kratos error 時原樣回應，其他錯誤回應 403。
+type AdminAuthorizer func(ctx khttp.Context, mutating bool) error
+
+// AdminHTTPOption 設定 RegisterAdminHTTPServer 掛載的管理 API。
+type AdminHTTPOption func(*adminHTTPOptions)
+
+type adminHTTPOptions struct {
+	authorizer AdminAuthorizer
+}
+
+func WithAdminAuthorizer(fn AdminAuthorizer) AdminHTTPOption {
+	return func(o *adminHTTPOptions) {
+		o.authorizer = fn
+	}
+}
+
+// AdminGRPCAuthorizer 檢查 FailoverAdmin gRPC 請求，回傳錯誤時拒絕；mutating 表示請求會修改狀態。
+// ctx 帶有 Kratos gRPC server 的 transport，可由 transport.FromServerContext 讀取 metadata，
+// 或讀取驗證 middleware 寫入的身分。回傳 Kratos error 時原樣回應，其他錯誤回應 403。
+type AdminGRPCAuthorizer func(ctx context.Context, mutating bool) error
+
+// AdminGRPCOption 設定 RegisterAdminGRPCServer 註冊的 FailoverAdmin service。
+type AdminGRPCOption func(*adminGRPCOptions)
+
+type adminGRPCOptions struct {
+	authorizer AdminGRPCAuthorizer
+}
+
+func WithAdminGRPCAuthorizer(fn AdminGRPCAuthorizer) AdminGRPCOption {
+	return func(o *adminGRPCOptions) {
+		o.authorizer = fn
+	}
+}
+
+// AdapterOption 設定 NewAdapter 與 NewAdapterV2 建立的 adapter。
+type AdapterOption func(*adapterOptions)
+
+type adapterOptions struct {
+	infoCache      *ExchangeInfoCache
+	validator      *OrderValidator
+	validatorSet   bool
+	validationMode OrderValidationMode
+	klineOffset    time.Duration
+}
+
+// WithExchangeInfoCache 讓精度、exchange info 與幣種設定經由 c 取得，下單前檢查的交易對限制也一併快取。
+// 需背景更新時另外啟動 c。
+func WithExchangeInfoCache(c *ExchangeInfoCache) AdapterOption {
+	return func(o *adapterOptions) {
+		o.infoCache = c
 	}
 }
 
-func NewAdapter(proxy ExchangeApiProxy) ExchangeApi {
+// WithOrderValidator 取代預設的下單前檢查，nil 表示不檢查。
+func WithOrderValidator(v *OrderValidator) AdapterOption {
+	return func(o *adapterOptions) {
+		o.validator = v
+		o.validatorSet = true
+	}
+}
+
+// WithOrderValidation 以 mode 啟用下單前檢查，交易對限制經由 WithExchangeInfoCache 的快取取得；
+// 未設定快取時每筆訂單送出前都會同步查詢一次交易所。
+func WithOrderValidation(mode OrderValidationMode) AdapterOption {
+	return func(o *adapterOptions) {
+		o.validationMode = mode
+	}
+}
+
+// WithKlineOffset 設定 ClosingTimeRemaining 切分 K 線使用的時區與 UTC 的差，例如 UTC+8 為 8 * time.Hour。
+func WithKlineOffset(offset time.Duration) AdapterOption {
+	return func(o *adapterOptions) {
+		o.klineOffset = offset
+	}
+}
+
+func newAdapterOptions(proxy ExchangeApiProxy, opts []AdapterOption) adapterOptions {
+	options := adapterOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if !options.validatorSet {
+		options.validator = defaultOrderValidator(proxy, options.infoCache, options.validationMode)
+	}
+	return options
+}
+
+func NewAdapter(proxy ExchangeApiProxy, opts ...AdapterOption) ContextExchangeApi {
+	options := newAdapterOptions(proxy, opts)
 	return ExchangeApiAdapter{
-		ApiProxy: proxy,
+		ApiProxy:    proxy,
+		Instruments: proxyInstruments(proxy),
+		Validator:   options.validator,
+		InfoCache:   options.infoCache,
+		KlineOffset: options.klineOffset,
+	}
+}
+
+// NewAdapterV2 回傳型別化的 ExchangeApiV2，可與 NewAdapter 共用同一個 proxy 與 ExchangeInfoCache。
+func NewAdapterV2(proxy ExchangeApiProxy, opts ...AdapterOption) ExchangeApiV2 {
+	options := newAdapterOptions(proxy, opts)
+	return ExchangeApiV2Adapter{
+		ApiProxy:    proxy,
+		Instruments: proxyInstruments(proxy),
+		Validator:   options.validator,
+		InfoCache:   options.infoCache,
+		KlineOffset: options.klineOffset,
+	}
+}
+
+func proxyInstruments(proxy ExchangeApiProxy) *InstrumentRegistry {
+	if impl, ok := proxy.(ExchangeApiProxyImpl); ok && impl.Instruments != nil {
+		return impl.Instruments
+	}
+	return DefaultInstrumentRegistry
+}
+
+// defaultOrderValidator 以 proxy 的交易對設定與回應標準化向實際下單的交易所查詢限制。未指定 mode 時只在設定快取後
+// 以 OrderValidationReject 檢查，不修改訂單，也不會在每筆訂單前多呼叫一次交易所。
+func defaultOrderValidator(proxy ExchangeApiProxy, cache *ExchangeInfoCache, mode OrderValidationMode) *OrderValidator {
+	if mode == "" {
+		if cache == nil {
+			return nil
+		}
+		mode = OrderValidationReject
+	}
+	source := ConnectorSymbolInfoSource{Instruments: proxyInstruments(proxy)}
+	if impl, ok := proxy.(ExchangeApiProxyImpl); ok {
+		source.Normalizers = impl.Normalizers
+	}
+	if cache != nil {
+		return NewOrderValidator(mode, CachedSymbolInfoSource{Cache: cache, Source: source})
 	}
+	return NewOrderValidator(mode, source)
 }
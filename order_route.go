@@ -0,0 +1,134 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// OrderRouter 記錄訂單所在的交易所。切換交易所後，撤單、查詢與改單仍需送到下單的交易所，
+// ExchangeApiProxyImpl 以 Redis 保存紀錄，讓其他 instance 也能查到。
+type OrderRouter interface {
+	RecordOrder(ctx context.Context, market MarketType, orderID string, ct ExchangeConnectorType) error
+	// OrderConnector 回傳下單的交易所，沒有紀錄時回傳空字串
+	OrderConnector(ctx context.Context, market MarketType, orderID string) (ExchangeConnectorType, error)
+	// Connectors 回傳已設定的交易所
+	Connectors() []ExchangeConnectorType
+}
+
+func (proxy ExchangeApiProxyImpl) orderKey(market MarketType, orderID string) string {
+	return fmt.Sprintf("%v:%v:%v", proxy.config().RedisKeyOrders, market, orderID)
+}
+
+var errNoOrderRouteCache = errors.New("order routes need a redis cache")
+
+func (proxy ExchangeApiProxyImpl) RecordOrder(ctx context.Context, market MarketType, orderID string, ct ExchangeConnectorType) error {
+	if proxy.Cache == nil {
+		return errNoOrderRouteCache
+	}
+	return proxy.Cache.Set(ctx, proxy.orderKey(market, orderID), ct.String(), proxy.config().OrderRouteTTL).Err()
+}
+
+func (proxy ExchangeApiProxyImpl) OrderConnector(ctx context.Context, market MarketType, orderID string) (ExchangeConnectorType, error) {
+	if proxy.Cache == nil {
+		return "", errNoOrderRouteCache
+	}
+	ct, err := proxy.Cache.Get(ctx, proxy.orderKey(market, orderID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return ExchangeConnectorType(ct), err
+}
+
+func (proxy ExchangeApiProxyImpl) Connectors() []ExchangeConnectorType {
+	var connectors []ExchangeConnectorType
+	if proxy.BinanceImpl != nil {
+		connectors = append(connectors, ExchangeConnectorTypeBinance)
+	}
+	if proxy.OKXImpl != nil {
+		connectors = append(connectors, ExchangeConnectorTypeOKX)
+	}
+	return connectors
+}
+
+// recordOrder 記錄剛送出的訂單所在的交易所；訂單已成立，記錄失敗只寫 log。
+func recordOrder(ctx context.Context, proxy ExchangeApiProxy, market MarketType, res ExchangeApiResponse) {
+	order, err := decodeObject(res.Body, toOrderResult)
+	if err != nil {
+		log.Infof("record %v order on %v error: %v", market, res.ConnectorType, err)
+		return
+	}
+	order.ConnectorType = res.ConnectorType
+	recordOrderResult(ctx, proxy, market, order)
+}
+
+func recordOrderResult(ctx context.Context, proxy ExchangeApiProxy, market MarketType, order OrderResult) {
+	router, ok := proxy.(OrderRouter)
+	if !ok {
+		return
+	}
+	err := errors.New("missing orderId")
+	if order.OrderID != "" {
+		err = router.RecordOrder(ctx, market, order.OrderID, order.ConnectorType)
+	}
+	if err != nil {
+		log.Infof("record %v order on %v error: %v", market, order.ConnectorType, err)
+	}
+}
+
+// ErrUnknownOrderRoute 表示找不到訂單所在的交易所，呼叫端需指定 connector。
+var ErrUnknownOrderRoute = errors.New("unknown order route")
+
+// orderConnector 決定 orderID 要送往的交易所：呼叫端指定的 connector 優先，只設定一個交易所時送到該交易所，
+// 其次為 proxy 的紀錄；都沒有時回傳 ErrUnknownOrderRoute，避免送到目前使用但不是下單的交易所。
+func orderConnector(ctx context.Context, proxy ExchangeApiProxy, market MarketType, orderID string, connector ExchangeConnectorType) (*ExchangeConnectorType, error) {
+	if connector != "" {
+		return &connector, nil
+	}
+	router, ok := proxy.(OrderRouter)
+	if !ok {
+		return nil, fmt.Errorf("%v order %v: %w", market, orderID, ErrUnknownOrderRoute)
+	}
+	if connectors := router.Connectors(); len(connectors) == 1 {
+		return &connectors[0], nil
+	}
+	ct, err := router.OrderConnector(ctx, market, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("load %v order %v connector: %w", market, orderID, err)
+	}
+	if ct == "" {
+		return nil, fmt.Errorf("%v order %v: %w", market, orderID, ErrUnknownOrderRoute)
+	}
+	return &ct, nil
+}
+
+// orderConnectors 回傳 OpenOrders 與 CancelAllOrders 要呼叫的交易所：指定 connector 時只呼叫該交易所，
+// 否則呼叫所有已設定的交易所，切換前後留下的掛單都會處理到。
+func orderConnectors(proxy ExchangeApiProxy, connector ExchangeConnectorType) []ExchangeConnectorType {
+	if connector != "" {
+		return []ExchangeConnectorType{connector}
+	}
+	if router, ok := proxy.(OrderRouter); ok {
+		return router.Connectors()
+	}
+	return []ExchangeConnectorType{ExchangeConnectorTypeBinance, ExchangeConnectorTypeOKX}
+}
+
+// invokeEach 依序對每個交易所呼叫 fn，回傳成功的回應；部分交易所失敗時一併回傳合併後的錯誤。
+func invokeEach(ctx context.Context, proxy ExchangeApiProxy, fn func(cType ExchangeConnectorType, connector ExchangeConnector) (ExchangeApiResponse, error), connectors []ExchangeConnectorType) ([]ExchangeApiResponse, error) {
+	var responses []ExchangeApiResponse
+	var errs []error
+	for _, ct := range connectors {
+		ct := ct
+		res, err := proxy.InvokeContext(ctx, fn, &ct, false)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", ct, err))
+			continue
+		}
+		responses = append(responses, res)
+	}
+	return responses, errors.Join(errs...)
+}
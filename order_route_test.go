@@ -0,0 +1,177 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// fakeOrderConnector 只實作訂單相關的方法，其餘方法未實作，呼叫時會 panic。
+type fakeOrderConnector struct {
+	ExchangeConnector
+	name string
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (c *fakeOrderConnector) record(call string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, call)
+}
+
+func (c *fakeOrderConnector) called() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
+func (c *fakeOrderConnector) ok(body string) (ExchangeApiResponse, error) {
+	return ExchangeApiResponse{IsSuccess: true, Body: []byte(body)}, nil
+}
+
+func (c *fakeOrderConnector) IsSystemAbnormal(string) bool { return false }
+
+func (c *fakeOrderConnector) SpotTrade(symbol, side, quantity, price string) (ExchangeApiResponse, error) {
+	c.record("SpotTrade " + quantity + " " + price)
+	return c.ok(`{"symbol":"BTCUSDT","orderId":"` + c.name + `-1","status":"NEW"}`)
+}
+
+func (c *fakeOrderConnector) SpotCancelOrder(symbol, orderID string) (ExchangeApiResponse, error) {
+	c.record("SpotCancelOrder " + orderID)
+	return c.ok(`{"symbol":"BTCUSDT","orderId":"` + orderID + `","status":"CANCELED"}`)
+}
+
+func (c *fakeOrderConnector) SpotAmendOrder(symbol, orderID, quantity, price string) (ExchangeApiResponse, error) {
+	c.record(fmt.Sprintf("SpotAmendOrder %v %q %q", orderID, quantity, price))
+	return c.ok(`{"symbol":"BTCUSDT","orderId":"` + orderID + `"}`)
+}
+
+func (c *fakeOrderConnector) SpotOpenOrders(symbol string) (ExchangeApiResponse, error) {
+	c.record("SpotOpenOrders")
+	return c.ok(`[{"symbol":"BTCUSDT","orderId":"` + c.name + `-open","status":"NEW"}]`)
+}
+
+func newOrderTestProxy(t *testing.T) (ExchangeApiProxyImpl, *fakeOrderConnector, *fakeOrderConnector) {
+	t.Helper()
+	_, client := newTestRedis(t)
+	binance := &fakeOrderConnector{name: "binance"}
+	okx := &fakeOrderConnector{name: "okx"}
+	proxy := NewProxy(
+		WithPrimaryConnector(binance),
+		WithStandbyConnector(okx),
+		WithCache(client),
+		WithResponseNormalizer(ExchangeConnectorTypeOKX, nil),
+	)
+	return proxy, binance, okx
+}
+
+func TestOrderRouteRecordsAndRoutes(t *testing.T) {
+	proxy, binance, okx := newOrderTestProxy(t)
+	api := NewAdapterV2(proxy)
+	ctx := context.Background()
+
+	order, err := api.SpotTrade("BTCUSDT", "BUY", decimal.RequireFromString("1"), decimal.Zero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct, err := proxy.OrderConnector(ctx, MarketTypeSpot, order.OrderID); err != nil || ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("recorded connector = %q, %v", ct, err)
+	}
+
+	// 切換後的訂單記錄在 OKX，未指定 connector 時仍送到 OKX
+	if err := proxy.RecordOrder(ctx, MarketTypeSpot, "okx-7", ExchangeConnectorTypeOKX); err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := api.SpotCancelOrder("BTCUSDT", "okx-7", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.ConnectorType != ExchangeConnectorTypeOKX || len(okx.called()) != 1 {
+		t.Fatalf("cancel routed to %v, okx calls %v", cancelled.ConnectorType, okx.called())
+	}
+	if calls := binance.called(); len(calls) != 1 {
+		t.Fatalf("binance calls = %v, want only the trade", calls)
+	}
+}
+
+func TestOrderRouteUnknownOrder(t *testing.T) {
+	proxy, binance, okx := newOrderTestProxy(t)
+
+	_, err := NewAdapterV2(proxy).SpotCancelOrder("BTCUSDT", "missing", "")
+	if !errors.Is(err, ErrUnknownOrderRoute) {
+		t.Fatalf("v2 err = %v, want ErrUnknownOrderRoute", err)
+	}
+	_, err = NewAdapter(proxy).SpotCancelOrder("BTCUSDT", "missing", "")
+	if !errors.Is(err, ErrUnknownOrderRoute) {
+		t.Fatalf("v1 err = %v, want ErrUnknownOrderRoute", err)
+	}
+	if len(binance.called())+len(okx.called()) != 0 {
+		t.Fatal("unknown order should not reach any connector")
+	}
+
+	if _, err := NewAdapterV2(proxy).SpotCancelOrder("BTCUSDT", "missing", ExchangeConnectorTypeOKX); err != nil {
+		t.Fatalf("explicit connector: %v", err)
+	}
+}
+
+func TestOrderRouteMergesConnectors(t *testing.T) {
+	proxy, _, _ := newOrderTestProxy(t)
+
+	orders, err := NewAdapterV2(proxy).SpotOpenOrders("BTCUSDT", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]ExchangeConnectorType{}
+	for _, o := range orders {
+		got[o.OrderID] = o.ConnectorType
+	}
+	if len(got) != 2 || got["binance-open"] != ExchangeConnectorTypeBinance || got["okx-open"] != ExchangeConnectorTypeOKX {
+		t.Fatalf("orders = %v", got)
+	}
+
+	v1, err := NewAdapter(proxy).SpotOpenOrders("BTCUSDT", "")
+	if err != nil || len(v1) != 2 {
+		t.Fatalf("v1 orders = %v, %v", v1, err)
+	}
+}
+
+type staticSymbolInfo SymbolInfo
+
+func (s staticSymbolInfo) SymbolInfo(context.Context, ExchangeConnectorType, ExchangeConnector, Instrument) (SymbolInfo, error) {
+	return SymbolInfo(s), nil
+}
+
+func TestAmendOrderValidated(t *testing.T) {
+	proxy, binance, _ := newOrderTestProxy(t)
+	info := staticSymbolInfo{Symbol: "BTCUSDT", Filters: []SymbolFilter{
+		{FilterType: "PRICE_FILTER", TickSize: decimal.RequireFromString("0.1")},
+		{FilterType: "LOT_SIZE", StepSize: decimal.RequireFromString("0.01"), MinQty: decimal.RequireFromString("0.01")},
+	}}
+
+	reject := NewAdapterV2(proxy, WithOrderValidator(NewOrderValidator(OrderValidationReject, info)))
+	_, err := reject.SpotAmendOrder("BTCUSDT", "1", decimal.Zero, decimal.RequireFromString("100.05"), ExchangeConnectorTypeBinance)
+	if !errors.Is(err, ErrOrderValidation) {
+		t.Fatalf("err = %v, want validation error", err)
+	}
+	if len(binance.called()) != 0 {
+		t.Fatal("rejected amend reached the connector")
+	}
+
+	round := NewAdapter(proxy, WithOrderValidator(NewOrderValidator(OrderValidationRound, info)))
+	if _, err := round.SpotAmendOrder("BTCUSDT", "1", "0.015", "", ExchangeConnectorTypeBinance); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := round.SpotAmendOrder("BTCUSDT", "1", "", "100.06", ExchangeConnectorTypeBinance); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`SpotAmendOrder 1 "0.01" ""`, `SpotAmendOrder 1 "" "100.1"`}
+	if calls := binance.called(); fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+}
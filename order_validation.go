@@ -0,0 +1,318 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/shopspring/decimal"
+)
+
+// ErrOrderValidation 為所有下單前檢查錯誤的共同原因，可用 errors.Is 判斷；需要細節時以 errors.As 取出 *OrderValidationError。
+var ErrOrderValidation = errors.New("order validation failed")
+
+type OrderRejectReason string
+
+const (
+	OrderRejectInvalid  OrderRejectReason = "invalid"
+	OrderRejectBelowMin OrderRejectReason = "below_min"
+	OrderRejectAboveMax OrderRejectReason = "above_max"
+	// OrderRejectStep 為數量不是 stepSize 的整數倍，或價格不是 tickSize 的整數倍
+	OrderRejectStep OrderRejectReason = "step"
+)
+
+// OrderValidationError 描述違反的交易所限制。Filter 為 LOT_SIZE、MARKET_LOT_SIZE、PRICE_FILTER 或 MIN_NOTIONAL，
+// Field 為 quantity、price 或 notional。
+type OrderValidationError struct {
+	Connector ExchangeConnectorType
+	Symbol    string
+	Filter    string
+	Field     string
+	Reason    OrderRejectReason
+	Value     decimal.Decimal
+	Limit     decimal.Decimal
+}
+
+func (e *OrderValidationError) Error() string {
+	return fmt.Sprintf("order validation failed on %v %v: %v %v %v (%v %v)", e.Connector, e.Symbol, e.Field, e.Value, e.Reason, e.Filter, e.Limit)
+}
+
+func (e *OrderValidationError) Unwrap() error {
+	return ErrOrderValidation
+}
+
+type OrderValidationMode string
+
+const (
+	// OrderValidationRound 把數量無條件捨去到 stepSize、價格調整到 tickSize 後再檢查上下限
+	OrderValidationRound OrderValidationMode = "round"
+	// OrderValidationReject 不調整，不符合 stepSize 或 tickSize 時直接回傳錯誤
+	OrderValidationReject OrderValidationMode = "reject"
+)
+
+type OrderRequest struct {
+	Symbol string
+	// Side 為空時（改單）價格調整到最接近的 tickSize
+	Side string
+	// Price 為零表示市價單，只檢查 MARKET_LOT_SIZE 與 LOT_SIZE
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+	// Amend 為改單：數量或價格為零表示不修改，不檢查該欄位
+	Amend bool
+}
+
+// ValidateOrder 依 info 的 filters 檢查 order，mode 為 OrderValidationRound 時回傳調整後的 order。
+// 限價單的價格以不利於成交的方向調整：買單往下、賣單往上，避免超出呼叫端設定的價格。
+func ValidateOrder(info SymbolInfo, order OrderRequest, mode OrderValidationMode) (OrderRequest, error) {
+	fail := func(filter, field string, reason OrderRejectReason, value, limit decimal.Decimal) error {
+		return &OrderValidationError{Symbol: order.Symbol, Filter: filter, Field: field, Reason: reason, Value: value, Limit: limit}
+	}
+	keepQuantity := order.Amend && order.Quantity.IsZero()
+	if !order.Quantity.IsPositive() && !keepQuantity {
+		return order, fail("", "quantity", OrderRejectInvalid, order.Quantity, decimal.Zero)
+	}
+	if order.Price.IsNegative() {
+		return order, fail("", "price", OrderRejectInvalid, order.Price, decimal.Zero)
+	}
+	market := order.Price.IsZero() && !order.Amend
+
+	if f, ok := info.Filter("PRICE_FILTER"); ok && !order.Price.IsZero() {
+		if f.TickSize.IsPositive() && !isMultiple(order.Price.Sub(f.MinPrice), f.TickSize) {
+			if mode != OrderValidationRound {
+				return order, fail(f.FilterType, "price", OrderRejectStep, order.Price, f.TickSize)
+			}
+			if order.Side == "" {
+				order.Price = roundToNearestStep(order.Price, f.MinPrice, f.TickSize)
+			} else {
+				order.Price = roundToStep(order.Price, f.MinPrice, f.TickSize, strings.EqualFold(order.Side, "SELL"))
+			}
+		}
+		if f.MinPrice.IsPositive() && order.Price.LessThan(f.MinPrice) {
+			return order, fail(f.FilterType, "price", OrderRejectBelowMin, order.Price, f.MinPrice)
+		}
+		if f.MaxPrice.IsPositive() && order.Price.GreaterThan(f.MaxPrice) {
+			return order, fail(f.FilterType, "price", OrderRejectAboveMax, order.Price, f.MaxPrice)
+		}
+	}
+
+	lotFilters := []string{"LOT_SIZE"}
+	switch {
+	case keepQuantity:
+		lotFilters = nil
+	case market:
+		lotFilters = []string{"MARKET_LOT_SIZE", "LOT_SIZE"}
+	}
+	for _, name := range lotFilters {
+		f, ok := info.Filter(name)
+		if !ok {
+			continue
+		}
+		if f.StepSize.IsPositive() && !isMultiple(order.Quantity.Sub(f.MinQty), f.StepSize) {
+			if mode != OrderValidationRound {
+				return order, fail(f.FilterType, "quantity", OrderRejectStep, order.Quantity, f.StepSize)
+			}
+			order.Quantity = roundToStep(order.Quantity, f.MinQty, f.StepSize, false)
+		}
+		if order.Quantity.LessThan(f.MinQty) || !order.Quantity.IsPositive() {
+			return order, fail(f.FilterType, "quantity", OrderRejectBelowMin, order.Quantity, f.MinQty)
+		}
+		if f.MaxQty.IsPositive() && order.Quantity.GreaterThan(f.MaxQty) {
+			return order, fail(f.FilterType, "quantity", OrderRejectAboveMax, order.Quantity, f.MaxQty)
+		}
+	}
+
+	// 市價單沒有價格，名目價值交由交易所檢查；改單只修改一個欄位時也無法計算
+	if !order.Price.IsZero() && !keepQuantity {
+		for _, name := range []string{"MIN_NOTIONAL", "NOTIONAL"} {
+			f, ok := info.Filter(name)
+			if !ok || !f.Notional.IsPositive() {
+				continue
+			}
+			if notional := order.Price.Mul(order.Quantity); notional.LessThan(f.Notional) {
+				return order, fail(f.FilterType, "notional", OrderRejectBelowMin, notional, f.Notional)
+			}
+		}
+	}
+	return order, nil
+}
+
+func isMultiple(v, step decimal.Decimal) bool {
+	return v.Mod(step).IsZero()
+}
+
+// roundToStep 以 base 為起點把 v 調整到 step 的整數倍，up 為 true 時無條件進位，否則捨去。
+func roundToStep(v, base, step decimal.Decimal, up bool) decimal.Decimal {
+	n := v.Sub(base).Div(step)
+	if up {
+		n = n.Ceil()
+	} else {
+		n = n.Floor()
+	}
+	return base.Add(n.Mul(step))
+}
+
+func roundToNearestStep(v, base, step decimal.Decimal) decimal.Decimal {
+	return base.Add(v.Sub(base).Div(step).Round(0).Mul(step))
+}
+
+// SymbolInfoSource 提供下單前檢查使用的交易對限制，connector 為實際要送出訂單的交易所。
+type SymbolInfoSource interface {
+	SymbolInfo(ctx context.Context, ct ExchangeConnectorType, connector ExchangeConnector, inst Instrument) (SymbolInfo, error)
+}
+
+// ConnectorSymbolInfoSource 每次都向 connector 查詢：合約使用 FuturesExchangeInfo，
+// 現貨沒有 exchange info 可用，以 GetSpotPrecision 的精度產生 PRICE_FILTER 與 LOT_SIZE。
+// 查詢在下單前同步進行且不經過 proxy，每筆訂單會多一次交易所呼叫，建議搭配 CachedSymbolInfoSource。
+type ConnectorSymbolInfoSource struct {
+	Instruments *InstrumentRegistry
+	// Normalizers 未設定的交易所使用 DefaultResponseNormalizers
+	Normalizers map[ExchangeConnectorType]ResponseNormalizer
+}
+
+func (s ConnectorSymbolInfoSource) instruments() *InstrumentRegistry {
+	if s.Instruments == nil {
+		return DefaultInstrumentRegistry
+	}
+	return s.Instruments
+}
+
+func (s ConnectorSymbolInfoSource) normalize(ct ExchangeConnectorType, method string, res ExchangeApiResponse, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	if !res.IsSuccess {
+		return nil, fmt.Errorf("%v %v failed: %v", ct, method, res.FailureCode)
+	}
+	res.ConnectorType = ct
+	res, err = normalizeResponse(s.Normalizers, method, res)
+	return res.Body, err
+}
+
+func (s ConnectorSymbolInfoSource) SymbolInfo(_ context.Context, ct ExchangeConnectorType, connector ExchangeConnector, inst Instrument) (SymbolInfo, error) {
+	instruments := s.instruments()
+	native, err := instruments.Native(ct, inst)
+	if err != nil {
+		return SymbolInfo{}, err
+	}
+	if inst.Market == MarketTypeSpot {
+		res, err := connector.GetSpotPrecision(inst.Base)
+		body, err := s.normalize(ct, "GetSpotPrecision", res, err)
+		if err != nil {
+			return SymbolInfo{}, err
+		}
+		return decodeObject(body, func(v interface{}) (SymbolInfo, error) {
+			r := newPayloadReader(v)
+			price, qty := r.int64("pricePrecision"), r.int64("quantityPrecision")
+			return SymbolInfo{
+				Symbol:            native,
+				BaseAsset:         inst.Base,
+				QuoteAsset:        inst.Quote,
+				PricePrecision:    int32(price),
+				QuantityPrecision: int32(qty),
+				Filters: []SymbolFilter{
+					{FilterType: "PRICE_FILTER", TickSize: decimal.New(1, -int32(price))},
+					{FilterType: "LOT_SIZE", StepSize: decimal.New(1, -int32(qty))},
+				},
+			}, r.err
+		})
+	}
+	res, err := connector.FuturesExchangeInfo(native)
+	body, err := s.normalize(ct, "FuturesExchangeInfo", res, err)
+	if err != nil {
+		return SymbolInfo{}, err
+	}
+	symbol, err := instruments.Native(ExchangeConnectorTypeBinance, inst)
+	if err != nil {
+		return SymbolInfo{}, err
+	}
+	return findSymbolInfo(body, symbol)
+}
+
+// OrderValidator 在訂單送到目標交易所前依該交易所的限制檢查，SymbolInfoSource 失敗時不阻擋下單，交由交易所檢查。
+type OrderValidator struct {
+	Mode   OrderValidationMode
+	Source SymbolInfoSource
+}
+
+func NewOrderValidator(mode OrderValidationMode, source SymbolInfoSource) *OrderValidator {
+	return &OrderValidator{Mode: mode, Source: source}
+}
+
+// Validate 回傳調整後的數量與價格；symbol 為呼叫端傳入的 Binance 格式或標準 ID。
+func (v *OrderValidator) Validate(ctx context.Context, instruments *InstrumentRegistry, ct ExchangeConnectorType, connector ExchangeConnector, symbol string, market MarketType, side string, quantity, price decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	order, err := v.validate(ctx, instruments, ct, connector, market, OrderRequest{Symbol: symbol, Side: side, Quantity: quantity, Price: price})
+	return order.Quantity, order.Price, err
+}
+
+// ValidateAmend 檢查改單的數量與價格，零值表示不修改。改單沒有買賣方向，OrderValidationRound 把價格調整到最接近的 tickSize。
+func (v *OrderValidator) ValidateAmend(ctx context.Context, instruments *InstrumentRegistry, ct ExchangeConnectorType, connector ExchangeConnector, symbol string, market MarketType, quantity, price decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	order, err := v.validate(ctx, instruments, ct, connector, market, OrderRequest{Symbol: symbol, Quantity: quantity, Price: price, Amend: true})
+	return order.Quantity, order.Price, err
+}
+
+func (v *OrderValidator) validate(ctx context.Context, instruments *InstrumentRegistry, ct ExchangeConnectorType, connector ExchangeConnector, market MarketType, order OrderRequest) (OrderRequest, error) {
+	if v == nil || v.Source == nil {
+		return order, nil
+	}
+	inst, err := instruments.Resolve(order.Symbol, market)
+	if err != nil {
+		log.Infof("order validation skipped for %v: %v", order.Symbol, err)
+		return order, nil
+	}
+	info, err := v.Source.SymbolInfo(ctx, ct, connector, inst)
+	if err != nil {
+		log.Infof("order validation skipped for %v on %v: %v", order.Symbol, ct, err)
+		return order, nil
+	}
+	order, err = ValidateOrder(info, order, v.Mode)
+	var validationErr *OrderValidationError
+	if errors.As(err, &validationErr) {
+		validationErr.Connector = ct
+	}
+	return order, err
+}
+
+// validateOrderStrings 供以字串傳入數量與價格的 ExchangeApi 使用，price 為空字串表示市價單。
+func (v *OrderValidator) validateOrderStrings(ctx context.Context, instruments *InstrumentRegistry, ct ExchangeConnectorType, connector ExchangeConnector, symbol string, market MarketType, side, quantity, price string) (string, string, error) {
+	return v.validateStrings(ct, symbol, quantity, price, func(qty, px decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+		return v.Validate(ctx, instruments, ct, connector, symbol, market, side, qty, px)
+	})
+}
+
+// validateAmendStrings 為 validateOrderStrings 的改單版本，quantity、price 為空字串表示不修改。
+func (v *OrderValidator) validateAmendStrings(ctx context.Context, instruments *InstrumentRegistry, ct ExchangeConnectorType, connector ExchangeConnector, symbol string, market MarketType, quantity, price string) (string, string, error) {
+	return v.validateStrings(ct, symbol, quantity, price, func(qty, px decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+		return v.ValidateAmend(ctx, instruments, ct, connector, symbol, market, qty, px)
+	})
+}
+
+func (v *OrderValidator) validateStrings(ct ExchangeConnectorType, symbol, quantity, price string, validate func(qty, px decimal.Decimal) (decimal.Decimal, decimal.Decimal, error)) (string, string, error) {
+	if v == nil || v.Source == nil {
+		return quantity, price, nil
+	}
+	parse := func(field, s string) (decimal.Decimal, error) {
+		if s == "" {
+			return decimal.Zero, nil
+		}
+		d, err := decimal.NewFromString(s)
+		if err != nil {
+			return d, &OrderValidationError{Connector: ct, Symbol: symbol, Field: field, Reason: OrderRejectInvalid}
+		}
+		return d, nil
+	}
+	qty, err := parse("quantity", quantity)
+	if err != nil {
+		return "", "", err
+	}
+	px, err := parse("price", price)
+	if err != nil {
+		return "", "", err
+	}
+	qty, px, err = validate(qty, px)
+	if err != nil {
+		return "", "", err
+	}
+	return orderPrice(qty), orderPrice(px), nil
+}
@@ -0,0 +1,47 @@
+package failover
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDefaultOrderValidatorOptIn(t *testing.T) {
+	proxy := ExchangeApiProxyImpl{}
+	if v := newAdapterOptions(proxy, nil).validator; v != nil {
+		t.Fatalf("validator without cache = %+v, want nil", v)
+	}
+	cache := NewExchangeInfoCache(ExchangeInfoCacheConfig{})
+	if v := newAdapterOptions(proxy, []AdapterOption{WithExchangeInfoCache(cache)}).validator; v == nil || v.Mode != OrderValidationReject {
+		t.Fatalf("validator with cache = %+v, want reject", v)
+	}
+	if v := newAdapterOptions(proxy, []AdapterOption{WithOrderValidation(OrderValidationRound)}).validator; v == nil || v.Mode != OrderValidationRound {
+		t.Fatalf("validator = %+v, want round", v)
+	}
+}
+
+func TestValidateOrderRejectDoesNotModify(t *testing.T) {
+	info := SymbolInfo{Symbol: "BTCUSDT", Filters: []SymbolFilter{
+		{FilterType: "PRICE_FILTER", TickSize: decimal.RequireFromString("0.1")},
+		{FilterType: "LOT_SIZE", StepSize: decimal.RequireFromString("0.001"), MinQty: decimal.RequireFromString("0.001")},
+	}}
+	order := OrderRequest{Symbol: "BTCUSDT", Side: "BUY", Quantity: decimal.RequireFromString("0.0015"), Price: decimal.RequireFromString("100.05")}
+
+	got, err := ValidateOrder(info, order, OrderValidationReject)
+	var validationErr *OrderValidationError
+	if !errors.As(err, &validationErr) || validationErr.Reason != OrderRejectStep {
+		t.Fatalf("err = %v, want step rejection", err)
+	}
+	if !got.Price.Equal(order.Price) || !got.Quantity.Equal(order.Quantity) {
+		t.Fatalf("reject mode modified order: %+v", got)
+	}
+
+	got, err = ValidateOrder(info, order, OrderValidationRound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Price.String() != "100" || got.Quantity.String() != "0.001" {
+		t.Fatalf("rounded = %v %v", got.Price, got.Quantity)
+	}
+}
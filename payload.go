@@ -0,0 +1,465 @@
+package failover
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// payloadReader 從 ExchangeApiResponse.Body 的 JSON 物件讀取欄位；交易所常把數字放在字串中，
+// 讀取時兩種都接受，缺少的欄位為零值，格式錯誤時記下第一個錯誤。
+type payloadReader struct {
+	m   map[string]interface{}
+	err error
+}
+
+func decodePayload(body []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func newPayloadReader(v interface{}) *payloadReader {
+	m, _ := v.(map[string]interface{})
+	return &payloadReader{m: m}
+}
+
+func (r *payloadReader) value(keys []string) (string, interface{}) {
+	for _, key := range keys {
+		if v, ok := r.m[key]; ok && v != nil {
+			return key, v
+		}
+	}
+	return "", nil
+}
+
+func (r *payloadReader) fail(key string, err error) {
+	if r.err == nil {
+		r.err = fmt.Errorf("field %v: %w", key, err)
+	}
+}
+
+func (r *payloadReader) str(keys ...string) string {
+	_, v := r.value(keys)
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func (r *payloadReader) decimal(keys ...string) decimal.Decimal {
+	key, v := r.value(keys)
+	s := ""
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	}
+	if s == "" {
+		return decimal.Zero
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		r.fail(key, err)
+		return decimal.Zero
+	}
+	return d
+}
+
+func (r *payloadReader) int64(keys ...string) int64 {
+	key, v := r.value(keys)
+	s := ""
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	}
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		r.fail(key, err)
+	}
+	return n
+}
+
+func (r *payloadReader) bool(keys ...string) bool {
+	_, v := r.value(keys)
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// time 接受毫秒 timestamp 或 "2006-01-02 15:04:05"（UTC）格式的字串。
+func (r *payloadReader) time(keys ...string) time.Time {
+	key, v := r.value(keys)
+	s := ""
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	}
+	if s == "" {
+		return time.Time{}
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if ms == 0 {
+			return time.Time{}
+		}
+		return time.UnixMilli(ms).UTC()
+	}
+	t, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		r.fail(key, err)
+	}
+	return t
+}
+
+func (r *payloadReader) list(key string) []interface{} {
+	l, _ := r.m[key].([]interface{})
+	return l
+}
+
+// decodeList 把 JSON 陣列中的每個元素以 fn 轉換。
+func decodeList[T any](body []byte, fn func(v interface{}) (T, error)) ([]T, error) {
+	raw := []interface{}{}
+	if err := decodePayload(body, &raw); err != nil {
+		return nil, err
+	}
+	return convertList(raw, fn)
+}
+
+func convertList[T any](raw []interface{}, fn func(v interface{}) (T, error)) ([]T, error) {
+	out := make([]T, 0, len(raw))
+	for i, v := range raw {
+		item, err := fn(v)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func decodeObject[T any](body []byte, fn func(v interface{}) (T, error)) (T, error) {
+	var raw interface{}
+	if err := decodePayload(body, &raw); err != nil {
+		var zero T
+		return zero, err
+	}
+	return fn(raw)
+}
+
+var klineFields = []string{"openTime", "open", "high", "low", "close", "volume", "closeTime", "quoteVolume", "trades"}
+
+// toKline 轉換標準格式的 K 線物件，或 Binance 原始的
+// [openTime, open, high, low, close, volume, closeTime, quoteVolume, trades, ...] 陣列。
+func toKline(v interface{}) (Kline, error) {
+	if row, ok := v.([]interface{}); ok {
+		if len(row) < 6 {
+			return Kline{}, fmt.Errorf("kline has %d fields", len(row))
+		}
+		m := map[string]interface{}{}
+		for i, key := range klineFields {
+			if i < len(row) {
+				m[key] = row[i]
+			}
+		}
+		v = m
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return Kline{}, fmt.Errorf("invalid kline")
+	}
+	r := newPayloadReader(v)
+	k := Kline{
+		OpenTime:    r.time("openTime"),
+		Open:        r.decimal("open"),
+		High:        r.decimal("high"),
+		Low:         r.decimal("low"),
+		Close:       r.decimal("close"),
+		Volume:      r.decimal("volume"),
+		CloseTime:   r.time("closeTime"),
+		QuoteVolume: r.decimal("quoteVolume"),
+		Trades:      r.int64("trades"),
+	}
+	return k, r.err
+}
+
+func toOrderResult(v interface{}) (OrderResult, error) {
+	r := newPayloadReader(v)
+	o := OrderResult{
+		Symbol:        r.str("symbol"),
+		OrderID:       r.str("orderId"),
+		ClientOrderID: r.str("clientOrderId"),
+		Side:          r.str("side"),
+		Type:          r.str("type"),
+		Status:        r.str("status"),
+		Price:         r.decimal("price"),
+		AvgPrice:      r.decimal("avgPrice"),
+		OrigQty:       r.decimal("origQty"),
+		ExecutedQty:   r.decimal("executedQty"),
+		CumQuote:      r.decimal("cumQuote", "cummulativeQuoteQty"),
+		UpdateTime:    r.time("updateTime", "transactTime", "time"),
+	}
+	return o, r.err
+}
+
+func toBalance(v interface{}) (Balance, error) {
+	r := newPayloadReader(v)
+	b := Balance{
+		Asset:       r.str("asset"),
+		Free:        r.decimal("free"),
+		Locked:      r.decimal("locked"),
+		Freeze:      r.decimal("freeze"),
+		Withdrawing: r.decimal("withdrawing"),
+	}
+	return b, r.err
+}
+
+func toPosition(v interface{}) (Position, error) {
+	r := newPayloadReader(v)
+	p := Position{
+		Symbol:           r.str("symbol"),
+		PositionSide:     r.str("positionSide"),
+		PositionAmt:      r.decimal("positionAmt"),
+		EntryPrice:       r.decimal("entryPrice"),
+		MarkPrice:        r.decimal("markPrice"),
+		UnrealizedProfit: r.decimal("unRealizedProfit", "unrealizedProfit"),
+		LiquidationPrice: r.decimal("liquidationPrice"),
+		Leverage:         r.decimal("leverage"),
+		MarginType:       r.str("marginType"),
+		UpdateTime:       r.time("updateTime"),
+	}
+	return p, r.err
+}
+
+func toFuturesAsset(v interface{}) (FuturesAsset, error) {
+	r := newPayloadReader(v)
+	a := FuturesAsset{
+		Asset:            r.str("asset"),
+		WalletBalance:    r.decimal("walletBalance"),
+		UnrealizedProfit: r.decimal("unrealizedProfit"),
+		MarginBalance:    r.decimal("marginBalance"),
+		AvailableBalance: r.decimal("availableBalance"),
+	}
+	return a, r.err
+}
+
+func toFuturesAccount(v interface{}) (FuturesAccount, error) {
+	r := newPayloadReader(v)
+	a := FuturesAccount{
+		TotalWalletBalance:    r.decimal("totalWalletBalance"),
+		TotalUnrealizedProfit: r.decimal("totalUnrealizedProfit"),
+		TotalMarginBalance:    r.decimal("totalMarginBalance"),
+		AvailableBalance:      r.decimal("availableBalance"),
+		MaxWithdrawAmount:     r.decimal("maxWithdrawAmount"),
+	}
+	if r.err != nil {
+		return FuturesAccount{}, r.err
+	}
+	var err error
+	if a.Assets, err = convertList(r.list("assets"), toFuturesAsset); err != nil {
+		return FuturesAccount{}, fmt.Errorf("assets: %w", err)
+	}
+	if a.Positions, err = convertList(r.list("positions"), toPosition); err != nil {
+		return FuturesAccount{}, fmt.Errorf("positions: %w", err)
+	}
+	return a, nil
+}
+
+func toBill(v interface{}) (Bill, error) {
+	r := newPayloadReader(v)
+	b := Bill{
+		Symbol:  r.str("symbol"),
+		Type:    r.str("incomeType", "type"),
+		Amount:  r.decimal("income", "amount"),
+		Asset:   r.str("asset"),
+		Info:    r.str("info"),
+		TranID:  r.str("tranId"),
+		TradeID: r.str("tradeId"),
+		Time:    r.time("time"),
+	}
+	return b, r.err
+}
+
+func toTrade(v interface{}) (Trade, error) {
+	r := newPayloadReader(v)
+	t := Trade{
+		Symbol:          r.str("symbol"),
+		ID:              r.str("id"),
+		OrderID:         r.str("orderId"),
+		Side:            r.str("side"),
+		Price:           r.decimal("price"),
+		Qty:             r.decimal("qty"),
+		QuoteQty:        r.decimal("quoteQty"),
+		Commission:      r.decimal("commission"),
+		CommissionAsset: r.str("commissionAsset"),
+		RealizedPnl:     r.decimal("realizedPnl"),
+		IsBuyer:         r.bool("isBuyer", "buyer"),
+		IsMaker:         r.bool("isMaker", "maker"),
+		Time:            r.time("time"),
+	}
+	if t.Side == "" {
+		t.Side = "SELL"
+		if t.IsBuyer {
+			t.Side = "BUY"
+		}
+	}
+	return t, r.err
+}
+
+func toCommission(v interface{}) (Commission, error) {
+	r := newPayloadReader(v)
+	c := Commission{
+		Symbol:          r.str("symbol"),
+		MakerCommission: r.decimal("makerCommission"),
+		TakerCommission: r.decimal("takerCommission"),
+	}
+	return c, r.err
+}
+
+func toTransfer(v interface{}) (Transfer, error) {
+	r := newPayloadReader(v)
+	t := Transfer{
+		TranID: r.str("tranId"),
+		Asset:  r.str("asset"),
+		Amount: r.decimal("amount"),
+		Type:   r.str("type"),
+		Status: r.str("status"),
+		Time:   r.time("timestamp", "time"),
+	}
+	return t, r.err
+}
+
+func toWithdrawal(v interface{}) (Withdrawal, error) {
+	r := newPayloadReader(v)
+	w := Withdrawal{
+		ID:           r.str("id"),
+		Coin:         r.str("coin"),
+		Network:      r.str("network"),
+		Address:      r.str("address"),
+		Amount:       r.decimal("amount"),
+		Fee:          r.decimal("transactionFee"),
+		TxID:         r.str("txId"),
+		Status:       int(r.int64("status")),
+		ApplyTime:    r.time("applyTime"),
+		CompleteTime: r.time("completeTime"),
+	}
+	return w, r.err
+}
+
+func toCoinNetwork(v interface{}) (CoinNetwork, error) {
+	r := newPayloadReader(v)
+	n := CoinNetwork{
+		Network:                 r.str("network"),
+		Name:                    r.str("name"),
+		IsDefault:               r.bool("isDefault"),
+		DepositEnable:           r.bool("depositEnable"),
+		WithdrawEnable:          r.bool("withdrawEnable"),
+		WithdrawFee:             r.decimal("withdrawFee"),
+		WithdrawMin:             r.decimal("withdrawMin"),
+		WithdrawMax:             r.decimal("withdrawMax"),
+		WithdrawIntegerMultiple: r.decimal("withdrawIntegerMultiple"),
+	}
+	return n, r.err
+}
+
+func toCoinConfig(v interface{}) (CoinConfig, error) {
+	r := newPayloadReader(v)
+	c := CoinConfig{
+		Coin:   r.str("coin"),
+		Name:   r.str("name"),
+		Free:   r.decimal("free"),
+		Locked: r.decimal("locked"),
+	}
+	if r.err != nil {
+		return CoinConfig{}, r.err
+	}
+	networks, err := convertList(r.list("networkList"), toCoinNetwork)
+	if err != nil {
+		return CoinConfig{}, fmt.Errorf("networkList: %w", err)
+	}
+	c.Networks = networks
+	return c, nil
+}
+
+func toTicker(v interface{}) (Ticker, error) {
+	r := newPayloadReader(v)
+	t := Ticker{
+		Symbol: r.str("symbol"),
+		Price:  r.decimal("price"),
+		Time:   r.time("time"),
+	}
+	return t, r.err
+}
+
+func toSymbolFilter(v interface{}) (SymbolFilter, error) {
+	r := newPayloadReader(v)
+	f := SymbolFilter{
+		FilterType: r.str("filterType"),
+		MinPrice:   r.decimal("minPrice"),
+		MaxPrice:   r.decimal("maxPrice"),
+		TickSize:   r.decimal("tickSize"),
+		MinQty:     r.decimal("minQty"),
+		MaxQty:     r.decimal("maxQty"),
+		StepSize:   r.decimal("stepSize"),
+		Notional:   r.decimal("notional", "minNotional"),
+	}
+	return f, r.err
+}
+
+func toSymbolInfo(v interface{}) (SymbolInfo, error) {
+	r := newPayloadReader(v)
+	s := SymbolInfo{
+		Symbol:            r.str("symbol"),
+		BaseAsset:         r.str("baseAsset"),
+		QuoteAsset:        r.str("quoteAsset"),
+		Status:            r.str("status"),
+		ContractType:      r.str("contractType"),
+		PricePrecision:    int32(r.int64("pricePrecision")),
+		QuantityPrecision: int32(r.int64("quantityPrecision")),
+	}
+	if r.err != nil {
+		return SymbolInfo{}, r.err
+	}
+	filters, err := convertList(r.list("filters"), toSymbolFilter)
+	if err != nil {
+		return SymbolInfo{}, fmt.Errorf("filters: %w", err)
+	}
+	s.Filters = filters
+	return s, nil
+}
+
+// findSymbolInfo 從單一交易對的物件，或含 symbols 清單的完整 exchange info 中取出 symbol。
+func findSymbolInfo(body []byte, symbol string) (SymbolInfo, error) {
+	return decodeObject(body, func(v interface{}) (SymbolInfo, error) {
+		symbols := newPayloadReader(v).list("symbols")
+		if symbols == nil {
+			return toSymbolInfo(v)
+		}
+		for _, s := range symbols {
+			if newPayloadReader(s).str("symbol") == symbol {
+				return toSymbolInfo(s)
+			}
+		}
+		return SymbolInfo{}, fmt.Errorf("symbol %v not found", symbol)
+	})
+}
@@ -0,0 +1,220 @@
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// pinExpiryGrace 為 pin key 在 ExpiresAt 之後額外保留的時間，讓 instance 有機會發現 pin
+// 到期並送出結束告警，而不是被 Redis 靜默刪除。
+const pinExpiryGrace = 10 * time.Minute
+
+// ConnectorPin 為人工指定的交易所，期間內覆蓋自動切換與切回的決策。
+type ConnectorPin struct {
+	Connector ExchangeConnectorType `json:"connector"`
+	Operator  string                `json:"operator"`
+	Reason    string                `json:"reason"`
+	CreatedAt time.Time             `json:"createdAt"`
+	ExpiresAt time.Time             `json:"expiresAt"`
+
+	raw string
+}
+
+func (p ConnectorPin) Expired(now time.Time) bool {
+	return !now.Before(p.ExpiresAt)
+}
+
+// Pin 回傳 Redis 上的 pin（可能已到期，由呼叫端判斷），沒有 pin 時為 nil。
+func (s *StateStore) Pin(ctx context.Context) (*ConnectorPin, error) {
+	raw, err := s.cache.Get(ctx, s.config.RedisKeyPin).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodePin(raw)
+}
+
+func decodePin(raw string) (*ConnectorPin, error) {
+	pin := ConnectorPin{}
+	if err := json.Unmarshal([]byte(raw), &pin); err != nil {
+		return nil, fmt.Errorf("decode pin: %w", err)
+	}
+	pin.raw = raw
+	return &pin, nil
+}
+
+// SetPin 在 generation 仍為 expected 時寫入 pin，回傳新的 generation。
+func (s *StateStore) SetPin(ctx context.Context, expected int64, pin ConnectorPin) (int64, error) {
+	ttl := time.Until(pin.ExpiresAt)
+	if ttl <= 0 {
+		return 0, fmt.Errorf("pin already expired at %v", pin.ExpiresAt)
+	}
+	data, err := json.Marshal(pin)
+	if err != nil {
+		return 0, err
+	}
+	return s.casWrite(ctx, expected, s.config.RedisKeyPin, string(data), ttl+pinExpiryGrace, "pin")
+}
+
+func (s *StateStore) ClearPin(ctx context.Context, expected int64) (int64, error) {
+	return s.casWrite(ctx, expected, s.config.RedisKeyPin, "", 0, "pin")
+}
+
+var clearPinIfScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// clearPinIf 只在 pin 仍是讀到的那一筆時刪除並遞增 generation，回傳是否由本次呼叫刪除；
+// 多個 instance 同時發現到期時只有一個會送出告警。
+func (s *StateStore) clearPinIf(ctx context.Context, pin ConnectorPin) (bool, error) {
+	if s.slotErr != nil {
+		return false, s.slotErr
+	}
+	gen, err := clearPinIfScript.Run(ctx, s.cache, []string{s.config.RedisKeyPin, s.config.RedisKeyGeneration}, pin.raw).Int64()
+	if err != nil {
+		return false, err
+	}
+	if gen > 0 {
+		s.changed(ctx, "pin")
+	}
+	return gen > 0, nil
+}
+
+// activePin 回傳仍有效的 pin，並處理到期的 pin。
+func (proxy ExchangeApiProxyImpl) activePin(ctx context.Context, state *StateStore) (*ConnectorPin, error) {
+	pin, err := state.Pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return proxy.resolvePin(ctx, state, pin)
+}
+
+// resolvePin 處理已讀取的 pin：未到期時原樣回傳，到期時清除並回傳 nil。
+func (proxy ExchangeApiProxyImpl) resolvePin(ctx context.Context, state *StateStore, pin *ConnectorPin) (*ConnectorPin, error) {
+	if pin == nil {
+		return nil, nil
+	}
+	if !pin.Expired(time.Now()) {
+		return pin, nil
+	}
+
+	cleared, err := state.clearPinIf(ctx, *pin)
+	if err != nil {
+		return nil, err
+	}
+	if cleared {
+		nowConnector, err := state.Connector(ctx)
+		if err != nil {
+			return nil, err
+		}
+		proxy.recordEvent(ctx, FailoverEvent{
+			Type:     FailoverEventPinExpired,
+			From:     pin.Connector,
+			To:       nowConnector,
+			Operator: pin.Operator,
+			Reason:   pin.Reason,
+		})
+		proxy.sendAlert(ctx, AlertTemplatePinExpired, pin.Connector.String(), AlertData{
+			From:     pin.Connector,
+			To:       connectorOrPrimary(nowConnector),
+			End:      pin.ExpiresAt,
+			Operator: pin.Operator,
+			Reason:   pin.Reason,
+		})
+	}
+	return nil, nil
+}
+
+// Pin 在 ttl 內把所有未指定交易所的呼叫固定到 ct，所有 instance 共用；
+// 期間內錯誤仍會計數，但不會自動切換或切回。
+func (proxy ExchangeApiProxyImpl) Pin(ctx context.Context, ct ExchangeConnectorType, ttl time.Duration, operator, reason string) error {
+	if _, err := ParseConnectorType(ct.String()); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("pin ttl must be positive")
+	}
+	now := time.Now()
+	pin := ConnectorPin{
+		Connector: ct,
+		Operator:  operator,
+		Reason:    reason,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	state := proxy.state()
+	nowConnector, gen, err := proxy.forceWrite(ctx, state, func(expected int64) (int64, error) {
+		return state.SetPin(ctx, expected, pin)
+	})
+	if err != nil {
+		return err
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:       FailoverEventPinned,
+		From:       nowConnector,
+		To:         ct,
+		Generation: gen,
+		Operator:   operator,
+		Reason:     reason,
+	})
+	proxy.sendAlert(ctx, AlertTemplatePinned, ct.String(), AlertData{
+		From:     connectorOrPrimary(nowConnector),
+		To:       ct,
+		Start:    pin.CreatedAt,
+		End:      pin.ExpiresAt,
+		Operator: operator,
+		Reason:   reason,
+	})
+	return nil
+}
+
+func (proxy ExchangeApiProxyImpl) Unpin(ctx context.Context, operator, reason string) error {
+	state := proxy.state()
+	pin, err := state.Pin(ctx)
+	if err != nil {
+		return err
+	}
+	if pin == nil {
+		return nil
+	}
+	nowConnector, gen, err := proxy.forceWrite(ctx, state, func(expected int64) (int64, error) {
+		return state.ClearPin(ctx, expected)
+	})
+	if err != nil {
+		return err
+	}
+
+	proxy.recordEvent(ctx, FailoverEvent{
+		Type:       FailoverEventUnpinned,
+		From:       pin.Connector,
+		To:         nowConnector,
+		Generation: gen,
+		Operator:   operator,
+		Reason:     reason,
+	})
+	proxy.sendAlert(ctx, AlertTemplateUnpinned, pin.Connector.String(), AlertData{
+		From:     pin.Connector,
+		To:       connectorOrPrimary(nowConnector),
+		Operator: operator,
+		Reason:   reason,
+	})
+	return nil
+}
+
+func connectorOrPrimary(ct ExchangeConnectorType) ExchangeConnectorType {
+	if ct == "" {
+		return ExchangeConnectorTypeBinance
+	}
+	return ct
+}
@@ -0,0 +1,90 @@
+package failover
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPinBlocksAutoSwitch(t *testing.T) {
+	ctx := context.Background()
+	ft := newFailoverTest(t)
+	if err := ft.proxy.Pin(ctx, ExchangeConnectorTypeBinance, time.Hour, "alice", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	ft.binance.fail("-1001")
+	ft.failures(t, nil, 5)
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeBinance)
+	}
+	if got := ft.events(t, FailoverEventSwitch); len(got) != 0 {
+		t.Fatalf("switch events while pinned = %+v", got)
+	}
+	if ft.binance.called() != 5 || ft.okx.called() != 0 {
+		t.Fatalf("calls = binance %v, okx %v", ft.binance.called(), ft.okx.called())
+	}
+	// pin 期間仍計數
+	records, err := ft.proxy.state().FailureWindow(ctx, ExchangeConnectorTypeBinance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("failure window = %v records, want 5", len(records))
+	}
+
+	// 解除後下一次錯誤依既有的錯誤視窗切換
+	if err := ft.proxy.Unpin(ctx, "alice", "done"); err != nil {
+		t.Fatal(err)
+	}
+	ft.failures(t, nil, 1)
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("connector after unpin = %v, want %v", ct, ExchangeConnectorTypeOKX)
+	}
+}
+
+func TestPinBlocksFailback(t *testing.T) {
+	ctx := context.Background()
+	ft := newFailoverTest(t)
+	if _, err := ft.proxy.state().Transition(ctx, 0, ExchangeConnectorTypeOKX, -1); err != nil {
+		t.Fatal(err)
+	}
+	if err := ft.proxy.Pin(ctx, ExchangeConnectorTypeOKX, time.Hour, "alice", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ct, err := ft.ticker(nil); err != nil || ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("ticker = %v, %v, want %v", ct, err, ExchangeConnectorTypeOKX)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("failed back while pinned: connector = %v", ct)
+	}
+
+	if err := ft.proxy.Unpin(ctx, "alice", "done"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ft.ticker(nil); err != nil {
+		t.Fatal(err)
+	}
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeBinance {
+		t.Fatalf("connector after unpin = %v, want %v", ct, ExchangeConnectorTypeBinance)
+	}
+}
+
+func TestExpiredPinAllowsSwitch(t *testing.T) {
+	ctx := context.Background()
+	ft := newFailoverTest(t)
+	if err := ft.proxy.Pin(ctx, ExchangeConnectorTypeBinance, time.Millisecond, "alice", "test"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	ft.binance.fail("-1001")
+	ft.failures(t, nil, 3)
+	if ct, _ := ft.connector(t); ct != ExchangeConnectorTypeOKX {
+		t.Fatalf("connector = %v, want %v", ct, ExchangeConnectorTypeOKX)
+	}
+	if got := ft.events(t, FailoverEventPinExpired); len(got) != 1 {
+		t.Fatalf("pin expired events = %+v", got)
+	}
+}
@@ -0,0 +1,193 @@
+package failover
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// StateSnapshot 為 getConnector 需要的狀態，一次從 Redis 載入。
+type StateSnapshot struct {
+	Connector     ExchangeConnectorType
+	Generation    int64
+	LockExpiresAt time.Time
+	Pin           *ConnectorPin
+	Maintenance   []MaintenanceWindow
+	LoadedAt      time.Time
+}
+
+func (snap StateSnapshot) Locked(now time.Time) bool {
+	return now.Before(snap.LockExpiresAt)
+}
+
+// Snapshot 以單一 pipeline 讀取 connector、LockTime、pin 與維護時段。
+func (s *StateStore) Snapshot(ctx context.Context) (StateSnapshot, error) {
+	var (
+		connector   *redis.StringCmd
+		generation  *redis.StringCmd
+		lockTTL     *redis.DurationCmd
+		pin         *redis.StringCmd
+		maintenance *redis.MapStringStringCmd
+	)
+	_, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		connector = pipe.Get(ctx, s.config.RedisKeyConnector)
+		generation = pipe.Get(ctx, s.config.RedisKeyGeneration)
+		lockTTL = pipe.PTTL(ctx, s.config.RedisKeyLockTime)
+		pin = pipe.Get(ctx, s.config.RedisKeyPin)
+		maintenance = pipe.HGetAll(ctx, s.config.RedisKeyMaintenance)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return StateSnapshot{}, err
+	}
+
+	now := time.Now()
+	snap := StateSnapshot{
+		Connector: ExchangeConnectorType(connector.Val()),
+		LoadedAt:  now,
+	}
+	if raw := generation.Val(); raw != "" {
+		if snap.Generation, err = parseGeneration(raw); err != nil {
+			return StateSnapshot{}, err
+		}
+	}
+	if ttl := lockTTL.Val(); ttl > 0 {
+		snap.LockExpiresAt = now.Add(ttl)
+	}
+	if raw := pin.Val(); raw != "" {
+		if snap.Pin, err = decodePin(raw); err != nil {
+			return StateSnapshot{}, err
+		}
+	}
+	if snap.Maintenance, err = decodeMaintenanceWindows(maintenance.Val()); err != nil {
+		return StateSnapshot{}, err
+	}
+	return snap, nil
+}
+
+// changed 在狀態寫入後通知其他 instance，通知失敗時由 StateCache 的定期 reconcile 補上。
+func (s *StateStore) changed(ctx context.Context, part string) {
+	if s.onChange != nil {
+		s.onChange()
+	}
+	if err := s.cache.Publish(ctx, s.config.RedisChannelState, part).Err(); err != nil {
+		log.Infof("publish state change %v error: %v", part, err)
+	}
+}
+
+// StateCache 在記憶體保存 StateSnapshot，任一 instance 寫入狀態時經由 Redis pub/sub 重新載入，
+// 並每 StateReconcileInterval 從 Redis reconcile 一次。實作 Kratos transport.Server，
+// 未啟動或快照過舊時 proxy 直接讀 Redis。
+type StateCache struct {
+	store         *StateStore
+	interval      time.Duration
+	probeInterval time.Duration
+
+	mu    sync.RWMutex
+	snap  *StateSnapshot
+	stale bool
+	// gen 在本地寫入時遞增，避免寫入前開始的載入覆蓋掉 stale
+	gen uint64
+
+	// last 為最後一次成功讀到的狀態，降級模式以此為基礎
+	last     *StateSnapshot
+	degraded *degradedState
+
+	reload chan struct{}
+	run    *lifecycle
+}
+
+func NewStateCache(cache redis.UniversalClient, cfg Config) *StateCache {
+	store := NewStateStore(cache, cfg)
+	return &StateCache{
+		store:         store,
+		interval:      store.config.StateReconcileInterval,
+		probeInterval: store.config.DegradedProbeInterval,
+		reload:        make(chan struct{}, 1),
+		run:           newLifecycle(),
+	}
+}
+
+// Get 回傳目前的快照；尚未載入、本地剛寫入或超過兩次 reconcile 未更新時回傳 false。
+func (c *StateCache) Get() (StateSnapshot, bool) {
+	if c == nil {
+		return StateSnapshot{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.snap == nil || c.stale || time.Since(c.snap.LoadedAt) > 2*c.interval {
+		return StateSnapshot{}, false
+	}
+	return *c.snap, true
+}
+
+func (c *StateCache) invalidate() {
+	c.mu.Lock()
+	c.gen++
+	c.stale = true
+	c.mu.Unlock()
+
+	select {
+	case c.reload <- struct{}{}:
+	default:
+	}
+}
+
+// Refresh 立即從 Redis 重新載入快照。
+func (c *StateCache) Refresh(ctx context.Context) error {
+	c.mu.RLock()
+	gen := c.gen
+	c.mu.RUnlock()
+
+	snap, err := c.store.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snap = &snap
+	c.last = &snap
+	if c.gen == gen {
+		c.stale = false
+	}
+	return nil
+}
+
+func (c *StateCache) Start(ctx context.Context) error {
+	if !c.run.begin() {
+		return nil
+	}
+	defer c.run.end()
+	pubsub := c.store.cache.Subscribe(ctx, c.store.config.RedisChannelState)
+	defer pubsub.Close()
+	// 重新連線後會收到 *redis.Subscription，斷線期間漏掉的通知由重新載入補上
+	messages := pubsub.ChannelWithSubscriptions()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Refresh(ctx); err != nil {
+			log.Infof("reload failover state error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.run.stop:
+			return nil
+		case _, ok := <-messages:
+			if !ok {
+				return nil
+			}
+		case <-c.reload:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *StateCache) Stop(ctx context.Context) error {
+	return c.run.shutdown(ctx)
+}
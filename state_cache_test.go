@@ -0,0 +1,96 @@
+package failover
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitSnapshot 等待 StateCache 載入符合條件的快照。
+func waitSnapshot(t *testing.T, c *StateCache, ok func(StateSnapshot) bool) StateSnapshot {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if snap, loaded := c.Get(); loaded && ok(snap) {
+			return snap
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	snap, loaded := c.Get()
+	t.Fatalf("snapshot not updated: %+v, loaded=%v", snap, loaded)
+	return snap
+}
+
+func startStateCache(t *testing.T, c *StateCache) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = c.Start(context.Background())
+	}()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := c.Stop(ctx); err != nil {
+			t.Errorf("stop state cache: %v", err)
+		}
+		<-done
+	})
+}
+
+func TestStateCacheReloadsOnPublishedChange(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestRedis(t)
+	cfg := DefaultConfig
+	// reconcile 間隔遠大於測試時間，快照只會因 pub/sub 通知更新
+	cfg.StateReconcileInterval = time.Hour
+
+	follower := NewStateCache(client, cfg)
+	startStateCache(t, follower)
+	waitSnapshot(t, follower, func(snap StateSnapshot) bool { return snap.Generation == 0 })
+
+	// 另一個 instance 寫入，follower 沒有本地寫入
+	writer := NewStateStore(client, cfg)
+	gen, err := writer.Transition(ctx, 0, ExchangeConnectorTypeOKX, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := waitSnapshot(t, follower, func(snap StateSnapshot) bool { return snap.Generation == gen })
+	if snap.Connector != ExchangeConnectorTypeOKX || !snap.Locked(time.Now()) {
+		t.Fatalf("snapshot = %+v, want locked %v", snap, ExchangeConnectorTypeOKX)
+	}
+
+	if err := writer.SaveMaintenanceWindow(ctx, MaintenanceWindow{ID: "mw-1", Connector: ExchangeConnectorTypeBinance, Phase: MaintenancePhaseActive}); err != nil {
+		t.Fatal(err)
+	}
+	waitSnapshot(t, follower, func(snap StateSnapshot) bool { return len(snap.Maintenance) == 1 })
+}
+
+func TestStateCacheLocalWriteInvalidates(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestRedis(t)
+	cfg := DefaultConfig
+	cfg.StateReconcileInterval = time.Hour
+	proxy := NewProxy(WithCache(client), WithConfig(cfg))
+
+	if err := proxy.StateCache.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := proxy.StateCache.Get(); !ok {
+		t.Fatal("snapshot not loaded")
+	}
+
+	// 未啟動的 StateCache 收不到通知，本地寫入後快照立即失效，改讀 Redis
+	if _, err := proxy.state().Transition(ctx, 0, ExchangeConnectorTypeOKX, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if snap, ok := proxy.StateCache.Get(); ok {
+		t.Fatalf("stale snapshot served after local write: %+v", snap)
+	}
+	if err := proxy.StateCache.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if snap, ok := proxy.StateCache.Get(); !ok || snap.Connector != ExchangeConnectorTypeOKX {
+		t.Fatalf("snapshot = %+v, %v, want %v", snap, ok, ExchangeConnectorTypeOKX)
+	}
+}